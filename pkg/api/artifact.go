@@ -32,6 +32,8 @@ type PackageDetails struct {
 	Hash    string      `json:"hash" yaml:"hash" cas:"hash"`
 	Status  meta.Status `json:"status" yaml:"status" cas:"status"`
 	License string      `json:"license,omitempty" yaml:"license"`
+
+	LicenseViolation string `json:"license_violation,omitempty" yaml:"license_violation,omitempty"`
 }
 
 // Copy returns a deep copy of the artifact.
@@ -267,6 +267,7 @@ func (u *LcUser) LoadArtifact(
 }
 
 // LoadArtifacts fetches and returns multiple *lcArtifact for the given hashes and current u, if any.
+// It is safe for concurrent use
 func (u *LcUser) LoadArtifacts(
 	signerID string,
 	hashes []string,
@@ -291,7 +292,7 @@ func (u *LcUser) LoadArtifacts(
 		keys = append(keys, key)
 	}
 
-	itemsExt, errsMsgs, err := u.Client.VerifiedGetExtAtMulti(ctx, keys, make([]uint64, len(keys)))
+	itemsExt, errsMsgs, err := u.verifiedGetExtMulti(ctx, keys)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -0,0 +1,162 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	"github.com/codenotary/immudb/embedded/store"
+	immuschema "github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/vchain-us/ledger-compliance-go/schema"
+)
+
+// stateMutex serializes access to the local ledger state - SDK state cache doesn't support concurrent use
+var stateMutex sync.Mutex
+
+// verifiedGetExtMulti does the same as VerifiedGetExtAtMulti of the SDK, but locks the local ledger state only
+// to read and to update it, so that several requests can be in flight at the same time. Each response is
+// verified against the state it was requested with, and the newest verified state is kept
+func (u *LcUser) verifiedGetExtMulti(ctx context.Context, keys [][]byte) ([]*schema.VerifiableItemExt, []string, error) {
+	state, err := u.getState(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req := &schema.VerifiableGetExtMultiRequest{Requests: make([]*immuschema.VerifiableGetRequest, len(keys))}
+	for i, key := range keys {
+		req.Requests[i] = &immuschema.VerifiableGetRequest{
+			KeyRequest:   &immuschema.KeyRequest{Key: key},
+			ProveSinceTx: state.TxId,
+		}
+	}
+
+	resp, err := u.Client.ServiceClient.VerifiableGetExtMulti(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	itemsExt, errs := resp.GetItems(), resp.GetErrors()
+	if len(itemsExt) != len(keys) || len(errs) != len(keys) {
+		return nil, nil, fmt.Errorf("expected %d entries and %d errors, got %d entries and %d errors",
+			len(keys), len(keys), len(itemsExt), len(errs))
+	}
+
+	newest := state
+	for i, itemExt := range itemsExt {
+		if errs[i] != "" {
+			continue
+		}
+		newState, err := verifyGet(state, itemExt.GetItem(), req.Requests[i].KeyRequest, u.Client.ApiKeyHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		if u.signingPubKey != nil {
+			ok, err := newState.CheckSignature(u.signingPubKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !ok {
+				return nil, nil, store.ErrCorruptedData
+			}
+		}
+		if newState.TxId > newest.TxId {
+			newest = newState
+		}
+	}
+	if newest != state {
+		if err = u.setState(ctx, newest); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return itemsExt, errs, nil
+}
+
+// getState returns the local ledger state, fetching it from the server, if there is none yet
+func (u *LcUser) getState(ctx context.Context) (*immuschema.ImmutableState, error) {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	if err := u.Client.StateService.CacheLock(); err != nil {
+		return nil, err
+	}
+	defer u.Client.StateService.CacheUnlock()
+
+	return u.Client.StateService.GetState(ctx, u.Client.ApiKey)
+}
+
+// setState stores the verified ledger state, unless concurrent request has already stored a newer one
+func (u *LcUser) setState(ctx context.Context, state *immuschema.ImmutableState) error {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	if err := u.Client.StateService.CacheLock(); err != nil {
+		return err
+	}
+	defer u.Client.StateService.CacheUnlock()
+
+	current, err := u.Client.StateService.GetState(ctx, u.Client.ApiKey)
+	if err == nil && current.TxId >= state.TxId {
+		return nil
+	}
+	return u.Client.StateService.SetState(u.Client.ApiKey, state)
+}
+
+// verifyGet verifies inclusion of the entry and consistency of its transaction with the given state, and returns
+// the new state. It is the same verification, as the SDK does
+func verifyGet(state *immuschema.ImmutableState, vEntry *immuschema.VerifiableEntry, kReq *immuschema.KeyRequest,
+	apiKeyHash string) (*immuschema.ImmutableState, error) {
+	entrySpecDigest, err := store.EntrySpecDigestFor(int(vEntry.VerifiableTx.Tx.Header.Version))
+	if err != nil {
+		return nil, err
+	}
+
+	inclusionProof := immuschema.InclusionProofFromProto(vEntry.InclusionProof)
+	dualProof := immuschema.DualProofFromProto(vEntry.VerifiableTx.DualProof)
+
+	var eh [sha256.Size]byte
+	var sourceID, targetID uint64
+	var sourceAlh, targetAlh [sha256.Size]byte
+	var vTx uint64
+	var e *store.EntrySpec
+
+	if vEntry.Entry.ReferencedBy == nil {
+		vTx = vEntry.Entry.Tx
+		e = database.EncodeEntrySpec(kReq.Key, immuschema.KVMetadataFromProto(vEntry.Entry.Metadata), vEntry.Entry.Value)
+	} else {
+		ref := vEntry.Entry.ReferencedBy
+		vTx = ref.Tx
+		e = database.EncodeReference(ref.Key, immuschema.KVMetadataFromProto(ref.Metadata), vEntry.Entry.Key, ref.AtTx)
+	}
+
+	if state.TxId <= vTx {
+		eh = immuschema.DigestFromProto(vEntry.VerifiableTx.DualProof.TargetTxHeader.EH)
+		sourceID, sourceAlh = state.TxId, immuschema.DigestFromProto(state.TxHash)
+		targetID, targetAlh = vTx, dualProof.TargetTxHeader.Alh()
+	} else {
+		eh = immuschema.DigestFromProto(vEntry.VerifiableTx.DualProof.SourceTxHeader.EH)
+		sourceID, sourceAlh = vTx, dualProof.SourceTxHeader.Alh()
+		targetID, targetAlh = state.TxId, immuschema.DigestFromProto(state.TxHash)
+	}
+
+	if !store.VerifyInclusion(inclusionProof, entrySpecDigest(e), eh) {
+		return nil, store.ErrCorruptedData
+	}
+	if state.TxId > 0 && !store.VerifyDualProof(dualProof, sourceID, targetID, sourceAlh, targetAlh) {
+		return nil, store.ErrCorruptedData
+	}
+
+	return &immuschema.ImmutableState{
+		Db:        apiKeyHash,
+		TxId:      targetID,
+		TxHash:    targetAlh[:],
+		Signature: vEntry.VerifiableTx.Signature,
+	}, nil
+}
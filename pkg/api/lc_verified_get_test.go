@@ -0,0 +1,252 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	immuschema "github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	immulogger "github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/signer"
+	sdk "github.com/vchain-us/ledger-compliance-go/grpcclient"
+	"github.com/vchain-us/ledger-compliance-go/schema"
+	"google.golang.org/grpc"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testStateService keeps the ledger state in memory and tracks if it is locked
+type testStateService struct {
+	locked int32
+	txID   uint64
+	txHash []byte
+}
+
+func (s *testStateService) GetState(ctx context.Context, db string) (*immuschema.ImmutableState, error) {
+	return &immuschema.ImmutableState{TxId: s.txID, TxHash: s.txHash}, nil
+}
+
+func (s *testStateService) SetState(db string, state *immuschema.ImmutableState) error {
+	s.txID, s.txHash = state.TxId, state.TxHash
+	return nil
+}
+
+func (s *testStateService) CacheLock() error {
+	atomic.StoreInt32(&s.locked, 1)
+	return nil
+}
+
+func (s *testStateService) CacheUnlock() error {
+	atomic.StoreInt32(&s.locked, 0)
+	return nil
+}
+
+// testLcService answers that no key is found, after a delay, and counts requests running at the same time
+type testLcService struct {
+	schema.LcServiceClient
+	state               *testStateService
+	running, maxRunning int32
+	lockedDuringRequest int32
+}
+
+func (s *testLcService) VerifiableGetExtMulti(ctx context.Context, in *schema.VerifiableGetExtMultiRequest,
+	opts ...grpc.CallOption) (*schema.VerifiableGetExtMultiResponse, error) {
+	running := atomic.AddInt32(&s.running, 1)
+	defer atomic.AddInt32(&s.running, -1)
+	for {
+		max := atomic.LoadInt32(&s.maxRunning)
+		if running <= max || atomic.CompareAndSwapInt32(&s.maxRunning, max, running) {
+			break
+		}
+	}
+	if atomic.LoadInt32(&s.state.locked) != 0 {
+		atomic.StoreInt32(&s.lockedDuringRequest, 1)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	resp := &schema.VerifiableGetExtMultiResponse{
+		Items:  make([]*schema.VerifiableItemExt, len(in.Requests)),
+		Errors: make([]string, len(in.Requests)),
+	}
+	for i := range in.Requests {
+		resp.Errors[i] = "tbtree: key not found"
+	}
+	return resp, nil
+}
+
+func TestLoadArtifactsConcurrently(t *testing.T) {
+	state := &testStateService{}
+	service := &testLcService{state: state}
+	u := &LcUser{Client: &sdk.LcClient{ApiKey: "signer.key", ServiceClient: service, StateService: state}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			artifacts, verified, errs, err := u.LoadArtifacts("signer", []string{"aa", "bb"}, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, []*LcArtifact{nil, nil}, artifacts)
+			assert.Equal(t, []bool{false, false}, verified)
+			assert.Equal(t, []error{ErrNotFound, ErrNotFound}, errs)
+		}()
+	}
+	wg.Wait()
+
+	assert.Greater(t, int(service.maxRunning), 1, "requests must be in flight at the same time")
+	assert.Zero(t, service.lockedDuringRequest, "ledger state must not be locked during request")
+}
+
+// testLedger commits two transactions, with a key in each, to the local store and returns the verifiable entry
+// of the key from the second transaction, and the state after the first one, which the entry is proven since
+func testLedger(t *testing.T, key, value string) (*immuschema.VerifiableEntry, *immuschema.ImmutableState) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions().WithLogger(immulogger.NewSimpleLogger("immudb", ioutil.Discard)))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer st.Close()
+
+	commit := func(key, value string) *store.TxHeader {
+		tx, err := st.NewWriteOnlyTx()
+		assert.NoError(t, err)
+		e := database.EncodeEntrySpec([]byte(key), nil, []byte(value))
+		assert.NoError(t, tx.Set(e.Key, e.Metadata, e.Value))
+		hdr, err := tx.Commit()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return hdr
+	}
+	hdr1 := commit("other", "value")
+	hdr2 := commit(key, value)
+
+	tx1, tx2 := st.NewTxHolder(), st.NewTxHolder()
+	assert.NoError(t, st.ReadTx(hdr1.ID, tx1))
+	assert.NoError(t, st.ReadTx(hdr2.ID, tx2))
+	inclusionProof, err := tx2.Proof(database.EncodeKey([]byte(key)))
+	assert.NoError(t, err)
+	dualProof, err := st.DualProof(tx1, tx2)
+	assert.NoError(t, err)
+
+	alh := hdr1.Alh()
+	return &immuschema.VerifiableEntry{
+		Entry: &immuschema.Entry{Tx: hdr2.ID, Key: []byte(key), Value: []byte(value)},
+		VerifiableTx: &immuschema.VerifiableTx{
+			Tx:        immuschema.TxToProto(tx2),
+			DualProof: immuschema.DualProofToProto(dualProof),
+		},
+		InclusionProof: immuschema.InclusionProofToProto(inclusionProof),
+	}, &immuschema.ImmutableState{TxId: hdr1.ID, TxHash: alh[:]}
+}
+
+func TestVerifyGet(t *testing.T) {
+	vEntry, state := testLedger(t, "key", "value")
+	kReq := &immuschema.KeyRequest{Key: []byte("key")}
+
+	newState, err := verifyGet(state, vEntry, kReq, "db")
+	assert.NoError(t, err)
+	assert.Equal(t, "db", newState.Db)
+	assert.Equal(t, vEntry.Entry.Tx, newState.TxId)
+	alh := immuschema.DualProofFromProto(vEntry.VerifiableTx.DualProof).TargetTxHeader.Alh()
+	assert.Equal(t, alh[:], newState.TxHash)
+
+	// forged value
+	vEntry.Entry.Value = []byte("forged")
+	_, err = verifyGet(state, vEntry, kReq, "db")
+	assert.Equal(t, store.ErrCorruptedData, err)
+	vEntry.Entry.Value = []byte("value")
+
+	// entry of another key
+	_, err = verifyGet(state, vEntry, &immuschema.KeyRequest{Key: []byte("other")}, "db")
+	assert.Equal(t, store.ErrCorruptedData, err)
+
+	// transaction isn't consistent with the local state
+	forged := &immuschema.ImmutableState{TxId: state.TxId, TxHash: make([]byte, len(state.TxHash))}
+	_, err = verifyGet(forged, vEntry, kReq, "db")
+	assert.Equal(t, store.ErrCorruptedData, err)
+}
+
+// testEntryService returns the same verifiable entry for all keys
+type testEntryService struct {
+	schema.LcServiceClient
+	vEntry *immuschema.VerifiableEntry
+}
+
+func (s *testEntryService) VerifiableGetExtMulti(ctx context.Context, in *schema.VerifiableGetExtMultiRequest,
+	opts ...grpc.CallOption) (*schema.VerifiableGetExtMultiResponse, error) {
+	resp := &schema.VerifiableGetExtMultiResponse{
+		Items:  make([]*schema.VerifiableItemExt, len(in.Requests)),
+		Errors: make([]string, len(in.Requests)),
+	}
+	for i := range in.Requests {
+		resp.Items[i] = &schema.VerifiableItemExt{Item: s.vEntry}
+	}
+	return resp, nil
+}
+
+func TestVerifiedGetExtMultiSignature(t *testing.T) {
+	vEntry, state := testLedger(t, "key", "value")
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	forgerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	alh := immuschema.DualProofFromProto(vEntry.VerifiableTx.DualProof).TargetTxHeader.Alh()
+	signed := &immuschema.ImmutableState{Db: "db", TxId: vEntry.Entry.Tx, TxHash: alh[:]}
+	sign := func(key *ecdsa.PrivateKey) *immuschema.Signature {
+		sig, pub, err := signer.NewSignerFromPKey(rand.Reader, key).Sign(signed.ToBytes())
+		assert.NoError(t, err)
+		return &immuschema.Signature{Signature: sig, PublicKey: pub}
+	}
+	keys := [][]byte{[]byte("key")}
+
+	for _, c := range []struct {
+		name      string
+		signature *immuschema.Signature
+		ok        bool
+	}{
+		{"unsigned", nil, false},
+		{"forged", sign(forgerKey), false},
+		{"signed", sign(serverKey), true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			stateService := &testStateService{txID: state.TxId, txHash: state.TxHash}
+			vEntry.VerifiableTx.Signature = c.signature
+			u := &LcUser{
+				Client: &sdk.LcClient{
+					ApiKey:        "signer.key",
+					ApiKeyHash:    "db",
+					ServiceClient: &testEntryService{vEntry: vEntry},
+					StateService:  stateService,
+				},
+				signingPubKey: &serverKey.PublicKey,
+			}
+			items, errs, err := u.verifiedGetExtMulti(context.Background(), keys)
+			if !c.ok {
+				assert.Error(t, err)
+				assert.Equal(t, state.TxId, stateService.txID, "state must not be updated")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []string{""}, errs)
+			assert.Len(t, items, 1)
+			assert.Equal(t, vEntry.Entry.Tx, stateService.txID)
+		})
+	}
+}
@@ -26,6 +26,8 @@ import (
 type LcUser struct {
 	Client     *sdk.LcClient
 	PrivateKey *ed25519.PrivateKey
+
+	signingPubKey *ecdsa.PublicKey // public key of the server, verified states must be signed with
 }
 
 const (
@@ -88,7 +90,8 @@ func GetOrCreateLcUser(lcApiKey, lcLedger, host, port, lcCert string, skipTlsVer
 		return nil, err
 	}
 	return &LcUser{
-		Client: client,
+		Client:        client,
+		signingPubKey: signingPubKey,
 	}, nil
 }
 
@@ -10,7 +10,9 @@ package artifact
 
 import (
 	"fmt"
+	"os"
 	"sort"
+	"strings"
 
 	"github.com/fatih/color"
 
@@ -24,6 +26,7 @@ const (
 	ColNameVersion ColumnID = 1 << iota
 	ColHash
 	ColTrustLevel
+	ColVulnerabilities
 	MaxColumn = iota
 )
 
@@ -34,6 +37,13 @@ const (
 	Progress
 )
 
+// Warnf prints the warning about resolved dependencies to stderr, unless output is silent
+func Warnf(output OutputOptions, format string, args ...interface{}) {
+	if output != Silent {
+		fmt.Fprintf(os.Stderr, format, args...)
+	}
+}
+
 // Artifact is a result of build process.
 // It is a language- and/or environment-specific interface which finds dependencies
 type Artifact interface {
@@ -43,6 +53,20 @@ type Artifact interface {
 	ResolveDependencies(output OutputOptions) ([]Dependency, error)
 }
 
+// AssetHasher is implemented by artifacts, which know the hash of the asset they are resolved from, like
+// images, read without Docker daemon
+type AssetHasher interface {
+	AssetHash() string
+}
+
+// AssetHash returns the hash of the asset, the artifact is resolved from, or empty string if it is unknown
+func AssetHash(a Artifact) string {
+	if h, ok := a.(AssetHasher); ok {
+		return h.AssetHash()
+	}
+	return ""
+}
+
 type GenericArtifact struct {
 	Deps []Dependency
 }
@@ -51,6 +75,13 @@ type LoadedArtifact struct {
 	GenericArtifact
 	path string
 	kind string
+
+	Warnings []string // found when loading dependencies, reported when they are resolved
+}
+
+// NewLoadedArtifact returns artifact with already known dependencies
+func NewLoadedArtifact(path, kind string, deps []Dependency) *LoadedArtifact {
+	return &LoadedArtifact{GenericArtifact: GenericArtifact{Deps: deps}, path: path, kind: kind}
 }
 
 func (a GenericArtifact) Dependencies() []Dependency {
@@ -98,7 +129,25 @@ func Display(a Artifact, columns ColumnID) {
 			case Unsupported, Untrusted:
 				color.Set(meta.StyleError())
 			}
-			fmt.Print(TrustLevelName(dep.TrustLevel))
+			if ColVulnerabilities&columns != 0 {
+				fmt.Printf("%-*s", len(levelText[Unsupported])+1, TrustLevelName(dep.TrustLevel))
+			} else {
+				fmt.Print(TrustLevelName(dep.TrustLevel))
+			}
+			color.Unset()
+		}
+		if ColVulnerabilities&columns != 0 && len(dep.Vulnerabilities) > 0 {
+			switch dep.MaxVulnerabilitySeverity() {
+			case SeverityHigh, SeverityCritical:
+				color.Set(meta.StyleError())
+			default:
+				color.Set(meta.StyleWarning())
+			}
+			vulns := make([]string, len(dep.Vulnerabilities))
+			for i, v := range dep.Vulnerabilities {
+				vulns[i] = v.ID + " (" + SeverityName(v.Severity) + ")"
+			}
+			fmt.Print(strings.Join(vulns, ", "))
 			color.Unset()
 		}
 		fmt.Println()
@@ -118,5 +167,8 @@ func (a LoadedArtifact) Dependencies() []Dependency {
 }
 
 func (a LoadedArtifact) ResolveDependencies(output OutputOptions) ([]Dependency, error) {
+	for _, w := range a.Warnings {
+		Warnf(output, "%s\n", w)
+	}
 	return a.Deps, nil
 }
@@ -11,6 +11,7 @@ package artifact
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/codenotary/cas/pkg/api"
@@ -50,7 +51,7 @@ const (
 
 var levelText = [MaxTrustLevel + 1]string{"", "Untrusted", "Unsupported", "Unknown", "Trusted"}
 
-const MaxGoroutines = 8 // used by other packages that query components from external sources
+const MaxGoroutines = 8 // default number of concurrent requests to external sources
 
 type DepType bool
 
@@ -68,9 +69,24 @@ type Dependency struct {
 	HashType   HashType
 	TrustLevel TrustLevel // set by Notorize/Authenticate
 	SignerID   string     // set by Notorize/Authenticate
-	License    string
+	License    string     // SPDX license expression
+	Supplier   string     // distributor of the dependency, optionally with e-mail: 'name <e-mail>'
 	Timestamp  time.Time
 	Type       DepType
+	Properties map[string]string // optional environment-specific details, like build settings
+	Requires   []DepRef          // dependencies of the same kind, required by this one, if known
+
+	LicenseDeclared string   // license as stated by the package, if it differs from normalized License
+	CPEs            []string // candidate CPE 2.3 names, the most likely first
+
+	Vulnerabilities  []Vulnerability // set by vulnerability matching
+	LicenseViolation string          // set by license policy check, if license is not compliant
+}
+
+// DepRef refers to another dependency by its name and version
+type DepRef struct {
+	Name    string
+	Version string
 }
 
 func HashTypeName(hashType HashType) string {
@@ -102,6 +118,7 @@ func AuthenticateDependencies(
 	signerID string,
 	deps []Dependency,
 	batchSize int,
+	concurrency int,
 	progressCallback func([]Dependency),
 ) ([]error, error) {
 	if len(deps) == 0 {
@@ -113,36 +130,26 @@ func AuthenticateDependencies(
 		hashes = append(hashes, dep.Hash)
 	}
 
-	if batchSize <= 0 {
-		batchSize = len(hashes)
-	}
-	nbBatches := len(hashes) / int(batchSize)
-	if nbBatches*batchSize < len(hashes) {
-		nbBatches++
-	}
-
-	var artifacts []*api.LcArtifact
-	var verified []bool
-	var errs []error
-
-	for i := 0; i < nbBatches; i++ {
-		startAt := i * batchSize
-		endBefore := startAt + batchSize
-		if endBefore > len(hashes) {
-			endBefore = len(hashes)
-		}
+	artifacts := make([]*api.LcArtifact, len(deps))
+	verified := make([]bool, len(deps))
+	errs := make([]error, len(deps))
 
+	err := runBatches(len(deps), batchSize, concurrency, func(startAt, endBefore int) error {
 		currArtifacts, currVerified, currErrs, err := lcUser.LoadArtifacts(signerID, hashes[startAt:endBefore], nil)
+		if err != nil {
+			return err
+		}
+		copy(artifacts[startAt:endBefore], currArtifacts)
+		copy(verified[startAt:endBefore], currVerified)
+		copy(errs[startAt:endBefore], currErrs)
+		return nil
+	}, func(startAt, endBefore int) {
 		if progressCallback != nil {
 			progressCallback(deps[startAt:endBefore])
 		}
-		if err != nil {
-			return nil, err
-		}
-
-		artifacts = append(artifacts, currArtifacts...)
-		verified = append(verified, currVerified...)
-		errs = append(errs, currErrs...)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	retErrs := make([]error, len(deps))
@@ -180,6 +187,7 @@ func NotarizeDependencies(
 	kinds []string,
 	deps []*Dependency,
 	batchSize int,
+	concurrency int,
 	progressCallback func([]*Dependency),
 ) error {
 	if len(deps) == 0 {
@@ -190,36 +198,26 @@ func NotarizeDependencies(
 		return fmt.Errorf("number of kinds (%d) and dependencies (%d) must match", len(kinds), len(deps))
 	}
 
-	if batchSize <= 0 {
-		batchSize = len(deps)
-	}
-	nbBatches := len(deps) / int(batchSize)
-	if nbBatches*batchSize < len(deps) {
-		nbBatches++
-	}
-
-	for i := 0; i < nbBatches; i++ {
-		startAt := i * batchSize
-		endBefore := startAt + batchSize
-		if endBefore > len(deps) {
-			endBefore = len(deps)
-		}
+	err := runBatches(len(deps), batchSize, concurrency, func(startAt, endBefore int) error {
 		currDeps := deps[startAt:endBefore]
+		currKinds := kinds[startAt:endBefore]
 
 		artifacts := make([]*api.Artifact, len(currDeps))
 		options := make([][]api.LcSignOption, len(currDeps))
 		for i, dep := range currDeps {
-			artifacts[i] = ToApiArtifact(kinds[i], dep.Name, dep.Version, dep.Hash, dep.HashType)
+			artifacts[i] = ToApiArtifact(currKinds[i], dep.Name, dep.Version, dep.Hash, dep.HashType)
 			options[i] = []api.LcSignOption{api.LcSignWithStatus(meta.StatusTrusted)}
 		}
 
 		_, err := lcUser.SignMulti(artifacts, options)
+		return err
+	}, func(startAt, endBefore int) {
 		if progressCallback != nil {
-			progressCallback(currDeps)
-		}
-		if err != nil {
-			return fmt.Errorf("notarization of %d dependencies failed: %w", len(deps), err)
+			progressCallback(deps[startAt:endBefore])
 		}
+	})
+	if err != nil {
+		return fmt.Errorf("notarization of %d dependencies failed: %w", len(deps), err)
 	}
 
 	signerID := api.GetSignerIDByApiKey(lcUser.Client.ApiKey)
@@ -231,6 +229,64 @@ func NotarizeDependencies(
 	return nil
 }
 
+// runBatches splits count items into batches of up to batchSize items (all items at once, if batchSize is 0),
+// and calls process for each batch, running up to concurrency batches at the same time. Once the batch is
+// processed, done is called with the same bounds - one batch at a time, so it needs no synchronization.
+// After the first failure no new batches are started, and the error of the earliest failed batch is returned
+func runBatches(count, batchSize, concurrency int, process func(startAt, endBefore int) error,
+	done func(startAt, endBefore int)) error {
+	if batchSize <= 0 {
+		batchSize = count
+	}
+	nbBatches := (count + batchSize - 1) / batchSize
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > nbBatches {
+		concurrency = nbBatches
+	}
+
+	var mutex sync.Mutex
+	var firstErr error
+	errAt := count
+	batches := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for startAt := range batches {
+				endBefore := startAt + batchSize
+				if endBefore > count {
+					endBefore = count
+				}
+				err := process(startAt, endBefore)
+
+				mutex.Lock()
+				done(startAt, endBefore)
+				if err != nil && startAt < errAt {
+					firstErr, errAt = err, startAt
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for startAt := 0; startAt < count; startAt += batchSize {
+		mutex.Lock()
+		failed := firstErr != nil
+		mutex.Unlock()
+		if failed {
+			break
+		}
+		batches <- startAt
+	}
+	close(batches)
+	wg.Wait()
+
+	return firstErr
+}
+
 // ToApiArtifact ...
 func ToApiArtifact(kind, name, version, hash string, hashType HashType) *api.Artifact {
 	return &api.Artifact{
@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package artifact
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunBatches(t *testing.T) {
+	for _, tc := range []struct {
+		count, batchSize, concurrency, batches int
+	}{
+		{count: 25, batchSize: 10, concurrency: 8, batches: 3},
+		{count: 25, batchSize: 10, concurrency: 0, batches: 3},
+		{count: 100, batchSize: 1, concurrency: 4, batches: 100},
+		{count: 7, batchSize: 0, concurrency: 8, batches: 1},
+	} {
+		results := make([]int, tc.count)
+		var running, maxRunning int32
+		processed, batches := 0, 0
+		err := runBatches(tc.count, tc.batchSize, tc.concurrency, func(startAt, endBefore int) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			for i := startAt; i < endBefore; i++ {
+				results[i] = i
+			}
+			atomic.AddInt32(&running, -1)
+			return nil
+		}, func(startAt, endBefore int) {
+			processed += endBefore - startAt
+			batches++
+		})
+		assert.NoError(t, err)
+		for i := range results {
+			assert.Equal(t, i, results[i])
+		}
+		assert.Equal(t, tc.count, processed)
+		assert.Equal(t, tc.batches, batches)
+		concurrency := tc.concurrency
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		assert.LessOrEqual(t, int(maxRunning), concurrency)
+	}
+}
+
+func TestRunBatchesError(t *testing.T) {
+	var started int32
+	err := runBatches(100, 10, 2, func(startAt, endBefore int) error {
+		atomic.AddInt32(&started, 1)
+		if startAt >= 20 {
+			return errors.New("batch failed")
+		}
+		return nil
+	}, func(startAt, endBefore int) {})
+	assert.EqualError(t, err, "batch failed")
+	// batches, which were already dispatched, are finished, but no new ones are started
+	assert.Less(t, int(started), 10)
+}
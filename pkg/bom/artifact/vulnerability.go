@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package artifact
+
+import "strings"
+
+type Severity uint
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+	MaxSeverity = SeverityCritical
+)
+
+var severityText = [MaxSeverity + 1]string{"unknown", "low", "medium", "high", "critical"}
+
+// Vulnerability is a known vulnerability, affecting the dependency
+type Vulnerability struct {
+	ID       string
+	Aliases  []string // IDs of the same vulnerability in other databases, f.e. CVE
+	Summary  string
+	Severity Severity
+	Score    float64 // CVSS base score, 0 if unknown
+	Vector   string  // CVSS vector, the score is calculated from
+	Fixed    string  // version, where vulnerability is fixed, empty if unknown
+}
+
+func SeverityName(severity Severity) string {
+	if severity > MaxSeverity {
+		return severityText[SeverityUnknown]
+	}
+	return severityText[severity]
+}
+
+// SeverityByName returns severity by its case-insensitive name, 'moderate' is accepted as an alias for 'medium'
+func SeverityByName(text string) (Severity, bool) {
+	text = strings.ToLower(text)
+	if text == "moderate" {
+		return SeverityMedium, true
+	}
+	for i := range severityText {
+		if severityText[i] == text {
+			return Severity(i), true
+		}
+	}
+	return SeverityUnknown, false
+}
+
+// MaxVulnerabilitySeverity returns the highest severity of dependency vulnerabilities
+func (d Dependency) MaxVulnerabilitySeverity() Severity {
+	res := SeverityUnknown
+	for _, v := range d.Vulnerabilities {
+		if v.Severity > res {
+			res = v.Severity
+		}
+	}
+	return res
+}
@@ -9,13 +9,146 @@
 package bom
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/conda"
+	"github.com/codenotary/cas/pkg/bom/docker"
+	"github.com/codenotary/cas/pkg/bom/dotnet"
+	"github.com/codenotary/cas/pkg/bom/executor"
+	"github.com/codenotary/cas/pkg/bom/golang"
+	"github.com/codenotary/cas/pkg/bom/java"
+	"github.com/codenotary/cas/pkg/bom/javascript"
+	"github.com/codenotary/cas/pkg/bom/php"
+	"github.com/codenotary/cas/pkg/bom/python"
+	"github.com/codenotary/cas/pkg/bom/ruby"
+	"github.com/codenotary/cas/pkg/bom/rust"
+	"github.com/codenotary/cas/pkg/uri"
 )
 
 // extractor schemes that can be used to point to BOM source
-var BomSchemes = map[string]struct{}{"dir": {}, "git": {}, "docker": {}, "": {}}
+var BomSchemes = map[string]struct{}{"dir": {}, "git": {}, "docker": {}, "docker-archive": {}, "oci": {},
+	"rootfs": {}, "host": {}, "spdx": {}, "cyclonedx": {}, "": {}}
+
+// image and root file system schemes, used for creating executors
+const (
+	SchemeDocker        = "docker"
+	SchemeDockerArchive = "docker-archive"
+	SchemeOCI           = "oci"
+	SchemeRootFS        = "rootfs"
+	SchemeHost          = "host"
+)
+
+// IsBOMOnlyURI returns true if BOM source isn't an asset, which can be notarized or authenticated by itself:
+// existing BOM document, image read without Docker daemon, or root file system. Hash of the asset is specified
+// explicitly, or taken from the source, if it is known, like image ID
+func IsBOMOnlyURI(rawURI string) bool {
+	u, err := uri.Parse(rawURI)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case SchemeSPDX, SchemeCycloneDX, SchemeDockerArchive, SchemeOCI, SchemeRootFS, SchemeHost:
+		return true
+	}
+	return false
+}
+
+// NewFromURI returns Artifact implementation for BOM source URI, or nil if artifact language/environment
+// isn't supported. Images are accessed with Docker daemon ('docker://<image>'), or read from 'docker save'
+// archive ('docker-archive://<file>[:<tag>]') or OCI image layout ('oci://<directory>[:<tag>]'). OS packages
+// are also read from root file system in local directory ('rootfs://<directory>') or of the host ('host://').
+// Existing SBOM documents are loaded as is ('spdx://<file>', 'cyclonedx://<file>')
+func NewFromURI(rawURI string) (artifact.Artifact, error) {
+	u, err := uri.Parse(rawURI)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := BomSchemes[u.Scheme]; !ok {
+		return nil, fmt.Errorf("unsupported URI %s for --bom option", rawURI)
+	}
+	path := rawURI
+	if u.Scheme != "" {
+		path = strings.TrimPrefix(u.Opaque, "//")
+	}
+
+	var ex executor.Executor
+	switch u.Scheme {
+	case SchemeSPDX:
+		a, err := NewFromSPDX(path)
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	case SchemeCycloneDX:
+		a, err := NewFromCycloneDX(path)
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	case SchemeDocker:
+		a, err := docker.New(path)
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	case SchemeDockerArchive:
+		ex, err = executor.NewArchiveExecutor(path)
+	case SchemeOCI:
+		ex, err = executor.NewOCIExecutor(path)
+	case SchemeRootFS:
+		if path, err = filepath.Abs(path); err != nil {
+			return nil, err
+		}
+		ex, err = executor.NewDirExecutor(path)
+	case SchemeHost:
+		// host is identified by its name
+		if path, err = os.Hostname(); err != nil {
+			return nil, err
+		}
+		ex, err = executor.NewDirExecutor("/")
+	default:
+		path, err = filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if a := New(path); a != nil {
+			return a, nil
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	a, err := docker.NewFromExecutor(path, ex)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
 
 // New returns Artifact implementation of type, matching the artifact language/environment
 func New(filename string) artifact.Artifact {
+	for _, fn := range newFuncs {
+		if a := fn(filename); a != nil {
+			return a
+		}
+	}
 	return nil
 }
+
+// language/environment-specific constructors, probed by New() in this order
+var newFuncs = []func(string) artifact.Artifact{
+	golang.New,
+	python.New,
+	javascript.New,
+	java.New,
+	dotnet.New,
+	rust.New,
+	ruby.New,
+	php.New,
+	conda.New,
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package conda
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+const AssetType = "condacom"
+
+const (
+	metaDir     = "conda-meta"
+	historyFile = "history"
+	metaExt     = ".json"
+)
+
+// dependency properties
+const (
+	PropBuild   = "conda.build"
+	PropSubdir  = "conda.subdir"
+	PropChannel = "conda.channel"
+)
+
+// '# update specs: [...]', '# install specs: [...]' and '# remove specs: [...]' lines of history file
+var historySpecsPattern = regexp.MustCompile(`^#\s*(\w+) specs:\s*\[(.*)\]`)
+
+// condaArtifact implements Artifact interface for Conda environments
+type condaArtifact struct {
+	artifact.GenericArtifact
+	path    string
+	metaDir string
+}
+
+// packageMeta is content of conda-meta/<name>-<version>-<build>.json
+type packageMeta struct {
+	Name          string   `json:"name"`
+	Version       string   `json:"version"`
+	Build         string   `json:"build"`
+	Channel       string   `json:"channel"`
+	Subdir        string   `json:"subdir"`
+	Depends       []string `json:"depends"`
+	License       string   `json:"license"`
+	SHA256        string   `json:"sha256"`
+	MD5           string   `json:"md5"`
+	RequestedSpec string   `json:"requested_spec"`
+}
+
+// New returns new Artifact object, or nil if filename doesn't refer to Conda environment or its conda-meta
+// directory
+func New(filename string) artifact.Artifact {
+	fi, err := os.Stat(filename)
+	if err != nil || !fi.IsDir() {
+		return nil
+	}
+
+	if filepath.Base(filename) == metaDir {
+		return &condaArtifact{path: filename, metaDir: filename}
+	}
+	dir := filepath.Join(filename, metaDir)
+	if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+		return &condaArtifact{path: filename, metaDir: dir}
+	}
+	return nil
+}
+
+func (a condaArtifact) Type() string {
+	return AssetType
+}
+
+func (a condaArtifact) Path() string {
+	return a.path
+}
+
+func (a *condaArtifact) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	if a.Deps != nil {
+		return a.Deps, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(a.metaDir, "*"+metaExt))
+	if err != nil {
+		return nil, err
+	}
+	metas := make([][]byte, 0, len(files))
+	for _, f := range files {
+		buf, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", f, err)
+		}
+		metas = append(metas, buf)
+	}
+	history, err := ioutil.ReadFile(filepath.Join(a.metaDir, historyFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot read history: %w", err)
+	}
+
+	deps, err := dependencies(a.path, metas, history)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Deps = deps
+	return deps, nil
+}
+
+// dependencies builds dependency graph from package metadata. Packages explicitly requested by the user
+// are direct ones
+func dependencies(root string, metas [][]byte, history []byte) ([]artifact.Dependency, error) {
+	packages := make(map[string]*packageMeta, len(metas))
+	for _, buf := range metas {
+		var p packageMeta
+		if err := json.Unmarshal(buf, &p); err != nil {
+			return nil, fmt.Errorf("cannot parse package metadata: %w", err)
+		}
+		if p.Name != "" {
+			packages[p.Name] = &p
+		}
+	}
+
+	g := depgraph.NewGraph(root, "")
+	for _, p := range packages {
+		hash, hashType := p.SHA256, artifact.HashSHA256
+		if hash == "" {
+			hash, hashType = p.MD5, artifact.HashMD5 // older Conda versions
+		}
+		props := map[string]string{PropBuild: p.Build}
+		if p.Subdir != "" {
+			props[PropSubdir] = p.Subdir
+		}
+		if p.Channel != "" {
+			props[PropChannel] = p.Channel
+		}
+		g.NewNode(p.Name, p.Version, &artifact.Dependency{
+			Name:       p.Name,
+			Version:    p.Version,
+			Hash:       hash,
+			HashType:   hashType,
+			Kind:       AssetType,
+			License:    p.License,
+			Properties: props,
+		})
+	}
+
+	direct := requestedPackages(history)
+	required := make(map[string]bool, len(packages))
+	for _, p := range packages {
+		parent := g.Node(p.Name, p.Version)
+		for _, spec := range p.Depends {
+			name := specName(spec)
+			required[name] = true
+			if child, ok := packages[name]; ok {
+				g.AddChild(parent, child.Name, child.Version, nil)
+			}
+		}
+		if p.RequestedSpec != "" {
+			direct[p.Name] = true
+		}
+	}
+	if len(direct) == 0 {
+		// history is unknown - packages not required by others are considered direct
+		for name := range packages {
+			direct[name] = !required[name]
+		}
+	}
+	for name, requested := range direct {
+		if p, ok := packages[name]; ok && requested {
+			g.AddChild(g.Root, p.Name, p.Version, nil)
+		}
+	}
+
+	return g.FlatDeps(), nil
+}
+
+// requestedPackages returns names of packages, explicitly installed and not removed later, according
+// to environment history
+func requestedPackages(history []byte) map[string]bool {
+	res := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(history))
+	for scanner.Scan() {
+		match := historySpecsPattern.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		for _, spec := range strings.Split(match[2], ",") {
+			name := specName(strings.Trim(strings.TrimSpace(spec), `'"`))
+			if name == "" {
+				continue
+			}
+			res[name] = match[1] != "remove"
+		}
+	}
+	return res
+}
+
+// specName extracts package name from match specification, like 'python >=3.9,<3.10.0a0',
+// 'conda-forge::numpy=1.21' or 'pandas[version='>=1.3']'
+func specName(spec string) string {
+	if i := strings.LastIndex(spec, "::"); i >= 0 {
+		spec = spec[i+2:]
+	}
+	end := strings.IndexAny(spec, " =<>!~[")
+	if end >= 0 {
+		spec = spec[:end]
+	}
+	return strings.ToLower(strings.TrimSpace(spec))
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package conda
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+var testMetas = map[string]string{
+	"numpy-1.21.2-py39h20f2e39_0.json": `{"name": "numpy", "version": "1.21.2", "build": "py39h20f2e39_0",
+		"channel": "https://repo.anaconda.com/pkgs/main/linux-64", "subdir": "linux-64",
+		"depends": ["libgcc-ng >=7.5.0", "python >=3.9,<3.10.0a0"], "license": "BSD-3-Clause",
+		"md5": "1f0f7ae9c0d2f1c4d0a6e6b0d0c7e0a1",
+		"sha256": "0b1b9a5c8c5b2b5d4e1d1fbc6f1e4d3ad0c8a9c6c1f4e6e0b8b7d2e7f2a1c3d4", "requested_spec": "numpy"}`,
+	"python-3.9.7-h12debd9_1.json": `{"name": "python", "version": "3.9.7", "build": "h12debd9_1",
+		"depends": ["libgcc-ng >=7.5.0"], "license": "Python-2.0", "md5": "a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3",
+		"requested_spec": ""}`,
+	"libgcc-ng-9.3.0-h5101ec6_17.json": `{"name": "libgcc-ng", "version": "9.3.0", "build": "h5101ec6_17",
+		"license": "GPL-3.0-only WITH GCC-exception-3.1",
+		"sha256": "a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2"}`,
+}
+
+const testHistory = `==> 2021-10-01 10:00:00 <==
+# cmd: /opt/conda/bin/conda create -n env python=3.9
+# conda version: 4.10.3
++defaults/linux-64::python-3.9.7-h12debd9_1
+# update specs: ['python=3.9']
+==> 2021-10-01 10:05:00 <==
+# cmd: /opt/conda/bin/conda install numpy
+# update specs: ["numpy"]
+`
+
+func TestEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, metaDir), 0755))
+	for name, content := range testMetas {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, metaDir, name), []byte(content), 0644))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, metaDir, historyFile), []byte(testHistory), 0644))
+
+	a := New(dir)
+	assert.NotNil(t, a)
+	assert.Equal(t, AssetType, a.Type())
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+
+	expected := map[string]artifact.Dependency{
+		"numpy": {Version: "1.21.2", Hash: "0b1b9a5c8c5b2b5d4e1d1fbc6f1e4d3ad0c8a9c6c1f4e6e0b8b7d2e7f2a1c3d4",
+			HashType: artifact.HashSHA256, License: "BSD-3-Clause", Type: artifact.DepDirect},
+		"python": {Version: "3.9.7", Hash: "a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3", HashType: artifact.HashMD5,
+			License: "Python-2.0", Type: artifact.DepDirect},
+		"libgcc-ng": {Version: "9.3.0", Hash: "a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2",
+			HashType: artifact.HashSHA256, License: "GPL-3.0-only WITH GCC-exception-3.1", Type: artifact.DepTransient},
+	}
+	assert.Len(t, deps, len(expected))
+	for _, d := range deps {
+		e, ok := expected[d.Name]
+		if !assert.True(t, ok, d.Name) {
+			continue
+		}
+		assert.Equal(t, e.Version, d.Version)
+		assert.Equal(t, e.Hash, d.Hash)
+		assert.Equal(t, e.HashType, d.HashType)
+		assert.Equal(t, e.License, d.License)
+		assert.Equal(t, e.Type, d.Type, d.Name)
+	}
+}
+
+func TestSpecName(t *testing.T) {
+	for spec, name := range map[string]string{
+		"python >=3.9,<3.10.0a0":       "python",
+		"conda-forge::numpy=1.21":      "numpy",
+		"pandas[version='>=1.3']":      "pandas",
+		"libgcc-ng":                    "libgcc-ng",
+		"defaults/linux-64::openssl>1": "openssl",
+	} {
+		assert.Equal(t, name, specName(spec), spec)
+	}
+}
+
+func TestInstalledPackages(t *testing.T) {
+	root := t.TempDir()
+	env := filepath.Join(root, "opt", "conda", metaDir)
+	broken := filepath.Join(root, "opt", "conda", "envs", "broken", metaDir)
+	assert.NoError(t, os.MkdirAll(env, 0755))
+	assert.NoError(t, os.MkdirAll(broken, 0755))
+	for name, content := range testMetas {
+		assert.NoError(t, os.WriteFile(filepath.Join(env, name), []byte(content), 0644))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(broken, "broken-1.0-0.json"), []byte("{"), 0644))
+
+	e, err := executor.NewDirExecutor(root)
+	assert.NoError(t, err)
+	deps, err := InstalledPackages(e, artifact.Silent)
+	assert.NoError(t, err)
+	assert.Len(t, deps, len(testMetas))
+}
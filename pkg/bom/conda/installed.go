@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package conda
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+// Conda installation locations in official Miniconda and Anaconda images, and common user installations
+var envGlobs = []string{"/opt/conda", "/opt/conda/envs/*", "/opt/miniconda3", "/opt/miniconda3/envs/*",
+	"/opt/anaconda3", "/opt/anaconda3/envs/*", "/root/miniconda3", "/root/miniconda3/envs/*",
+	"/root/anaconda3", "/root/anaconda3/envs/*", "/usr/local"}
+
+// InstalledPackages finds packages of Conda environments, accessible with executor. Environments with malformed
+// metadata are skipped
+func InstalledPackages(e executor.Executor, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	globs := make([]string, len(envGlobs))
+	for i, env := range envGlobs {
+		globs[i] = env + "/" + metaDir
+	}
+	dirs, err := executor.Glob(e, globs)
+	if err != nil {
+		// no shell - try locations without wildcards
+		dirs = dirs[:0]
+		for _, glob := range globs {
+			if !strings.Contains(glob, "*") {
+				dirs = append(dirs, glob)
+			}
+		}
+	}
+
+	var res []artifact.Dependency
+	for _, dir := range dirs {
+		metas, history, err := readMetaDir(e, dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(metas) == 0 {
+			continue
+		}
+		deps, err := dependencies(path.Dir(dir), metas, history)
+		if err != nil {
+			artifact.Warnf(output, "Cannot process %s, skipping: %v\n", dir, err)
+			continue
+		}
+		res = append(res, deps...)
+	}
+	return res, nil
+}
+
+// readMetaDir reads package metadata and history from conda-meta directory. Missing directory isn't an error
+func readMetaDir(e executor.Executor, dir string) ([][]byte, []byte, error) {
+	reader, err := e.ReadDir(dir)
+	if err != nil {
+		return nil, nil, nil // no such directory
+	}
+	defer reader.Close()
+
+	var metas [][]byte
+	var history []byte
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading directory %s: %w", dir, err)
+		}
+		// file name has a form of 'conda-meta/<file>'
+		if !hdr.FileInfo().Mode().IsRegular() || path.Dir(hdr.Name) != metaDir {
+			continue
+		}
+		base := path.Base(hdr.Name)
+		if base != historyFile && path.Ext(base) != metaExt {
+			continue
+		}
+		buf, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading file %s: %w", hdr.Name, err)
+		}
+		if base == historyFile {
+			history = buf
+		} else {
+			metas = append(metas, buf)
+		}
+	}
+	return metas, history, nil
+}
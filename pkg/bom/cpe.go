@@ -0,0 +1,278 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package bom
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	purl "github.com/package-url/packageurl-go"
+	"gopkg.in/yaml.v3"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/docker"
+)
+
+// CPE 2.3 formatted string binding, see https://nvlpubs.nist.gov/nistpubs/Legacy/IR/nistir7695.pdf
+const (
+	cpePrefix = "cpe:2.3:"
+	cpeParts  = 13 // 'cpe', '2.3' and 11 attributes
+	cpeAny    = "*"
+)
+
+// characters, which must be quoted in CPE attribute
+const cpeSpecialChars = "\\!\"#$%&'()*+,/:;<=>?@[]^`{|}~"
+
+// prefixes of Maven group IDs, followed by the vendor name
+var mavenDomains = map[string]bool{"org": true, "com": true, "net": true, "io": true, "dev": true, "de": true}
+
+// hosts of Go modules, where module path is '<host>/<owner>/<repo>'
+var goHosts = map[string]bool{"github.com": true, "gitlab.com": true, "bitbucket.org": true}
+
+// CPEOverride sets CPE names for packages, matching package URL. Override URL matches packages regardless of
+// version and qualifiers, and regardless of namespace, if it has none. CPE names may omit trailing attributes,
+// and version, if omitted or '*', is taken from the package. Empty list of CPEs means that package has no CPE name
+type CPEOverride struct {
+	Purl string   `yaml:"purl"`
+	CPEs []string `yaml:"cpes"`
+
+	url purl.PackageURL
+}
+
+// LoadCPEOverrides reads the list of CPE overrides from YAML file
+func LoadCPEOverrides(filename string) ([]CPEOverride, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var overrides []CPEOverride
+	dec := yaml.NewDecoder(bytes.NewReader(buf))
+	dec.KnownFields(true)
+	if err := dec.Decode(&overrides); err != nil {
+		return nil, fmt.Errorf("cannot parse CPE overrides %s: %w", filename, err)
+	}
+	for i := range overrides {
+		overrides[i].url, err = purl.FromString(overrides[i].Purl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid package URL '%s' in CPE overrides %s: %w", overrides[i].Purl, filename, err)
+		}
+		for _, cpe := range overrides[i].CPEs {
+			if !strings.HasPrefix(cpe, cpePrefix) || len(splitCPE(cpe)) > cpeParts {
+				return nil, fmt.Errorf("invalid CPE name '%s' in CPE overrides %s", cpe, filename)
+			}
+		}
+	}
+	return overrides, nil
+}
+
+// GenerateCPEs sets candidate CPE names for all dependencies of the artifact, which don't have them yet, unless
+// the dependency matches one of overrides
+func GenerateCPEs(a artifact.Artifact, overrides []CPEOverride) {
+	deps := a.Dependencies()
+	for i := range deps { // dependencies are updated in place, so use the index
+		p := purlOf(a, deps[i])
+		if override := findCPEOverride(overrides, p); override != nil {
+			deps[i].CPEs = make([]string, len(override.CPEs))
+			for j, cpe := range override.CPEs {
+				deps[i].CPEs[j] = completeCPE(cpe, cpeVersion(p, deps[i].Version))
+			}
+		} else if len(deps[i].CPEs) == 0 {
+			deps[i].CPEs = CPEs(a, deps[i])
+		}
+	}
+}
+
+// CPEs returns candidate CPE names of the dependency, guessed from its name, as vendor and product names
+// in NVD often differ from package names
+func CPEs(a artifact.Artifact, d artifact.Dependency) []string {
+	p := purlOf(a, d)
+	var candidates [][2]string // vendor and product
+	switch p.Type {
+	case typeDeb, typeApk, purl.TypeRPM:
+		// upstream project is better identified by the source package
+		name := p.Name
+		if source := d.Properties[docker.PropSource]; source != "" {
+			name = source
+		}
+		candidates = [][2]string{{name, name}}
+	case purl.TypeGolang:
+		candidates = goCPECandidates(p.Namespace, p.Name)
+	case purl.TypeNPM:
+		if p.Namespace != "" {
+			candidates = [][2]string{{strings.TrimPrefix(p.Namespace, "@"), p.Name}}
+		}
+		candidates = append(candidates, projectCPECandidates(p.Name)...)
+	case purl.TypeMaven:
+		candidates = mavenCPECandidates(p.Namespace, p.Name)
+	case purl.TypeComposer:
+		if p.Namespace != "" {
+			candidates = [][2]string{{p.Namespace, p.Name}}
+		}
+		candidates = append(candidates, projectCPECandidates(p.Name)...)
+	case purl.TypePyPi, purl.TypeGem, typeCargo, purl.TypeNuget, typeConda:
+		candidates = projectCPECandidates(p.Name)
+	case purl.TypeDocker:
+		return nil
+	default:
+		candidates = [][2]string{{p.Name, p.Name}}
+	}
+
+	seen := make(map[string]bool)
+	var res []string
+	for _, c := range candidates {
+		cpe := formatCPE(c[0], c[1], cpeVersion(p, d.Version))
+		if c[0] == "" || c[1] == "" || seen[cpe] {
+			continue
+		}
+		seen[cpe] = true
+		res = append(res, cpe)
+	}
+	return res
+}
+
+// projectCPECandidates returns the candidates for packages without obvious vendor, which in NVD usually have
+// the package name or '<name>_project' as vendor
+func projectCPECandidates(name string) [][2]string {
+	return [][2]string{{name, name}, {name + "_project", name}}
+}
+
+// goCPECandidates uses repository owner as vendor and repository name as product
+func goCPECandidates(namespace, name string) [][2]string {
+	path := strings.Split(namespace+"/"+name, "/")
+	// major version suffix is not a part of the repository name
+	if n := len(path); n > 1 && len(path[n-1]) > 1 && path[n-1][0] == 'v' && isDigits(path[n-1][1:]) {
+		path = path[:n-1]
+	}
+	if len(path) >= 3 && goHosts[path[0]] {
+		return [][2]string{{path[1], path[2]}}
+	}
+	if len(path) >= 2 {
+		// like 'golang.org/x/net' or 'gopkg.in/yaml.v3'
+		host := strings.Split(path[0], ".")
+		product := strings.Split(path[len(path)-1], ".")[0]
+		return [][2]string{{host[0], product}, {product, product}}
+	}
+	return [][2]string{{path[0], path[0]}}
+}
+
+// mavenCPECandidates uses the organization from group ID as vendor, and artifact ID or the last part of
+// group ID as product, like 'apache' and 'log4j' for 'org.apache.logging.log4j:log4j-core'
+func mavenCPECandidates(groupID, artifactID string) [][2]string {
+	group := strings.Split(groupID, ".")
+	vendor := group[0]
+	if len(group) > 1 && mavenDomains[vendor] {
+		vendor = group[1]
+	}
+	return [][2]string{{vendor, artifactID}, {vendor, group[len(group)-1]}, {artifactID, artifactID}}
+}
+
+// cpeVersion returns version of the package, as used in CPE names - upstream version for OS packages
+func cpeVersion(p *purl.PackageURL, version string) string {
+	switch p.Type {
+	case typeDeb, typeApk, purl.TypeRPM:
+		return upstreamVersion(version)
+	}
+	return version
+}
+
+// upstreamVersion strips epoch and distribution revision from OS package version, like '1:1.2.3-4+deb12u1'
+func upstreamVersion(version string) string {
+	if i := strings.Index(version, ":"); i >= 0 {
+		version = version[i+1:]
+	}
+	if i := strings.LastIndex(version, "-"); i > 0 {
+		version = version[:i]
+	}
+	// repacked sources, like '1.2.3+dfsg' or '1.2.3~ds1'
+	if i := strings.IndexAny(version, "+~"); i > 0 {
+		version = version[:i]
+	}
+	return version
+}
+
+// formatCPE returns CPE name of application
+func formatCPE(vendor, product, version string) string {
+	attrs := make([]string, cpeParts)
+	attrs[0], attrs[1], attrs[2] = "cpe", "2.3", "a"
+	attrs[3], attrs[4], attrs[5] = quoteCPE(vendor), quoteCPE(product), quoteCPE(version)
+	for i := 6; i < cpeParts; i++ {
+		attrs[i] = cpeAny
+	}
+	if attrs[5] == "" {
+		attrs[5] = cpeAny
+	}
+	return strings.Join(attrs, ":")
+}
+
+// quoteCPE converts value to CPE attribute - lower-case, with spaces replaced by underscores and special
+// characters quoted
+func quoteCPE(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(value)) {
+		switch {
+		case r == ' ':
+			b.WriteRune('_')
+		case strings.ContainsRune(cpeSpecialChars, r):
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// completeCPE adds missing trailing attributes to CPE name from override, and sets the version, if it is not set
+func completeCPE(cpe, version string) string {
+	attrs := splitCPE(cpe)
+	for len(attrs) < cpeParts {
+		attrs = append(attrs, cpeAny)
+	}
+	if attrs[5] == cpeAny && version != "" {
+		attrs[5] = quoteCPE(version)
+	}
+	return strings.Join(attrs, ":")
+}
+
+// splitCPE splits CPE name into attributes, ignoring quoted colons
+func splitCPE(cpe string) []string {
+	var attrs []string
+	start := 0
+	for i := 0; i < len(cpe); i++ {
+		switch cpe[i] {
+		case '\\':
+			i++
+		case ':':
+			attrs = append(attrs, cpe[start:i])
+			start = i + 1
+		}
+	}
+	return append(attrs, cpe[start:])
+}
+
+func findCPEOverride(overrides []CPEOverride, p *purl.PackageURL) *CPEOverride {
+	for i := range overrides {
+		o := &overrides[i].url
+		if o.Type == p.Type && o.Name == p.Name && (o.Namespace == "" || o.Namespace == p.Namespace) {
+			return &overrides[i]
+		}
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
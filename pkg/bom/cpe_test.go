@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package bom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/docker"
+	"github.com/codenotary/cas/pkg/bom/golang"
+	"github.com/codenotary/cas/pkg/bom/java"
+	"github.com/codenotary/cas/pkg/bom/javascript"
+	"github.com/codenotary/cas/pkg/bom/php"
+	"github.com/codenotary/cas/pkg/bom/python"
+)
+
+func TestCPEs(t *testing.T) {
+	a := newTestArtifact(docker.DPKG)
+	for _, tc := range []struct {
+		dep      artifact.Dependency
+		expected []string
+	}{
+		{artifact.Dependency{Name: "libssl3", Version: "3.0.11-1~deb12u2", Kind: docker.DPKG,
+			Properties: map[string]string{docker.PropSource: "openssl"}},
+			[]string{"cpe:2.3:a:openssl:openssl:3.0.11:*:*:*:*:*:*:*"}},
+		{artifact.Dependency{Name: "bash", Version: "1:5.2.15-2+b2", Kind: docker.DPKG},
+			[]string{"cpe:2.3:a:bash:bash:5.2.15:*:*:*:*:*:*:*"}},
+		{artifact.Dependency{Name: "musl", Version: "1.2.4-r2", Kind: docker.APK},
+			[]string{"cpe:2.3:a:musl:musl:1.2.4:*:*:*:*:*:*:*"}},
+		{artifact.Dependency{Name: "github.com/gin-gonic/gin", Version: "v1.9.1", Kind: golang.AssetType},
+			[]string{"cpe:2.3:a:gin-gonic:gin:v1.9.1:*:*:*:*:*:*:*"}},
+		{artifact.Dependency{Name: "github.com/go-pg/pg/v10", Version: "v10.11.1", Kind: golang.AssetType},
+			[]string{"cpe:2.3:a:go-pg:pg:v10.11.1:*:*:*:*:*:*:*"}},
+		{artifact.Dependency{Name: "golang.org/x/net", Version: "v0.17.0", Kind: golang.AssetType},
+			[]string{"cpe:2.3:a:golang:net:v0.17.0:*:*:*:*:*:*:*", "cpe:2.3:a:net:net:v0.17.0:*:*:*:*:*:*:*"}},
+		{artifact.Dependency{Name: "lodash", Version: "4.17.21", Kind: javascript.AssetType},
+			[]string{"cpe:2.3:a:lodash:lodash:4.17.21:*:*:*:*:*:*:*",
+				"cpe:2.3:a:lodash_project:lodash:4.17.21:*:*:*:*:*:*:*"}},
+		{artifact.Dependency{Name: "@angular/core", Version: "16.2.0", Kind: javascript.AssetType},
+			[]string{"cpe:2.3:a:angular:core:16.2.0:*:*:*:*:*:*:*", "cpe:2.3:a:core:core:16.2.0:*:*:*:*:*:*:*",
+				"cpe:2.3:a:core_project:core:16.2.0:*:*:*:*:*:*:*"}},
+		{artifact.Dependency{Name: "PyYAML", Version: "6.0", Kind: python.AssetType},
+			[]string{"cpe:2.3:a:pyyaml:pyyaml:6.0:*:*:*:*:*:*:*", "cpe:2.3:a:pyyaml_project:pyyaml:6.0:*:*:*:*:*:*:*"}},
+		{artifact.Dependency{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1", Kind: java.AssetType},
+			[]string{"cpe:2.3:a:apache:log4j-core:2.14.1:*:*:*:*:*:*:*", "cpe:2.3:a:apache:log4j:2.14.1:*:*:*:*:*:*:*",
+				"cpe:2.3:a:log4j-core:log4j-core:2.14.1:*:*:*:*:*:*:*"}},
+		{artifact.Dependency{Name: "guzzlehttp/guzzle", Version: "7.8.0", Kind: php.AssetType},
+			[]string{"cpe:2.3:a:guzzlehttp:guzzle:7.8.0:*:*:*:*:*:*:*", "cpe:2.3:a:guzzle:guzzle:7.8.0:*:*:*:*:*:*:*",
+				"cpe:2.3:a:guzzle_project:guzzle:7.8.0:*:*:*:*:*:*:*"}},
+		{artifact.Dependency{Name: "My Tool", Version: "1.0+build:5", Kind: "unknown"},
+			[]string{"cpe:2.3:a:my_tool:my_tool:1.0\\+build\\:5:*:*:*:*:*:*:*"}},
+		{artifact.Dependency{Name: "nameless", Kind: "unknown"},
+			[]string{"cpe:2.3:a:nameless:nameless:*:*:*:*:*:*:*:*"}},
+	} {
+		assert.Equal(t, tc.expected, CPEs(a, tc.dep), tc.dep.Name)
+	}
+}
+
+func TestCPEOverrides(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "cpe.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte(`
+- purl: pkg:deb/libcurl4
+  cpes: ["cpe:2.3:a:haxx:libcurl"]
+- purl: pkg:npm/internal-lib
+  cpes: []
+- purl: pkg:deb/ubuntu/bash
+  cpes: ["cpe:2.3:a:gnu:bash:5.1:*:*:*:*:*:*:*"]
+`), 0644))
+	overrides, err := LoadCPEOverrides(filename)
+	assert.NoError(t, err)
+	assert.Len(t, overrides, 3)
+
+	a := newTestArtifact(docker.DPKG)
+	a.Deps = []artifact.Dependency{
+		{Name: "libcurl4", Version: "7.88.1-10+deb12u4", Kind: docker.DPKG,
+			Properties: map[string]string{docker.PropDistro: "debian-12", docker.PropSource: "curl"}},
+		{Name: "internal-lib", Version: "1.0.0", Kind: javascript.AssetType},
+		{Name: "bash", Version: "5.2.15-2", Kind: docker.DPKG, Properties: map[string]string{docker.PropDistro: "debian-12"}},
+		{Name: "zlib1g", Version: "1.2.13", Kind: docker.DPKG, CPEs: []string{"cpe:2.3:a:zlib:zlib:1.2.13:*:*:*:*:*:*:*"}},
+	}
+	GenerateCPEs(a, overrides)
+	assert.Equal(t, []string{"cpe:2.3:a:haxx:libcurl:7.88.1:*:*:*:*:*:*:*"}, a.Deps[0].CPEs)
+	assert.Empty(t, a.Deps[1].CPEs)
+	assert.Equal(t, []string{"cpe:2.3:a:bash:bash:5.2.15:*:*:*:*:*:*:*"}, a.Deps[2].CPEs)
+	assert.Equal(t, []string{"cpe:2.3:a:zlib:zlib:1.2.13:*:*:*:*:*:*:*"}, a.Deps[3].CPEs)
+
+	for _, content := range []string{"- purl: \"libcurl\"\n", "- purl: pkg:deb/curl\n  cpes: [\"curl:curl\"]\n",
+		"- purl: pkg:deb/curl\n  cpe: []\n"} {
+		assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+		_, err := LoadCPEOverrides(filename)
+		assert.Error(t, err, content)
+	}
+}
+
+func TestCPEOutput(t *testing.T) {
+	a := newTestArtifact(javascript.AssetType)
+	a.Deps = a.Deps[:1]
+	a.Deps[0].CPEs = []string{"cpe:2.3:a:app-lib:app-lib:1.0:*:*:*:*:*:*:*", "cpe:2.3:a:app-lib_project:app-lib:1.0:*:*:*:*:*:*:*"}
+
+	bom := convertToCyclone(a, "")
+	comps := *bom.Components
+	assert.Equal(t, "cpe:2.3:a:app-lib:app-lib:1.0:*:*:*:*:*:*:*", comps[0].CPE)
+	assert.Contains(t, *comps[0].Properties, cdx.Property{Name: "CPE", Value: "cpe:2.3:a:app-lib_project:app-lib:1.0:*:*:*:*:*:*:*"})
+
+	doc, err := convertToSpdx(a, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []spdxExternalRef{
+		{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: "pkg:npm/app-lib@1.0"},
+		{ReferenceCategory: "SECURITY", ReferenceType: "cpe23Type", ReferenceLocator: a.Deps[0].CPEs[0]},
+		{ReferenceCategory: "SECURITY", ReferenceType: "cpe23Type", ReferenceLocator: a.Deps[0].CPEs[1]},
+	}, doc.Packages[1].ExternalRefs)
+}
@@ -12,7 +12,9 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 
@@ -28,7 +30,8 @@ var hashNames = map[artifact.HashType]cdx.HashAlgorithm{
 	artifact.HashSHA512: cdx.HashAlgoSHA512,
 }
 
-func OutputCycloneDX(a artifact.Artifact, filename string, format cdx.BOMFileFormat) error {
+// OutputCycloneDX writes BOM in CycloneDX format. Hash is the notarized hash of the asset itself, empty if unknown
+func OutputCycloneDX(a artifact.Artifact, hash, filename string, format cdx.BOMFileFormat) error {
 	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
 		return err
@@ -39,7 +42,7 @@ func OutputCycloneDX(a artifact.Artifact, filename string, format cdx.BOMFileFor
 	encoder := cdx.NewBOMEncoder(buf, format)
 	encoder.SetPretty(true)
 
-	bom := convertToCyclone(a)
+	bom := convertToCyclone(a, hash)
 
 	err = encoder.Encode(bom)
 	if err != nil {
@@ -52,7 +55,7 @@ func OutputCycloneDX(a artifact.Artifact, filename string, format cdx.BOMFileFor
 	return err
 }
 
-func convertToCyclone(a artifact.Artifact) *cdx.BOM {
+func convertToCyclone(a artifact.Artifact, hash string) *cdx.BOM {
 	bom := cdx.NewBOM()
 
 	bom.Metadata = &cdx.Metadata{
@@ -67,8 +70,12 @@ func convertToCyclone(a artifact.Artifact) *cdx.BOM {
 
 	name := filepath.Base(a.Path())
 	bom.Metadata.Component = &cdx.Component{
-		Name: name,
-		Type: cdx.ComponentTypeApplication,
+		BOMRef: name,
+		Name:   name,
+		Type:   cdx.ComponentTypeApplication,
+	}
+	if hash != "" {
+		bom.Metadata.Component.Hashes = &[]cdx.Hash{{Algorithm: cdx.HashAlgoSHA256, Value: hash}}
 	}
 
 	deps := a.Dependencies()
@@ -85,6 +92,7 @@ func convertToCyclone(a artifact.Artifact) *cdx.BOM {
 			Name:       dep.Name,
 			Version:    dep.Version,
 			PackageURL: pkgUrl,
+			CPE:        firstCPE(dep.CPEs),
 			Hashes: &[]cdx.Hash{
 				{
 					Algorithm: hashName,
@@ -92,18 +100,139 @@ func convertToCyclone(a artifact.Artifact) *cdx.BOM {
 				},
 			},
 		}
+		if dep.Supplier != "" {
+			comps[i].Supplier = &cdx.OrganizationalEntity{Name: dep.Supplier}
+		}
 		if dep.License != "" {
 			comps[i].Licenses = &cdx.Licenses{cdx.LicenseChoice{Expression: dep.License}}
 		}
-		props := make([]cdx.Property, 1, 2)
+		props := make([]cdx.Property, 1, 3+len(dep.Properties))
 		props[0] = cdx.Property{Name: "LinkType", Value: DepLinkType(a, dep)}
 		trustLevel := artifact.TrustLevelName(dep.TrustLevel)
 		if trustLevel != "" {
 			props = append(props, cdx.Property{Name: "TrustLevel", Value: trustLevel})
 		}
+		if dep.LicenseDeclared != "" {
+			props = append(props, cdx.Property{Name: "DeclaredLicense", Value: dep.LicenseDeclared})
+		}
+		// CycloneDX allows single CPE name per component, other candidates are kept as properties
+		for j := 1; j < len(dep.CPEs); j++ {
+			props = append(props, cdx.Property{Name: "CPE", Value: dep.CPEs[j]})
+		}
+		props = append(props, extraProperties(dep)...)
 		comps[i].Properties = &props
 	}
 	bom.Components = &comps
+	if vulns := cycloneVulnerabilities(deps, comps); len(vulns) > 0 {
+		bom.Vulnerabilities = &vulns
+	}
+
+	// the asset depends on direct dependencies, and each dependency - on the ones it requires
+	index := newDepIndex(deps)
+	dependencies := make([]cdx.Dependency, 1, len(deps)+1)
+	var direct []cdx.Dependency
+	for i, dep := range deps {
+		if dep.Type == artifact.DepDirect {
+			direct = append(direct, cdx.Dependency{Ref: comps[i].BOMRef})
+		}
+	}
+	dependencies[0] = cdx.Dependency{Ref: name}
+	if len(direct) > 0 {
+		dependencies[0].Dependencies = &direct
+	}
+	for i, dep := range deps {
+		d := cdx.Dependency{Ref: comps[i].BOMRef}
+		if required := index.required(dep); len(required) > 0 {
+			refs := make([]cdx.Dependency, len(required))
+			for j, k := range required {
+				refs[j] = cdx.Dependency{Ref: comps[k].BOMRef}
+			}
+			d.Dependencies = &refs
+		}
+		dependencies = append(dependencies, d)
+	}
+	bom.Dependencies = &dependencies
 
 	return bom
 }
+
+// cycloneVulnerabilities lists vulnerabilities, affecting the components, in order of their first occurrence
+func cycloneVulnerabilities(deps []artifact.Dependency, comps []cdx.Component) []cdx.Vulnerability {
+	var res []cdx.Vulnerability
+	index := make(map[string]int)
+	for i, dep := range deps {
+		for _, v := range dep.Vulnerabilities {
+			j, ok := index[v.ID]
+			if !ok {
+				j = len(res)
+				index[v.ID] = j
+				res = append(res, newCycloneVulnerability(v))
+			}
+			versions := []cdx.AffectedVersions{{Version: dep.Version, Status: cdx.VulnerabilityStatusAffected}}
+			if v.Fixed != "" {
+				versions = append(versions, cdx.AffectedVersions{Version: v.Fixed, Status: cdx.VulnerabilityStatusNotAffected})
+			}
+			affects := append(*res[j].Affects, cdx.Affects{Ref: comps[i].BOMRef, Range: &versions})
+			res[j].Affects = &affects
+		}
+	}
+	return res
+}
+
+func newCycloneVulnerability(v artifact.Vulnerability) cdx.Vulnerability {
+	res := cdx.Vulnerability{
+		ID:          v.ID,
+		Source:      osvSource(v.ID),
+		Description: v.Summary,
+		Affects:     &[]cdx.Affects{},
+	}
+	rating := cdx.VulnerabilityRating{Severity: cdx.Severity(artifact.SeverityName(v.Severity))}
+	if v.Vector != "" {
+		score := v.Score
+		rating.Score = &score
+		rating.Vector = v.Vector
+		rating.Method = cdx.ScoringMethodCVSSv3
+		if strings.HasPrefix(v.Vector, "CVSS:3.1/") {
+			rating.Method = cdx.ScoringMethodCVSSv31
+		}
+	}
+	res.Ratings = &[]cdx.VulnerabilityRating{rating}
+	if len(v.Aliases) > 0 {
+		refs := make([]cdx.VulnerabilityReference, len(v.Aliases))
+		for i, alias := range v.Aliases {
+			refs[i] = cdx.VulnerabilityReference{ID: alias, Source: osvSource(alias)}
+			if strings.HasPrefix(alias, "CVE-") {
+				refs[i].Source = &cdx.Source{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + alias}
+			}
+		}
+		res.References = &refs
+	}
+	return res
+}
+
+func osvSource(id string) *cdx.Source {
+	return &cdx.Source{Name: "OSV", URL: "https://osv.dev/vulnerability/" + id}
+}
+
+// firstCPE returns the most likely CPE name, or empty string if there are none
+func firstCPE(cpes []string) string {
+	if len(cpes) == 0 {
+		return ""
+	}
+	return cpes[0]
+}
+
+// extraProperties returns environment-specific dependency properties in stable order
+func extraProperties(dep artifact.Dependency) []cdx.Property {
+	keys := make([]string, 0, len(dep.Properties))
+	for k := range dep.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	props := make([]cdx.Property, len(keys))
+	for i, k := range keys {
+		props[i] = cdx.Property{Name: k, Value: dep.Properties[k]}
+	}
+	return props
+}
@@ -9,6 +9,7 @@
 package depgraph
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/codenotary/cas/pkg/bom/artifact"
@@ -84,14 +85,34 @@ func (g *Graph) AddChild(parent *GraphNode, name, version string, child *artifac
 	return node
 }
 
-// FlatDeps return flat slice with all dependencies (excluding the root)
+// FlatDeps return flat slice with all dependencies (excluding the root). Each dependency refers to the
+// dependencies it requires
 func (g *Graph) FlatDeps() []artifact.Dependency {
+	requires := make(map[*GraphNode][]artifact.DepRef)
+	seen := make(map[graphLink]bool, len(g.links))
+	for _, link := range g.links {
+		if link.from == g.Root || link.to.asset == nil || seen[link] {
+			continue
+		}
+		seen[link] = true
+		requires[link.from] = append(requires[link.from],
+			artifact.DepRef{Name: link.to.asset.Name, Version: link.to.asset.Version})
+	}
+
 	res := make([]artifact.Dependency, 0, len(g.nodes))
 	for _, node := range g.nodes {
 		if node == g.Root || node.asset == nil {
 			continue
 		}
 		node.asset.Type = node.depType
+		refs := requires[node]
+		sort.Slice(refs, func(i, j int) bool {
+			if refs[i].Name != refs[j].Name {
+				return refs[i].Name < refs[j].Name
+			}
+			return refs[i].Version < refs[j].Version
+		})
+		node.asset.Requires = refs
 		res = append(res, *node.asset)
 	}
 	return res
@@ -14,6 +14,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"github.com/codenotary/cas/pkg/bom/artifact"
 	"github.com/codenotary/cas/pkg/bom/executor"
@@ -26,10 +27,18 @@ type apk struct {
 }
 
 const (
-	checksumTag = 'C'
-	packageTag  = 'P'
-	versionTag  = 'V'
-	licenseTag  = 'L'
+	checksumTag   = 'C'
+	packageTag    = 'P'
+	versionTag    = 'V'
+	archTag       = 'A'
+	licenseTag    = 'L'
+	maintainerTag = 'm'
+	originTag     = 'o'
+	dependsTag    = 'D'
+	providesTag   = 'p'
+
+	apkInstalled = "/lib/apk/db/installed"
+	apkWorld     = "/etc/apk/world" // packages, explicitly installed by user
 )
 
 func (pkg apk) Type() string {
@@ -50,20 +59,20 @@ func (pkg *apk) AllPackages(e executor.Executor, output artifact.OutputOptions)
 
 // build package cache and index - see https://wiki.alpinelinux.org/wiki/Apk_spec
 func (pkg *apk) buildCache(e executor.Executor) error {
-	buf, err := e.ReadFile("/lib/apk/db/installed")
+	buf, err := e.ReadFile(apkInstalled)
 	if err != nil {
 		return fmt.Errorf("error reading file from container: %w", err)
 	}
 
-	pkg.cache = make([]artifact.Dependency, 0)
+	pkgs := make([]osPackage, 0)
 	scanner := bufio.NewScanner(bytes.NewBuffer(buf))
-	curPkg := artifact.Dependency{Type: artifact.DepDirect}
+	curPkg := osPackage{}
 	for scanner.Scan() {
 		line := scanner.Text()
 		if line == "" {
 			// end of package section
-			pkg.cache = append(pkg.cache, curPkg)
-			curPkg = artifact.Dependency{}
+			pkgs = append(pkgs, curPkg)
+			curPkg = osPackage{}
 			continue
 		}
 		tag := line[0]
@@ -79,12 +88,46 @@ func (pkg *apk) buildCache(e executor.Executor) error {
 			}
 			curPkg.Hash = hex.EncodeToString(hash)
 			curPkg.HashType = artifact.HashSHA1
+		case archTag:
+			setProperty(&curPkg.Dependency, PropArch, line[2:])
 		case licenseTag:
-			curPkg.License = line[2:]
+			setLicense(&curPkg.Dependency, line[2:])
+		case maintainerTag:
+			curPkg.Supplier = line[2:]
+		case originTag:
+			curPkg.setSource(line[2:])
+		case dependsTag:
+			for _, dep := range strings.Fields(line[2:]) {
+				if strings.HasPrefix(dep, "!") {
+					continue // conflict
+				}
+				curPkg.requires = append(curPkg.requires, []string{trimConstraint(dep)})
+			}
+		case providesTag:
+			for _, name := range strings.Fields(line[2:]) {
+				curPkg.provides = append(curPkg.provides, trimConstraint(name))
+			}
 		}
 	}
 	// last line in 'installed' file is empty, therefore last created curPkg just discarded
 
+	// world file may be missing in images built without apk, all top-level packages are direct then
+	var world []string
+	if buf, err := e.ReadFile(apkWorld); err == nil {
+		world = make([]string, 0)
+		for _, name := range strings.Fields(string(buf)) {
+			if strings.HasPrefix(name, "!") {
+				continue
+			}
+			// strip pinned repository tag, f.e. 'package@edge'
+			if i := strings.IndexByte(name, '@'); i > 0 {
+				name = name[:i]
+			}
+			world = append(world, trimConstraint(name))
+		}
+	}
+	pkg.cache = packageGraph(pkgs, world)
+
 	pkg.index = make(map[string]*artifact.Dependency, len(pkg.cache))
 	for i := range pkg.cache {
 		pkg.index[pkg.cache[i].Name] = &pkg.cache[i]
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package docker
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+func TestApkDependencyGraph(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"lib/apk/db/installed": "C:Q1AAAAAAAAAAAAAAAAAAAAAAAAAAA=\nP:musl\nV:1.2.4-r2\nA:x86_64\nL:MIT\n" +
+			"m:Timo Teräs <timo.teras@iki.fi>\n" +
+			"p:so:libc.musl-x86_64.so.1=1\n\n" +
+			"C:Q1AAAAAAAAAAAAAAAAAAAAAAAAAAE=\nP:busybox\nV:1.36.1-r5\nL:GPL-2.0-only\no:busybox\n" +
+			"D:so:libc.musl-x86_64.so.1 !busybox-static\np:/bin/sh cmd:busybox=1.36.1-r5\n\n" +
+			"C:Q1AAAAAAAAAAAAAAAAAAAAAAAAAAI=\nP:alpine-baselayout\nV:3.4.3-r1\nL:GPL-2.0-only\no:alpine-base\n" +
+			"D:/bin/sh musl>=1.2\n\n",
+		"etc/apk/world":  "alpine-baselayout\nbusybox@edge\n",
+		"etc/os-release": "NAME=\"Alpine Linux\"\nID=alpine\nVERSION_ID=3.18.4\n",
+	})
+	e, err := executor.NewDirExecutor(root)
+	assert.NoError(t, err)
+
+	a, err := NewFromExecutor("alpine", e)
+	assert.NoError(t, err)
+	assert.Equal(t, APK, a.Type())
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	assert.Equal(t, []artifact.Dependency{{
+		Name:       "alpine-baselayout",
+		Version:    "3.4.3-r1",
+		Hash:       "0000000000000000000000000000000000000002",
+		HashType:   artifact.HashSHA1,
+		License:    "GPL-2.0-only",
+		Kind:       APK,
+		Type:       artifact.DepDirect,
+		Properties: map[string]string{PropSource: "alpine-base", PropDistro: "alpine-3.18.4"},
+		Requires:   []artifact.DepRef{{Name: "busybox", Version: "1.36.1-r5"}, {Name: "musl", Version: "1.2.4-r2"}},
+	}, {
+		Name:       "busybox",
+		Version:    "1.36.1-r5",
+		Hash:       "0000000000000000000000000000000000000001",
+		HashType:   artifact.HashSHA1,
+		License:    "GPL-2.0-only",
+		Kind:       APK,
+		Type:       artifact.DepDirect,
+		Properties: map[string]string{PropDistro: "alpine-3.18.4"},
+		Requires:   []artifact.DepRef{{Name: "musl", Version: "1.2.4-r2"}},
+	}, {
+		Name:       "musl",
+		Version:    "1.2.4-r2",
+		Hash:       "0000000000000000000000000000000000000000",
+		HashType:   artifact.HashSHA1,
+		License:    "MIT",
+		Supplier:   "Timo Teräs <timo.teras@iki.fi>",
+		Kind:       APK,
+		Type:       artifact.DepTransient,
+		Properties: map[string]string{PropArch: "x86_64", PropDistro: "alpine-3.18.4"},
+	}}, deps)
+}
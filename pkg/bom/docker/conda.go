@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package docker
+
+import (
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/conda"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+// condaPkg implements packageManager interface for packages of Conda environments
+type condaPkg struct{}
+
+func (pkg condaPkg) Type() string {
+	return conda.AssetType
+}
+
+// AllPackages finds packages of all Conda environments
+func (pkg condaPkg) AllPackages(e executor.Executor, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	return conda.InstalledPackages(e, output)
+}
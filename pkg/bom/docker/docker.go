@@ -29,9 +29,11 @@ const (
 type DockerArtifact struct {
 	artifact.GenericArtifact
 	image   string
+	id      string // image ID, if known without Docker daemon
 	ex      executor.Executor
-	pkg     pkgManager
+	pkgs    []pkgManager // OS package managers, found in the image
 	pkgType string
+	langs   []pkgManager // language-specific package managers, used along with OS one
 }
 
 // New returns new DockerArtifact object
@@ -42,19 +44,31 @@ func New(path string) (*DockerArtifact, error) {
 		return nil, err
 	}
 
-	pkg, err := probePackageManager(executor)
+	return NewFromExecutor(path, executor)
+}
+
+// NewFromExecutor returns new DockerArtifact object for the image, accessible with executor. Executor is
+// closed when dependencies are resolved, or if artifact cannot be created. Image without OS package manager
+// has Image type, only language-specific packages are reported for it
+func NewFromExecutor(image string, ex executor.Executor) (*DockerArtifact, error) {
+	pkgs, err := probePackageManagers(ex)
 	if err != nil {
+		ex.Close()
 		return nil, fmt.Errorf("error identifying package manager for the image: %w", err)
 	}
-	if pkg == nil {
-		return nil, fmt.Errorf("cannot identify package manager for the container image")
-	}
 
 	ret := DockerArtifact{
-		image:   path,
-		ex:      executor,
-		pkg:     pkg,
-		pkgType: pkg.Type(),
+		image:   image,
+		ex:      ex,
+		pkgs:    pkgs,
+		pkgType: Image,
+		langs:   []pkgManager{pythonPkg{}, nodePkg{}, javaPkg{}, rubyPkg{}, phpPkg{}, condaPkg{}},
+	}
+	if len(pkgs) > 0 {
+		ret.pkgType = pkgs[0].Type()
+	}
+	if i, ok := ex.(executor.Identifier); ok {
+		ret.id = i.ImageID()
 	}
 	return &ret, nil
 }
@@ -67,15 +81,40 @@ func (p DockerArtifact) Path() string {
 	return p.image
 }
 
+// AssetHash returns image ID, the same as Docker extractor uses as the hash of the image, if it is known
+func (p DockerArtifact) AssetHash() string {
+	return p.id
+}
+
+// ResolveDependencies runs all package managers over the image and merges the results. Each dependency
+// has the kind of package manager it comes from
 func (a *DockerArtifact) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
 	if a.Deps != nil {
 		return a.Deps, nil
 	}
 	defer a.ex.Close()
 
-	result, err := a.pkg.AllPackages(a.ex, output)
-	if err != nil {
-		return nil, err
+	result := make([]artifact.Dependency, 0)
+	distro := distroRelease(a.ex)
+	for _, pkg := range a.pkgs {
+		deps, err := pkg.AllPackages(a.ex, output)
+		if err != nil {
+			return nil, err
+		}
+		for i := range deps {
+			setProperty(&deps[i], PropDistro, distro)
+		}
+		result = appendWithKind(result, deps, pkg.Type())
+	}
+
+	for _, lang := range a.langs {
+		deps, err := lang.AllPackages(a.ex, output)
+		if err != nil {
+			// language packages are optional, don't lose OS packages and other languages because of one of them
+			artifact.Warnf(output, "Cannot get %s packages, skipping: %v\n", lang.Type(), err)
+			continue
+		}
+		result = appendWithKind(result, deps, lang.Type())
 	}
 
 	result = a.filterOutDuplicates(result)
@@ -84,13 +123,27 @@ func (a *DockerArtifact) ResolveDependencies(output artifact.OutputOptions) ([]a
 	return result, nil
 }
 
+// appendWithKind appends dependencies, setting the kind for those which don't have it
+func appendWithKind(res, deps []artifact.Dependency, kind string) []artifact.Dependency {
+	for _, d := range deps {
+		if d.Kind == "" {
+			d.Kind = kind
+		}
+		res = append(res, d)
+	}
+	return res
+}
+
+// filterOutDuplicates removes the same packages, found several times, f.e. in different site-packages
+// directories. Packages of different kinds are never considered the same
 func (a *DockerArtifact) filterOutDuplicates(deps []artifact.Dependency) []artifact.Dependency {
 	ret := make([]artifact.Dependency, 0, len(deps))
-	hashesFound := make(map[string]struct{}, len(deps))
+	found := make(map[[4]string]struct{}, len(deps))
 	for _, dep := range deps {
-		if _, exists := hashesFound[dep.Hash]; !exists {
+		key := [4]string{dep.Kind, dep.Name, dep.Version, dep.Hash}
+		if _, exists := found[key]; !exists {
 			ret = append(ret, dep)
-			hashesFound[dep.Hash] = struct{}{}
+			found[key] = struct{}{}
 		}
 	}
 	return ret
@@ -108,7 +161,7 @@ func (a *DockerArtifact) FileHash(name string) (string, error) {
 
 func IsDocker(a artifact.Artifact) bool {
 	aType := a.Type()
-	if aType == APK || aType == RPM || aType == DPKG {
+	if aType == APK || aType == RPM || aType == DPKG || aType == Image {
 		return true
 	}
 	return false
@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package docker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+// testLang is a language package manager, which returns fixed result
+type testLang struct {
+	kind string
+	deps []artifact.Dependency
+	err  error
+}
+
+func (l testLang) AllPackages(e executor.Executor, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	return l.deps, l.err
+}
+
+func (l testLang) Type() string {
+	return l.kind
+}
+
+func TestResolveDependenciesSkipsFailedLanguage(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"lib/apk/db/installed": "P:musl\nV:1.2.4-r2\nC:Q1AAAAAAAAAAAAAAAAAAAAAAAAAAA=\n\n",
+	})
+	e, err := executor.NewDirExecutor(root)
+	assert.NoError(t, err)
+
+	a, err := NewFromExecutor("alpine", e)
+	assert.NoError(t, err)
+	a.langs = []pkgManager{
+		testLang{kind: "broken", err: errors.New("malformed lock file")},
+		testLang{kind: "lang", deps: []artifact.Dependency{{Name: "lib", Version: "1.0", Hash: "ab"}}},
+	}
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+	kinds := make(map[string]string)
+	for _, d := range deps {
+		kinds[d.Name] = d.Kind
+	}
+	assert.Equal(t, map[string]string{"musl": APK, "lib": "lang"}, kinds)
+}
@@ -17,7 +17,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"path/filepath"
+	"io/ioutil"
+	"path"
 	"regexp"
 	"strings"
 
@@ -32,7 +33,7 @@ type dpkg struct {
 }
 
 var (
-	licensePattern           = regexp.MustCompile(`^License: (\S*)`)
+	licensePattern           = regexp.MustCompile(`^License:\s*(\S.*)`)
 	commonLicensePathPattern = regexp.MustCompile(`/usr/share/common-licenses/([0-9A-Za-z_.\-]+)`)
 )
 
@@ -52,123 +53,314 @@ func (pkg *dpkg) AllPackages(e executor.Executor, output artifact.OutputOptions)
 	return pkg.cache, nil
 }
 
+const (
+	dpkgStatus   = "/var/lib/dpkg/status"
+	dpkgStatusD  = "/var/lib/dpkg/status.d" // used by distroless images instead of status file
+	dpkgInfo     = "/var/lib/dpkg/info"
+	md5sumsExt   = ".md5sums"
+	copyrightDir = "/usr/share/doc"
+
+	aptExtendedStates = "/var/lib/apt/extended_states" // APT marks of automatically installed packages
+)
+
 func (pkg *dpkg) buildCache(e executor.Executor) error {
-	buf, err := e.ReadFile("/var/lib/dpkg/status")
-	if err != nil {
-		buf, err = e.ReadFile("/var/lib/dpkg/status.d")
-		if err != nil {
+	// hashes of md5sums files, or of status.d files if there are no md5sums, keyed by package name
+	hashes := make(map[string]string)
+	var pkgs []osPackage
+	buf, err := e.ReadFile(dpkgStatus)
+	if err == nil {
+		pkgs = parseStatus(buf)
+	} else if pkgs, err = readStatusD(e, hashes); err != nil {
+		return err
+	}
+
+	pkg.index = make(map[string]*artifact.Dependency, len(pkgs))
+	for i := range pkgs {
+		pkg.index[pkgs[i].Name] = &pkgs[i].Dependency
+	}
+
+	// calculate hashes for all packages
+	if hashReader, err := e.ReadDir(dpkgInfo); err == nil {
+		defer hashReader.Close()
+		if err := md5sumsHashes(hashReader, hashes); err != nil {
 			return fmt.Errorf("error reading file from container: %w", err)
 		}
 	}
-
-	pkg.cache = make([]artifact.Dependency, 0)
-	scanner := bufio.NewScanner(bytes.NewBuffer(buf))
-	curPkg := artifact.Dependency{Type: artifact.DepDirect}
-	for scanner.Scan() {
-		line := scanner.Text()
-		fields := strings.SplitN(line, ": ", 2)
-		if len(fields) != 2 {
-			continue
+	for name, hash := range hashes {
+		if p, ok := pkg.index[name]; ok {
+			p.Hash = hash
+			p.HashType = artifact.HashSHA256
 		}
-		switch fields[0] {
-		case "Package":
-			if curPkg.Name != "" {
-				pkg.cache = append(pkg.cache, curPkg)
+	}
+
+	if err := pkg.readLicenses(e); err != nil {
+		return err
+	}
+
+	// without APT state all top-level packages are direct
+	var manual []string
+	if buf, err := e.ReadFile(aptExtendedStates); err == nil {
+		auto := autoInstalled(buf)
+		manual = make([]string, 0, len(pkgs))
+		for _, p := range pkgs {
+			if !auto[p.Name] {
+				manual = append(manual, p.Name)
 			}
-			curPkg = artifact.Dependency{Name: fields[1]}
-		case "Version":
-			curPkg.Version = fields[1]
 		}
 	}
-	if curPkg.Name != "" {
-		pkg.cache = append(pkg.cache, curPkg)
-	}
+	pkg.cache = packageGraph(pkgs, manual)
 
 	pkg.index = make(map[string]*artifact.Dependency, len(pkg.cache))
 	for i := range pkg.cache {
 		pkg.index[pkg.cache[i].Name] = &pkg.cache[i]
 	}
+	return nil
+}
 
-	// calculate hashes for all packages
-	hashReader, err := e.ReadDir("/var/lib/dpkg/info")
+// readLicenses collects license info from copyright files
+func (pkg *dpkg) readLicenses(e executor.Executor) error {
+	// minimal images may have no documentation
+	licReader, err := e.ReadDir(copyrightDir)
 	if err != nil {
-		return err
+		return nil
 	}
-	defer hashReader.Close()
-	tr := tar.NewReader(hashReader)
+	defer licReader.Close()
 
+	tr := tar.NewReader(licReader)
 	for {
 		hdr, err := tr.Next()
-		if errors.Is(err, io.EOF) {
+		if err == io.EOF {
 			break
 		}
-		if !strings.HasSuffix(hdr.Name, ".md5sums") {
+		if err != nil {
+			return fmt.Errorf("error reading file from container: %w", err)
+		}
+		fields := strings.Split(hdr.Name, "/")
+		if len(fields) != 3 { // expect doc/<package_name>/copyright
 			continue
 		}
-		// file name has a form of '/var/lib/dpkg/info/<pkg>[:arch].md5sums'
-		fields := strings.Split(strings.TrimSuffix(filepath.Base(hdr.Name), ".md5sums"), ":")
-		p, ok := pkg.index[fields[0]]
+		if fields[2] != "copyright" {
+			continue
+		}
+		pkg, ok := pkg.index[fields[1]]
 		if !ok {
-			continue // unknown package - maybe md5sums file is a leftover
+			continue
 		}
-		h := sha256.New()
-		if _, err := io.Copy(h, tr); err != nil {
-			return err
+		if licenses := findLicenses(tr); len(licenses) > 0 {
+			setLicense(pkg, licenses...)
 		}
-		p.Hash = hex.EncodeToString(h.Sum(nil))
-		p.HashType = artifact.HashSHA256
 	}
+	return nil
+}
 
-	// collect license info
-	licReader, err := e.ReadDir("/usr/share/doc")
+// readStatusD reads packages from status.d directory, where each file has a single package stanza. Distroless
+// images may also keep md5sums files there. If package has no md5sums file, hash of its stanza is used
+func readStatusD(e executor.Executor, hashes map[string]string) ([]osPackage, error) {
+	reader, err := e.ReadDir(dpkgStatusD)
 	if err != nil {
-		return fmt.Errorf("error reading file from container: %w", err)
+		return nil, fmt.Errorf("error reading file from container: %w", err)
 	}
-	defer licReader.Close()
+	defer reader.Close()
 
-	tr = tar.NewReader(licReader)
+	var res []osPackage
+	stanzaHashes := make(map[string]string)
+	tr := tar.NewReader(reader)
 	for {
 		hdr, err := tr.Next()
-		if err == io.EOF {
+		if errors.Is(err, io.EOF) {
 			break
 		}
 		if err != nil {
-			return fmt.Errorf("error reading file from container: %w", err)
+			return nil, fmt.Errorf("error reading file from container: %w", err)
 		}
-		fields := strings.Split(hdr.Name, "/")
-		if len(fields) != 3 { // expect doc/<package_name>/copyright
+		if !hdr.FileInfo().Mode().IsRegular() {
 			continue
 		}
-		if fields[2] != "copyright" {
+		buf, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file from container: %w", err)
+		}
+		sum := sha256.Sum256(buf)
+		if strings.HasSuffix(hdr.Name, md5sumsExt) {
+			hashes[strings.TrimSuffix(path.Base(hdr.Name), md5sumsExt)] = hex.EncodeToString(sum[:])
 			continue
 		}
-		pkg, ok := pkg.index[fields[1]]
-		if !ok {
+		for _, p := range parseStatus(buf) {
+			res = append(res, p)
+			stanzaHashes[p.Name] = hex.EncodeToString(sum[:])
+		}
+	}
+	for name, hash := range stanzaHashes {
+		if _, ok := hashes[name]; !ok {
+			hashes[name] = hash
+		}
+	}
+	return res, nil
+}
+
+// parseStatus parses package stanzas of dpkg status file
+func parseStatus(buf []byte) []osPackage {
+	res := make([]osPackage, 0)
+	scanner := bufio.NewScanner(bytes.NewBuffer(buf))
+	curPkg := osPackage{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.SplitN(line, ": ", 2)
+		if len(fields) != 2 {
 			continue
 		}
-		pkg.License = findLicense(tr)
+		switch fields[0] {
+		case "Package":
+			if curPkg.Name != "" {
+				res = append(res, curPkg)
+			}
+			curPkg = osPackage{Dependency: artifact.Dependency{Name: fields[1]}}
+		case "Version":
+			curPkg.Version = fields[1]
+		case "Architecture":
+			setProperty(&curPkg.Dependency, PropArch, fields[1])
+		case "Maintainer":
+			curPkg.Supplier = fields[1]
+		case "Source":
+			curPkg.setSource(trimConstraint(fields[1])) // source version follows in parentheses, if it differs
+		case "Depends", "Pre-Depends":
+			curPkg.requires = append(curPkg.requires, parseRelations(fields[1])...)
+		case "Provides":
+			for _, alternatives := range parseRelations(fields[1]) {
+				curPkg.provides = append(curPkg.provides, alternatives...)
+			}
+		}
 	}
-	return nil
+	if curPkg.Name != "" {
+		res = append(res, curPkg)
+	}
+	return res
 }
 
-// see https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/#license-syntax for details
-func findLicense(reader io.Reader) string {
+// parseRelations parses package relationship field, like 'libc6 (>= 2.34), debconf (>= 0.5) | debconf-2.0'.
+// Version constraints and architecture qualifiers are dropped, see
+// https://www.debian.org/doc/debian-policy/ch-relationships.html
+func parseRelations(field string) [][]string {
+	var res [][]string
+	for _, rel := range strings.Split(field, ",") {
+		var alternatives []string
+		for _, alt := range strings.Split(rel, "|") {
+			name := trimConstraint(strings.TrimSpace(alt))
+			if i := strings.IndexByte(name, ':'); i >= 0 {
+				name = name[:i] // architecture qualifier, f.e. 'python3:any'
+			}
+			if name != "" {
+				alternatives = append(alternatives, name)
+			}
+		}
+		if len(alternatives) > 0 {
+			res = append(res, alternatives)
+		}
+	}
+	return res
+}
+
+// autoInstalled returns names of packages, marked by APT as automatically installed in extended_states file
+func autoInstalled(buf []byte) map[string]bool {
+	res := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewBuffer(buf))
+	name := ""
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ": ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "Package":
+			name = fields[1]
+		case "Auto-Installed":
+			if fields[1] == "1" {
+				res[name] = true
+			}
+		}
+	}
+	return res
+}
+
+// md5sumsHashes calculates hashes of md5sums files in TAR stream of dpkg info directory
+func md5sumsHashes(reader io.Reader, hashes map[string]string) error {
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(hdr.Name, md5sumsExt) {
+			continue
+		}
+		// file name has a form of '/var/lib/dpkg/info/<pkg>[:arch].md5sums'
+		fields := strings.Split(strings.TrimSuffix(path.Base(hdr.Name), md5sumsExt), ":")
+		h := sha256.New()
+		if _, err := io.Copy(h, tr); err != nil {
+			return err
+		}
+		hashes[fields[0]] = hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+// findLicenses returns licenses, declared by copyright file. Machine-readable (DEP-5) file has licenses in
+// the header and in all 'Files' stanzas, stand-alone 'License' stanzas only have license texts, see
+// https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/ for details. Other files may have
+// the license or the reference to the common license
+func findLicenses(reader io.Reader) []string {
 	scanner := bufio.NewScanner(reader)
 
-	license := ""
+	var licenses []string
+	seen := make(map[string]bool)
+	dep5, header, files, empty := false, true, false, true
+	stanzaLicense, fallback := "", ""
+	endStanza := func() {
+		if dep5 && (header || files) && stanzaLicense != "" && !seen[stanzaLicense] {
+			licenses = append(licenses, stanzaLicense)
+			seen[stanzaLicense] = true
+		}
+		if !empty {
+			header = false
+		}
+		files, empty, stanzaLicense = false, true, ""
+	}
 	for scanner.Scan() {
 		line := scanner.Text()
-		match := licensePattern.FindStringSubmatch(line)
-		if len(match) > 0 {
-			license = match[1]
-			break
+		if fallback == "" {
+			if match := licensePattern.FindStringSubmatch(line); len(match) > 0 {
+				fallback = strings.TrimSpace(match[1])
+			} else if match = commonLicensePathPattern.FindStringSubmatch(line); len(match) > 0 {
+				fallback = match[1]
+			}
 		}
-		match = commonLicensePathPattern.FindStringSubmatch(line)
-		if len(match) > 0 {
-			license = match[1]
-			break
+		if strings.TrimSpace(line) == "" {
+			endStanza()
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue // continuation of multi-line field
+		}
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) != 2 {
+			continue
 		}
+		switch strings.ToLower(fields[0]) {
+		case "format":
+			dep5 = dep5 || header && empty
+		case "files":
+			files = true
+		case "license":
+			stanzaLicense = strings.TrimSpace(fields[1])
+		}
+		empty = false
 	}
+	endStanza()
 
-	return license
+	if len(licenses) == 0 && fallback != "" {
+		licenses = []string{fallback}
+	}
+	return licenses
 }
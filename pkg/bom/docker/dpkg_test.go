@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package docker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	}
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// distroless images have no dpkg info directory, each package is in its own status.d file
+func TestDpkgStatusD(t *testing.T) {
+	files := map[string]string{
+		"var/lib/dpkg/status.d/base-files":    "Package: base-files\nVersion: 12.4+deb12u5\nArchitecture: amd64\n",
+		"var/lib/dpkg/status.d/libc6":         "Package: libc6\nVersion: 2.36-9+deb12u4\n",
+		"var/lib/dpkg/status.d/libc6.md5sums": "0123456789abcdef0123456789abcdef  lib/x86_64-linux-gnu/libc.so.6\n",
+		"var/lib/dpkg/status.d/tzdata":        "Package: tzdata\nVersion: 2024a-0+deb12u1",
+		"usr/share/doc/tzdata/copyright":      "License: public-domain\n",
+		"usr/lib/os-release":                  "ID=debian\nVERSION_ID=\"12\"\n",
+	}
+	root := t.TempDir()
+	writeFiles(t, root, files)
+	e, err := executor.NewDirExecutor(root)
+	assert.NoError(t, err)
+
+	a, err := NewFromExecutor("distroless", e)
+	assert.NoError(t, err)
+	assert.Equal(t, DPKG, a.Type())
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	assert.Equal(t, []artifact.Dependency{{
+		Name:       "base-files",
+		Version:    "12.4+deb12u5",
+		Hash:       sha256Hex(files["var/lib/dpkg/status.d/base-files"]),
+		HashType:   artifact.HashSHA256,
+		Kind:       DPKG,
+		Properties: map[string]string{PropArch: "amd64", PropDistro: "debian-12"},
+	}, {
+		Name:       "libc6",
+		Version:    "2.36-9+deb12u4",
+		Hash:       sha256Hex(files["var/lib/dpkg/status.d/libc6.md5sums"]),
+		HashType:   artifact.HashSHA256,
+		Kind:       DPKG,
+		Properties: map[string]string{PropDistro: "debian-12"},
+	}, {
+		Name:       "tzdata",
+		Version:    "2024a-0+deb12u1",
+		Hash:       sha256Hex(files["var/lib/dpkg/status.d/tzdata"]),
+		HashType:   artifact.HashSHA256,
+		License:    "LicenseRef-public-domain",
+		Kind:       DPKG,
+		Properties: map[string]string{PropDistro: "debian-12"},
+
+		LicenseDeclared: "public-domain",
+	}}, deps)
+}
+
+func TestDpkgDependencyGraph(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"var/lib/dpkg/status": "Package: curl\nVersion: 7.88.1-10\nDepends: libc6 (>= 2.34), libcurl4 (= 7.88.1-10)\n\n" +
+			"Package: libcurl4\nSource: curl\nVersion: 7.88.1-10\nPre-Depends: libc6:amd64\n" +
+			"Depends: ca-certificates | ssl-certs, missing-lib\n\n" +
+			"Package: libc6\nSource: glibc (2.36-8)\nVersion: 2.36-9\n\n" +
+			"Package: openssl-certs\nVersion: 1.0\nProvides: ssl-certs (= 1.0)\n\n" +
+			"Package: base-files\nVersion: 12.4\n",
+		"var/lib/apt/extended_states": "Package: libcurl4\nArchitecture: amd64\nAuto-Installed: 1\n\n" +
+			"Package: openssl-certs\nArchitecture: all\nAuto-Installed: 1\n\n" +
+			"Package: curl\nArchitecture: amd64\nAuto-Installed: 0\n",
+	})
+	e, err := executor.NewDirExecutor(root)
+	assert.NoError(t, err)
+
+	a, err := NewFromExecutor("debian", e)
+	assert.NoError(t, err)
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+	types := make(map[string]artifact.DepType, len(deps))
+	sources := make(map[string]string)
+	for _, d := range deps {
+		types[d.Name] = d.Type
+		if source, ok := d.Properties[PropSource]; ok {
+			sources[d.Name] = source
+		}
+	}
+	assert.Equal(t, map[string]string{"libcurl4": "curl", "libc6": "glibc"}, sources)
+	assert.Equal(t, map[string]artifact.DepType{
+		"curl":          artifact.DepDirect,    // manually installed
+		"base-files":    artifact.DepDirect,    // installed without APT
+		"libc6":         artifact.DepDirect,    // no auto mark - installed without APT, but also required
+		"libcurl4":      artifact.DepTransient, // auto-installed
+		"openssl-certs": artifact.DepTransient, // auto-installed, provides virtual package
+	}, types)
+
+	// no APT state - packages, not required by others, are direct
+	assert.NoError(t, os.Remove(filepath.Join(root, "var", "lib", "apt", "extended_states")))
+	a, err = NewFromExecutor("debian", e)
+	assert.NoError(t, err)
+	deps, err = a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+	for _, d := range deps {
+		types[d.Name] = d.Type
+	}
+	assert.Equal(t, map[string]artifact.DepType{
+		"curl":          artifact.DepDirect,
+		"base-files":    artifact.DepDirect,
+		"libc6":         artifact.DepTransient,
+		"libcurl4":      artifact.DepTransient,
+		"openssl-certs": artifact.DepTransient,
+	}, types)
+}
+
+func TestParseRelations(t *testing.T) {
+	assert.Equal(t, [][]string{{"libc6"}, {"debconf", "debconf-2.0"}, {"python3"}},
+		parseRelations("libc6 (>= 2.34), debconf (>= 0.5) | debconf-2.0, python3:any"))
+	assert.Empty(t, parseRelations(""))
+}
+
+func TestImageWithoutPackageManager(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"app/server": "binary"})
+	e, err := executor.NewDirExecutor(root)
+	assert.NoError(t, err)
+
+	a, err := NewFromExecutor("scratch", e)
+	assert.NoError(t, err)
+	assert.Equal(t, Image, a.Type())
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+	assert.Empty(t, deps)
+}
+
+func TestFindLicenses(t *testing.T) {
+	dep5 := `Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+Upstream-Name: perl
+License: GPL-1+ or Artistic
+
+Files: *
+Copyright: 1993-2023, Larry Wall and others
+License: GPL-1+ or Artistic
+
+Files: cpan/Compress-Raw-Zlib/zlib-src/*
+Copyright: 1995-2022, Jean-loup Gailly and Mark Adler
+License: Zlib
+
+Files: regen/*
+Copyright: 2010, Nicholas Clark
+License: BSD-3-clause
+
+License: Zlib
+ This software is provided 'as-is', without any express or implied
+ warranty.
+License: Other
+`
+	licenses := findLicenses(strings.NewReader(dep5))
+	assert.Equal(t, []string{"GPL-1+ or Artistic", "Zlib", "BSD-3-clause"}, licenses)
+
+	var d artifact.Dependency
+	setLicense(&d, findLicenses(strings.NewReader("Format: x\n\nFiles: *\nLicense: GPL-2+\n\nFiles: a\nLicense: Expat\n"))...)
+	assert.Equal(t, "GPL-2.0-or-later AND MIT", d.License)
+	assert.Equal(t, "GPL-2+; Expat", d.LicenseDeclared)
+
+	plain := "This package was debianized by someone.\n\nOn Debian systems, the complete text of the GNU General\n" +
+		"Public License can be found in `/usr/share/common-licenses/GPL-2'.\n"
+	assert.Equal(t, []string{"GPL-2"}, findLicenses(strings.NewReader(plain)))
+	assert.Empty(t, findLicenses(strings.NewReader("no license\n")))
+}
+
+func TestParseOSRelease(t *testing.T) {
+	fields := parseOSRelease([]byte("# comment\nNAME=\"Alpine Linux\"\nID=alpine\nVERSION_ID=3.18.4\n" +
+		"PRETTY_NAME='Alpine Linux v3.18'\nHOME_URL=\"https://alpinelinux.org/\"\n"))
+	assert.Equal(t, map[string]string{
+		"NAME":        "Alpine Linux",
+		"ID":          "alpine",
+		"VERSION_ID":  "3.18.4",
+		"PRETTY_NAME": "Alpine Linux v3.18",
+		"HOME_URL":    "https://alpinelinux.org/",
+	}, fields)
+}
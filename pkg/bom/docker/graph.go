@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package docker
+
+import (
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+	"github.com/codenotary/cas/pkg/bom/license"
+)
+
+// properties of OS packages
+const (
+	PropSource = "SourcePackage" // name of the source package, OS package is built from, if it differs
+	PropArch   = "Architecture"  // architecture of the package, as named by the package manager
+	PropDistro = "Distro"        // distribution release from os-release, '<ID>-<VERSION_ID>', f.e. 'debian-12'
+)
+
+// osPackage is an installed OS package together with its relations to other packages
+type osPackage struct {
+	artifact.Dependency
+	provides []string   // capabilities provided in addition to package name - virtual packages, libraries, files
+	requires [][]string // requirements, each of them can be satisfied by any of the alternatives
+}
+
+// setSource records the name of the source package, unless it is the same as the package name
+func (p *osPackage) setSource(name string) {
+	if name == "" || name == p.Name {
+		return
+	}
+	setProperty(&p.Dependency, PropSource, name)
+}
+
+// setProperty sets the dependency property, unless the value is empty
+func setProperty(d *artifact.Dependency, key, value string) {
+	if value == "" {
+		return
+	}
+	if d.Properties == nil {
+		d.Properties = make(map[string]string, 1)
+	}
+	d.Properties[key] = value
+}
+
+// setLicense normalizes the licenses, stated by package metadata, to SPDX expression, keeping the original ones
+func setLicense(d *artifact.Dependency, declared ...string) {
+	d.License = license.Normalize(declared...)
+	if text := strings.Join(declared, "; "); d.License != text {
+		d.LicenseDeclared = text
+	}
+}
+
+// packageGraph builds dependency graph of installed packages, resolving requirements by package names and
+// provided capabilities. Unsatisfied requirements are ignored. Packages, satisfying explicit requirements
+// (f.e. explicitly installed by user), are direct dependencies. If explicit requirements are unknown (nil),
+// packages not required by any other package are direct. All other packages are transient
+func packageGraph(pkgs []osPackage, explicit []string) []artifact.Dependency {
+	// package names take precedence over capabilities provided by other packages
+	providers := make(map[string]*osPackage, len(pkgs))
+	for i := range pkgs {
+		for _, name := range pkgs[i].provides {
+			if _, ok := providers[name]; !ok {
+				providers[name] = &pkgs[i]
+			}
+		}
+	}
+	for i := range pkgs {
+		providers[pkgs[i].Name] = &pkgs[i]
+	}
+	resolve := func(alternatives []string) *osPackage {
+		for _, name := range alternatives {
+			if p, ok := providers[name]; ok {
+				return p
+			}
+		}
+		return nil
+	}
+
+	g := depgraph.NewGraph("", "")
+	required := make(map[*osPackage]bool, len(pkgs))
+	for i := range pkgs {
+		p := &pkgs[i]
+		parent := g.NewNode(p.Name, p.Version, &p.Dependency)
+		children := make(map[*osPackage]bool, len(p.requires))
+		for _, alternatives := range p.requires {
+			child := resolve(alternatives)
+			if child == nil || child == p || children[child] {
+				continue
+			}
+			children[child] = true
+			required[child] = true
+			g.AddChild(parent, child.Name, child.Version, &child.Dependency)
+		}
+	}
+
+	if explicit != nil {
+		for _, name := range explicit {
+			if p := resolve([]string{name}); p != nil {
+				g.AddChild(g.Root, p.Name, p.Version, nil)
+			}
+		}
+	} else {
+		for i := range pkgs {
+			if !required[&pkgs[i]] {
+				g.AddChild(g.Root, pkgs[i].Name, pkgs[i].Version, nil)
+			}
+		}
+	}
+
+	return g.FlatDeps()
+}
+
+// trimConstraint removes version constraint from requirement or capability, f.e. 'libc6 (>= 2.34)', 'musl>=1.2'
+// or 'cmd:busybox=1.36.1-r2'
+func trimConstraint(s string) string {
+	if i := strings.IndexAny(s, " (<>=~"); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package docker
+
+import (
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+	"github.com/codenotary/cas/pkg/bom/java"
+)
+
+// javaPkg implements packageManager interface for JVM archives in common application locations
+type javaPkg struct{}
+
+func (pkg javaPkg) Type() string {
+	return java.AssetType
+}
+
+// AllPackages finds JVM archives in common application locations
+func (pkg javaPkg) AllPackages(e executor.Executor, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	return java.InstalledArchives(e)
+}
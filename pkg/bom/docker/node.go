@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package docker
+
+import (
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+	"github.com/codenotary/cas/pkg/bom/javascript"
+)
+
+// nodePkg implements packageManager interface for npm packages in global module directories and common application locations
+type nodePkg struct{}
+
+func (pkg nodePkg) Type() string {
+	return javascript.AssetType
+}
+
+// AllPackages finds npm packages in global module directories and common application locations
+func (pkg nodePkg) AllPackages(e executor.Executor, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	return javascript.InstalledPackages(e, output)
+}
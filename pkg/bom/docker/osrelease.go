@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+// os-release locations, in order of preference, see https://www.freedesktop.org/software/systemd/man/os-release.html
+var osReleaseFiles = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// distroRelease identifies distribution release of the image as '<ID>-<VERSION_ID>', f.e. 'debian-12' or
+// 'alpine-3.18.4', or just '<ID>' for rolling releases. Empty string is returned if there is no os-release file
+func distroRelease(e executor.Executor) string {
+	for _, name := range osReleaseFiles {
+		buf, err := e.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		fields := parseOSRelease(buf)
+		id := strings.ToLower(fields["ID"])
+		if id == "" {
+			return ""
+		}
+		if version := fields["VERSION_ID"]; version != "" {
+			return id + "-" + version
+		}
+		return id
+	}
+	return ""
+}
+
+// parseOSRelease parses environment-like assignments of os-release file, values may be quoted
+func parseOSRelease(buf []byte) map[string]string {
+	res := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.SplitN(line, "=", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		value := fields[1]
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `'"`)
+		}
+		res[fields[0]] = value
+	}
+	return res
+}
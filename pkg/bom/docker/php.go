@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package docker
+
+import (
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+	"github.com/codenotary/cas/pkg/bom/php"
+)
+
+// phpPkg implements packageManager interface for Composer packages installed in PHP applications
+type phpPkg struct{}
+
+func (pkg phpPkg) Type() string {
+	return php.AssetType
+}
+
+// AllPackages finds Composer packages in common application locations
+func (pkg phpPkg) AllPackages(e executor.Executor, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	return php.InstalledPackages(e, output)
+}
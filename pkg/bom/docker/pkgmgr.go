@@ -22,30 +22,46 @@ type pkgManager interface {
 
 var ErrNotFound = errors.New("not found")
 
-func probePackageManager(e executor.Executor) (pkgManager, error) {
-	_, _, exitCode, err := e.Exec([]string{"apk", "--version"})
-	if err != nil {
-		return nil, err
-	}
-	if exitCode == 0 {
-		return &apk{}, nil
-	}
+// package manager databases, used for identifying package manager if commands cannot be executed
+var pkgDatabases = []struct {
+	paths []string
+	pkg   func() pkgManager
+}{
+	{[]string{"/lib/apk/db/installed"}, func() pkgManager { return &apk{} }},
+	{[]string{dpkgStatus, dpkgStatusD}, func() pkgManager { return &dpkg{} }},
+	{rpmDatabases, func() pkgManager { return &rpm{} }},
+}
 
-	_, _, exitCode, err = e.Exec([]string{"dpkg", "--version"})
-	if err != nil {
-		return nil, err
-	}
-	if exitCode == 0 {
-		return &dpkg{}, nil
+// probePackageManagers returns all OS package managers, found in the image. Images rarely have more than one,
+// but nothing prevents installing f.e. rpm on Debian
+func probePackageManagers(e executor.Executor) ([]pkgManager, error) {
+	var res []pkgManager
+	for _, db := range pkgDatabases {
+		pkg := db.pkg()
+		_, _, exitCode, err := e.Exec([]string{pkg.Type(), "--version"})
+		if errors.Is(err, executor.ErrNotSupported) {
+			return probePackageDatabases(e), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if exitCode == 0 {
+			res = append(res, pkg)
+		}
 	}
+	return res, nil
+}
 
-	_, _, exitCode, err = e.Exec([]string{"rpm", "--version"})
-	if err != nil {
-		return nil, err
-	}
-	if exitCode == 0 {
-		return &rpm{}, nil
+// probePackageDatabases identifies package managers by their databases
+func probePackageDatabases(e executor.Executor) []pkgManager {
+	var res []pkgManager
+	for _, db := range pkgDatabases {
+		for _, p := range db.paths {
+			if executor.Exists(e, p) {
+				res = append(res, db.pkg())
+				break
+			}
+		}
 	}
-
-	return nil, nil // cannot identify
+	return res
 }
@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package docker
+
+import (
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+	"github.com/codenotary/cas/pkg/bom/python"
+)
+
+// pythonPkg implements packageManager interface for Python distributions installed in site-packages
+type pythonPkg struct{}
+
+func (pkg pythonPkg) Type() string {
+	return python.AssetType
+}
+
+// AllPackages finds all installed Python distributions
+func (pkg pythonPkg) AllPackages(e executor.Executor, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	return python.InstalledPackages(e, output)
+}
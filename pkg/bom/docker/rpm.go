@@ -5,18 +5,33 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 
-	rpmdb "github.com/anchore/go-rpmdb/pkg"
+	rpmdb "github.com/knqyf263/go-rpmdb/pkg"
 
 	"github.com/codenotary/cas/pkg/bom/artifact"
 	"github.com/codenotary/cas/pkg/bom/executor"
 )
 
 type rpm struct {
-	db     *rpmdb.RpmDB
-	byFile map[string]*rpmdb.PackageInfo
+	pkgList []*rpmdb.PackageInfo
 }
 
+// RPM database locations in order of preference: SQLite (Fedora 33+, RHEL 9), NDB (SUSE) and BerkeleyDB.
+// Recent distributions keep database in /usr/lib/sysimage/rpm
+var rpmDatabases = []string{
+	"/var/lib/rpm/rpmdb.sqlite",
+	"/usr/lib/sysimage/rpm/rpmdb.sqlite",
+	"/var/lib/rpm/Packages.db",
+	"/usr/lib/sysimage/rpm/Packages.db",
+	"/var/lib/rpm/Packages",
+}
+
+// suffixes of SQLite write-ahead log and shared memory files
+var sqliteJournals = []string{"-wal", "-shm"}
+
 var hashTypeMaps = map[rpmdb.DigestAlgorithm]artifact.HashType{
 	rpmdb.PGPHASHALGO_MD5:    artifact.HashMD5,
 	rpmdb.PGPHASHALGO_SHA1:   artifact.HashSHA1,
@@ -32,26 +47,35 @@ func (pkg rpm) Type() string {
 }
 
 func (pkg *rpm) AllPackages(e executor.Executor, output artifact.OutputOptions) ([]artifact.Dependency, error) {
-	var err error
-	if pkg.db == nil {
-		pkg.db, err = openDb(e)
+	if pkg.pkgList == nil {
+		var err error
+		pkg.pkgList, err = listPackages(e)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	pkgList, err := pkg.db.ListPackages()
-	if err != nil {
-		return nil, fmt.Errorf("cannot read RPM database: %w", err)
+	// file requirements, like '/bin/sh', are satisfied by packages owning the file
+	requiredFiles := make(map[string]bool)
+	for _, p := range pkg.pkgList {
+		for _, name := range p.Requires {
+			if strings.HasPrefix(name, "/") {
+				requiredFiles[name] = true
+			}
+		}
 	}
 
-	res := make([]artifact.Dependency, 0, len(pkgList))
-	for _, p := range pkgList {
+	pkgs := make([]osPackage, 0, len(pkg.pkgList))
+	for _, p := range pkg.pkgList {
 		hashtype, ok := hashTypeMaps[p.DigestAlgorithm]
 		if !ok {
 			hashtype = artifact.HashInvalid
 		}
-		hash, err := combineHashesFromSlice(p.Files, p.Name)
+		files, err := p.InstalledFiles()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read RPM database: %w", err)
+		}
+		hash, err := combineHashesFromSlice(files, p.Name)
 		if err != nil {
 			fmt.Printf("Cannot combine hashes: %v\n", err)
 			// ignore error
@@ -59,47 +83,90 @@ func (pkg *rpm) AllPackages(e executor.Executor, output artifact.OutputOptions)
 		if hash == "" {
 			continue
 		}
-		if p.License == "" {
-			p.License = "NONE"
-		}
-		res = append(res, artifact.Dependency{
-			Name:     p.Name,
-			Version:  p.Version + "-" + p.Release,
-			HashType: hashtype,
-			Hash:     hash,
-			License:  p.License,
-			Type:     artifact.DepDirect,
-		})
+		provides := append([]string{}, p.Provides...)
+		for _, file := range files {
+			if requiredFiles[file.Path] {
+				provides = append(provides, file.Path)
+			}
+		}
+		requires := make([][]string, 0, len(p.Requires))
+		for _, name := range p.Requires {
+			requires = append(requires, []string{name})
+		}
+		osPkg := osPackage{
+			Dependency: artifact.Dependency{
+				Name:     p.Name,
+				Version:  p.Version + "-" + p.Release,
+				HashType: hashtype,
+				Hash:     hash,
+				License:  "NONE",
+				Supplier: p.Vendor,
+			},
+			provides: provides,
+			requires: requires,
+		}
+		if p.License != "" {
+			setLicense(&osPkg.Dependency, p.License)
+		}
+		if p.Arch != "(none)" {
+			setProperty(&osPkg.Dependency, PropArch, p.Arch)
+		}
+		pkgs = append(pkgs, osPkg)
 	}
 
-	return res, nil
+	// RPM database has no record of explicitly installed packages
+	return packageGraph(pkgs, nil), nil
 }
 
-func openDb(e executor.Executor) (*rpmdb.RpmDB, error) {
-	buf, err := e.ReadFile("/var/lib/rpm/Packages")
+// listPackages reads packages from the first RPM database found. Backend is detected by the database content
+func listPackages(e executor.Executor) ([]*rpmdb.PackageInfo, error) {
+	var buf []byte
+	var err error
+	var dbPath string
+	for _, dbPath = range rpmDatabases {
+		if buf, err = e.ReadFile(dbPath); err == nil && len(buf) > 0 {
+			break
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error reading file from container: %w", err)
 	}
 
-	f, err := ioutil.TempFile("", "cas.rpmdb")
+	// database is copied under its own name, so that SQLite finds the journal files next to it
+	dir, err := ioutil.TempDir("", "cas.rpmdb")
 	if err != nil {
-		return nil, fmt.Errorf("cannot create temporary file: %w", err)
+		return nil, fmt.Errorf("cannot create temporary directory: %w", err)
 	}
-	rpmFile := f.Name()
-	defer os.Remove(rpmFile) // clean up
+	defer os.RemoveAll(dir) // clean up
 
-	_, err = f.Write(buf)
-	if err != nil {
+	rpmFile := filepath.Join(dir, path.Base(dbPath))
+	if err = ioutil.WriteFile(rpmFile, buf, 0600); err != nil {
 		return nil, fmt.Errorf("cannot write temporary file: %w", err)
 	}
-	f.Close()
+	if strings.HasSuffix(dbPath, ".sqlite") {
+		// recent transactions may be kept in write-ahead log, not yet merged into the database
+		for _, suffix := range sqliteJournals {
+			buf, err := e.ReadFile(dbPath + suffix)
+			if err != nil {
+				continue
+			}
+			if err = ioutil.WriteFile(rpmFile+suffix, buf, 0600); err != nil {
+				return nil, fmt.Errorf("cannot write temporary file: %w", err)
+			}
+		}
+	}
 
 	db, err := rpmdb.Open(rpmFile)
 	if err != nil {
 		return nil, fmt.Errorf("cannot read RPM database: %w", err)
 	}
+	defer db.Close()
 
-	return db, err
+	pkgList, err := db.ListPackages()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read RPM database: %w", err)
+	}
+	return pkgList, nil
 }
 
 func combineHashesFromSlice(files []rpmdb.FileInfo, pkgName string) (string, error) {
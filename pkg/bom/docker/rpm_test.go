@@ -0,0 +1,192 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package docker
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	_ "github.com/glebarez/go-sqlite"
+	rpmdb "github.com/knqyf263/go-rpmdb/pkg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+// rpmTag is a header entry, data is int32 slice, string or string slice
+type rpmTag struct {
+	tag  int32
+	data interface{}
+}
+
+// rpmHeader builds legacy (region-less) RPM header blob, as stored in RPM database
+func rpmHeader(tags []rpmTag) []byte {
+	var index, data bytes.Buffer
+	for _, t := range tags {
+		var typ, count uint32
+		offset := int32(data.Len())
+		switch v := t.data.(type) {
+		case []int32:
+			typ, count = rpmdb.RPM_INT32_TYPE, uint32(len(v))
+			binary.Write(&data, binary.BigEndian, v)
+		case string:
+			typ, count = rpmdb.RPM_STRING_TYPE, 1
+			data.WriteString(v + "\x00")
+		case []string:
+			typ, count = rpmdb.RPM_STRING_ARRAY_TYPE, uint32(len(v))
+			for _, s := range v {
+				data.WriteString(s + "\x00")
+			}
+		}
+		binary.Write(&index, binary.BigEndian, struct {
+			Tag    int32
+			Type   uint32
+			Offset int32
+			Count  uint32
+		}{t.tag, typ, offset, count})
+	}
+	var blob bytes.Buffer
+	binary.Write(&blob, binary.BigEndian, []int32{int32(len(tags)), int32(data.Len())})
+	blob.Write(index.Bytes())
+	blob.Write(data.Bytes())
+	return blob.Bytes()
+}
+
+func TestRpmSQLite(t *testing.T) {
+	root := t.TempDir()
+	filename := filepath.Join(root, "usr", "lib", "sysimage", "rpm", "rpmdb.sqlite")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+
+	db, err := sql.Open("sqlite", filename)
+	assert.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE Packages (hnum INTEGER PRIMARY KEY AUTOINCREMENT, blob BLOB NOT NULL)")
+	assert.NoError(t, err)
+	// integer tags go first to avoid alignment padding
+	header := rpmHeader([]rpmTag{
+		{rpmdb.RPMTAG_DIRINDEXES, []int32{0, 0, 1}},
+		{rpmdb.RPMTAG_FILEDIGESTALGO, []int32{rpmdb.PGPHASHALGO_SHA256}},
+		{rpmdb.RPMTAG_NAME, "bash"},
+		{rpmdb.RPMTAG_VERSION, "5.1.8"},
+		{rpmdb.RPMTAG_RELEASE, "6.el9"},
+		{rpmdb.RPMTAG_LICENSE, "GPLv3+"},
+		{rpmdb.RPMTAG_BASENAMES, []string{"bash", "sh", "bashrc"}},
+		{rpmdb.RPMTAG_DIRNAMES, []string{"/usr/bin/", "/etc/"}},
+		{rpmdb.RPMTAG_FILEDIGESTS, []string{
+			"00000000000000000000000000000000000000000000000000000000000000ff",
+			"", // symbolic link
+			"000000000000000000000000000000000000000000000000000000000000ff00",
+		}},
+	})
+	_, err = db.Exec("INSERT INTO Packages (blob) VALUES (?)", header)
+	assert.NoError(t, err)
+	// requires bash by file name
+	header = rpmHeader([]rpmTag{
+		{rpmdb.RPMTAG_DIRINDEXES, []int32{0}},
+		{rpmdb.RPMTAG_FILEDIGESTALGO, []int32{rpmdb.PGPHASHALGO_SHA256}},
+		{rpmdb.RPMTAG_NAME, "which"},
+		{rpmdb.RPMTAG_VERSION, "2.21"},
+		{rpmdb.RPMTAG_RELEASE, "28.el9"},
+		{rpmdb.RPMTAG_LICENSE, "GPLv3"},
+		{rpmdb.RPMTAG_REQUIRENAME, []string{"/usr/bin/sh", "rpmlib(CompressedFileNames)"}},
+		{rpmdb.RPMTAG_BASENAMES, []string{"which"}},
+		{rpmdb.RPMTAG_DIRNAMES, []string{"/usr/bin/"}},
+		{rpmdb.RPMTAG_FILEDIGESTS, []string{
+			"0000000000000000000000000000000000000000000000000000000000000001",
+		}},
+	})
+	_, err = db.Exec("INSERT INTO Packages (blob) VALUES (?)", header)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	e, err := executor.NewDirExecutor(root)
+	assert.NoError(t, err)
+	a, err := NewFromExecutor("ubi9", e)
+	assert.NoError(t, err)
+	assert.Equal(t, RPM, a.Type())
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	assert.Equal(t, []artifact.Dependency{{
+		Name:     "bash",
+		Version:  "5.1.8-6.el9",
+		Hash:     "000000000000000000000000000000000000000000000000000000000000ffff",
+		HashType: artifact.HashSHA256,
+		License:  "GPL-3.0-or-later",
+		Kind:     RPM,
+		Type:     artifact.DepTransient,
+
+		LicenseDeclared: "GPLv3+",
+	}, {
+		Name:     "which",
+		Version:  "2.21-28.el9",
+		Hash:     "0000000000000000000000000000000000000000000000000000000000000001",
+		HashType: artifact.HashSHA256,
+		License:  "GPL-3.0-only",
+		Kind:     RPM,
+		Type:     artifact.DepDirect,
+		Requires: []artifact.DepRef{{Name: "bash", Version: "5.1.8-6.el9"}},
+
+		LicenseDeclared: "GPLv3",
+	}}, deps)
+}
+
+func TestRpmSQLiteWAL(t *testing.T) {
+	// database is copied while open, committed package is only in write-ahead log
+	filename := filepath.Join(t.TempDir(), "rpmdb.sqlite")
+	db, err := sql.Open("sqlite", filename)
+	assert.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		"PRAGMA journal_mode=WAL",
+		"PRAGMA wal_autocheckpoint=0",
+		"CREATE TABLE Packages (hnum INTEGER PRIMARY KEY AUTOINCREMENT, blob BLOB NOT NULL)",
+	} {
+		_, err = db.Exec(stmt)
+		assert.NoError(t, err)
+	}
+	header := rpmHeader([]rpmTag{
+		{rpmdb.RPMTAG_DIRINDEXES, []int32{0}},
+		{rpmdb.RPMTAG_FILEDIGESTALGO, []int32{rpmdb.PGPHASHALGO_SHA256}},
+		{rpmdb.RPMTAG_NAME, "bash"},
+		{rpmdb.RPMTAG_VERSION, "5.1.8"},
+		{rpmdb.RPMTAG_RELEASE, "6.el9"},
+		{rpmdb.RPMTAG_BASENAMES, []string{"bash"}},
+		{rpmdb.RPMTAG_DIRNAMES, []string{"/usr/bin/"}},
+		{rpmdb.RPMTAG_FILEDIGESTS, []string{
+			"00000000000000000000000000000000000000000000000000000000000000ff",
+		}},
+	})
+	_, err = db.Exec("INSERT INTO Packages (blob) VALUES (?)", header)
+	assert.NoError(t, err)
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "var", "lib", "rpm")
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		buf, err := ioutil.ReadFile(filename + suffix)
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "rpmdb.sqlite"+suffix), buf, 0644))
+	}
+
+	e, err := executor.NewDirExecutor(root)
+	assert.NoError(t, err)
+	pkgs, err := listPackages(e)
+	assert.NoError(t, err)
+	if assert.Len(t, pkgs, 1) {
+		assert.Equal(t, "bash", pkgs[0].Name)
+	}
+}
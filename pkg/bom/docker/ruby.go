@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package docker
+
+import (
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+	"github.com/codenotary/cas/pkg/bom/ruby"
+)
+
+// rubyPkg implements packageManager interface for Ruby gems installed in the system and Bundler locations
+type rubyPkg struct{}
+
+func (pkg rubyPkg) Type() string {
+	return ruby.AssetType
+}
+
+// AllPackages finds all installed gems
+func (pkg rubyPkg) AllPackages(e executor.Executor, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	return ruby.InstalledGems(e)
+}
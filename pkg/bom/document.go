@@ -0,0 +1,443 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package bom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	purl "github.com/package-url/packageurl-go"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/java"
+)
+
+// schemes of existing BOM documents, used as BOM source
+const (
+	SchemeSPDX      = "spdx"
+	SchemeCycloneDX = "cyclonedx"
+)
+
+// hash algorithms in order of preference, if document has several hashes for the component
+var hashPreference = []artifact.HashType{artifact.HashSHA256, artifact.HashSHA512, artifact.HashSHA384,
+	artifact.HashSHA224, artifact.HashSHA1, artifact.HashMD5}
+
+// docComponent is a component of imported BOM document
+type docComponent struct {
+	id       string
+	dep      artifact.Dependency
+	requires []string // IDs of required components
+}
+
+// NewFromSPDX loads dependencies from SPDX document in JSON or tag-value format. Packages, the described package
+// depends on, are direct dependencies
+func NewFromSPDX(filename string) (*artifact.LoadedArtifact, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var doc *spdxDocument
+	if isJSON(buf) {
+		doc = &spdxDocument{}
+		err = json.Unmarshal(buf, doc)
+	} else {
+		doc, err = parseSpdxText(buf)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse SPDX document %s: %w", filename, err)
+	}
+
+	rootID := ""
+	if len(doc.DocumentDescribes) > 0 {
+		rootID = doc.DocumentDescribes[0]
+	}
+	for _, r := range doc.Relationships {
+		if rootID != "" {
+			break
+		}
+		if r.SPDXElementID == spdxDocumentID && r.RelationshipType == "DESCRIBES" {
+			rootID = r.RelatedSPDXElement
+		} else if r.RelatedSPDXElement == spdxDocumentID && r.RelationshipType == "DESCRIBED_BY" {
+			rootID = r.SPDXElementID
+		}
+	}
+
+	name := doc.Name
+	root := &docComponent{id: rootID}
+	comps := make([]docComponent, 0, len(doc.Packages))
+	index := make(map[string]int, len(doc.Packages))
+	for _, p := range doc.Packages {
+		if p.SPDXID == rootID {
+			name = p.Name
+			continue
+		}
+		hashes := make(map[artifact.HashType]string, len(p.Checksums))
+		for _, c := range p.Checksums {
+			for hashType, algorithm := range spdxChecksumAlgorithms {
+				if algorithm == c.Algorithm {
+					hashes[hashType] = strings.ToLower(c.ChecksumValue)
+				}
+			}
+		}
+		dep := artifact.Dependency{
+			Name:     p.Name,
+			Version:  p.VersionInfo,
+			License:  p.LicenseConcluded,
+			Supplier: p.Supplier,
+		}
+		dep.HashType, dep.Hash = preferredHash(hashes)
+		if !isLicense(dep.License) {
+			dep.License = p.LicenseDeclared
+		}
+		if !isLicense(dep.License) {
+			dep.License = ""
+		}
+		if dep.Supplier == noAssertionStr {
+			dep.Supplier = ""
+		}
+		// 'Organization: name (e-mail)' to 'name <e-mail>'
+		if i := strings.Index(dep.Supplier, ": "); i >= 0 {
+			dep.Supplier = strings.NewReplacer("(", "<", ")", ">").Replace(dep.Supplier[i+2:])
+		}
+		for _, ref := range p.ExternalRefs {
+			switch ref.ReferenceType {
+			case "purl":
+				dep.Name = purlName(ref.ReferenceLocator, dep.Name)
+				dep.Kind = purlKind(ref.ReferenceLocator)
+				dep.Properties = purlProperties(ref.ReferenceLocator)
+			case "cpe23Type":
+				dep.CPEs = append(dep.CPEs, ref.ReferenceLocator)
+			}
+		}
+		index[p.SPDXID] = len(comps)
+		comps = append(comps, docComponent{id: p.SPDXID, dep: dep})
+	}
+
+	for _, r := range doc.Relationships {
+		from, to := r.SPDXElementID, r.RelatedSPDXElement
+		switch r.RelationshipType {
+		case "DEPENDS_ON":
+		case "DEPENDENCY_OF":
+			from, to = to, from
+		default:
+			continue
+		}
+		if from == rootID {
+			root.requires = append(root.requires, to)
+		} else if i, ok := index[from]; ok {
+			comps[i].requires = append(comps[i].requires, to)
+		}
+	}
+
+	return newFromDocument(name, SchemeSPDX, root, comps), nil
+}
+
+// parseSpdxText parses SPDX tag-value document. Only the tags, cas outputs itself, are processed
+func parseSpdxText(buf []byte) (*spdxDocument, error) {
+	headerTags := make(map[string]func(*spdxDocument, string), len(headerContent))
+	for _, line := range headerContent {
+		headerTags[line.tag] = line.set
+	}
+	packageTags := make(map[string]func(*spdxPackage, string), len(componentContent))
+	for _, line := range componentContent {
+		packageTags[line.tag] = line.parse
+	}
+
+	doc := &spdxDocument{}
+	var pkg *spdxPackage
+	inFile := false // file and snippet information follows the package and has the tags with the same names
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, ": ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed line '%s'", line)
+		}
+		tag, value := fields[0], fields[1]
+		// multi-line text value
+		for strings.HasPrefix(value, "<text>") && !strings.Contains(value, "</text>") && scanner.Scan() {
+			value += "\n" + scanner.Text()
+		}
+		switch {
+		case tag == "PackageName":
+			doc.Packages = append(doc.Packages, spdxPackage{})
+			pkg = &doc.Packages[len(doc.Packages)-1]
+			inFile = false
+		case tag == "FileName" || tag == "SnippetSPDXID":
+			inFile = true
+		case tag == "Relationship":
+			r := strings.Fields(value)
+			if len(r) != 3 {
+				return nil, fmt.Errorf("malformed relationship '%s'", value)
+			}
+			doc.Relationships = append(doc.Relationships,
+				spdxRelationship{SPDXElementID: r[0], RelationshipType: r[1], RelatedSPDXElement: r[2]})
+			continue
+		}
+		if pkg == nil {
+			if set, ok := headerTags[tag]; ok {
+				set(doc, value)
+			}
+		} else if set, ok := packageTags[tag]; ok && !inFile {
+			set(pkg, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// NewFromCycloneDX loads dependencies from CycloneDX document in JSON or XML format. Components, the metadata
+// component depends on, are direct dependencies
+func NewFromCycloneDX(filename string) (*artifact.LoadedArtifact, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	format := cdx.BOMFileFormatXML
+	if isJSON(buf) {
+		format = cdx.BOMFileFormatJSON
+	}
+	var bom cdx.BOM
+	if err := cdx.NewBOMDecoder(bytes.NewReader(buf), format).Decode(&bom); err != nil {
+		return nil, fmt.Errorf("cannot parse CycloneDX document %s: %w", filename, err)
+	}
+
+	name := filename
+	root := &docComponent{}
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		name = bom.Metadata.Component.Name
+		root.id = bom.Metadata.Component.BOMRef
+	}
+
+	var comps []docComponent
+	index := make(map[string]int)
+	var add func(components *[]cdx.Component)
+	add = func(components *[]cdx.Component) {
+		if components == nil {
+			return
+		}
+		for _, c := range *components {
+			hashes := make(map[artifact.HashType]string)
+			if c.Hashes != nil {
+				for _, h := range *c.Hashes {
+					for hashType, algorithm := range hashNames {
+						if algorithm == h.Algorithm {
+							hashes[hashType] = strings.ToLower(h.Value)
+						}
+					}
+				}
+			}
+			dep := artifact.Dependency{
+				Name:    c.Name,
+				Version: c.Version,
+				Kind:    purlKind(c.PackageURL),
+				License: cycloneLicense(c.Licenses),
+
+				Properties: purlProperties(c.PackageURL),
+			}
+			dep.HashType, dep.Hash = preferredHash(hashes)
+			if c.Group != "" {
+				sep := "/"
+				if dep.Kind == java.AssetType {
+					sep = ":"
+				}
+				dep.Name = c.Group + sep + c.Name
+			}
+			if c.Supplier != nil {
+				dep.Supplier = c.Supplier.Name
+			}
+			if c.CPE != "" {
+				dep.CPEs = []string{c.CPE}
+			}
+			if c.BOMRef != "" {
+				index[c.BOMRef] = len(comps)
+			}
+			comps = append(comps, docComponent{id: c.BOMRef, dep: dep})
+			add(c.Components)
+		}
+	}
+	add(bom.Components)
+
+	if bom.Dependencies != nil {
+		for _, d := range *bom.Dependencies {
+			if d.Dependencies == nil {
+				continue
+			}
+			requires := make([]string, 0, len(*d.Dependencies))
+			for _, r := range *d.Dependencies {
+				requires = append(requires, r.Ref)
+			}
+			if d.Ref == root.id && root.id != "" {
+				root.requires = append(root.requires, requires...)
+			} else if i, ok := index[d.Ref]; ok {
+				comps[i].requires = append(comps[i].requires, requires...)
+			}
+		}
+	}
+
+	return newFromDocument(name, SchemeCycloneDX, root, comps), nil
+}
+
+// cycloneLicense returns license expression, combining all licenses of the component
+func cycloneLicense(licenses *cdx.Licenses) string {
+	if licenses == nil {
+		return ""
+	}
+	var res []string
+	for _, l := range *licenses {
+		switch {
+		case l.Expression != "":
+			res = append(res, l.Expression)
+		case l.License != nil && l.License.ID != "":
+			res = append(res, l.License.ID)
+		case l.License != nil && l.License.Name != "":
+			res = append(res, l.License.Name)
+		}
+	}
+	return strings.Join(res, " AND ")
+}
+
+// newFromDocument builds artifact from document components. Components, required by the root, are direct
+// dependencies. If the root has no relations, components not required by any other component are direct.
+// Components without hash can be neither authenticated nor notarized, therefore they are skipped
+func newFromDocument(name, scheme string, root *docComponent, comps []docComponent) *artifact.LoadedArtifact {
+	direct := make(map[string]bool)
+	required := make(map[string]bool)
+	for _, c := range comps {
+		for _, id := range c.requires {
+			required[id] = true
+		}
+	}
+	for _, id := range root.requires {
+		direct[id] = true
+	}
+
+	byID := make(map[string]*docComponent, len(comps))
+	for i := range comps {
+		if comps[i].id != "" {
+			byID[comps[i].id] = &comps[i]
+		}
+	}
+
+	deps := make([]artifact.Dependency, 0, len(comps))
+	kind := ""
+	skipped := 0
+	for _, c := range comps {
+		if c.dep.Hash == "" {
+			skipped++
+			continue
+		}
+		dep := c.dep
+		if len(root.requires) > 0 {
+			dep.Type = artifact.DepType(!direct[c.id])
+		} else {
+			dep.Type = artifact.DepType(required[c.id])
+		}
+		for _, id := range c.requires {
+			if r, ok := byID[id]; ok && r.dep.Hash != "" {
+				dep.Requires = append(dep.Requires, artifact.DepRef{Name: r.dep.Name, Version: r.dep.Version})
+			}
+		}
+		if len(deps) == 0 {
+			kind = dep.Kind
+		} else if kind != dep.Kind {
+			kind = scheme // components of different kinds
+		}
+		deps = append(deps, dep)
+	}
+	if kind == "" {
+		kind = scheme
+	}
+	a := artifact.NewLoadedArtifact(name, kind, deps)
+	if skipped > 0 {
+		a.Warnings = append(a.Warnings, fmt.Sprintf("%d components have no supported hash and were skipped", skipped))
+	}
+	return a
+}
+
+// preferredHash returns the strongest hash of the component
+func preferredHash(hashes map[artifact.HashType]string) (artifact.HashType, string) {
+	for _, hashType := range hashPreference {
+		if hash, ok := hashes[hashType]; ok && hash != "" {
+			return hashType, hash
+		}
+	}
+	return artifact.HashInvalid, ""
+}
+
+// purlKind returns the kind of dependency, matching package URL type. Empty string is returned for unknown types
+func purlKind(packageURL string) string {
+	if packageURL == "" {
+		return ""
+	}
+	p, err := purl.FromString(packageURL)
+	if err != nil {
+		return ""
+	}
+	for kind, purlType := range typeMap {
+		if purlType == p.Type {
+			return kind
+		}
+	}
+	return ""
+}
+
+// purlName returns the name of dependency with the namespace from package URL, like Maven group ID, which SPDX
+// package name may omit. The name is returned as is, if package URL has no namespace, or it is not a part of the name
+func purlName(packageURL, name string) string {
+	p, err := purl.FromString(packageURL)
+	if err != nil || p.Namespace == "" {
+		return name
+	}
+	switch p.Type {
+	case purl.TypeMaven:
+		return p.Namespace + ":" + p.Name
+	case purl.TypeNPM, purl.TypeGolang, purl.TypeComposer:
+		return p.Namespace + "/" + p.Name
+	}
+	return name
+}
+
+// purlProperties returns dependency properties of OS package from package URL qualifiers, nil if there are none
+func purlProperties(packageURL string) map[string]string {
+	p, err := purl.FromString(packageURL)
+	if err != nil {
+		return nil
+	}
+	var res map[string]string
+	for _, q := range p.Qualifiers {
+		if prop, ok := qualifierProperties[q.Key]; ok && q.Value != "" {
+			if res == nil {
+				res = make(map[string]string)
+			}
+			res[prop] = q.Value
+		}
+	}
+	return res
+}
+
+func isLicense(license string) bool {
+	return license != "" && license != noAssertionStr && license != "NONE"
+}
+
+func isJSON(buf []byte) bool {
+	buf = bytes.TrimSpace(buf)
+	return len(buf) > 0 && buf[0] == '{'
+}
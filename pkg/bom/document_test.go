@@ -0,0 +1,176 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package bom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/docker"
+	"github.com/codenotary/cas/pkg/bom/java"
+	"github.com/codenotary/cas/pkg/bom/javascript"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	deps := []artifact.Dependency{{
+		Name:     "express",
+		Version:  "4.18.2",
+		Hash:     "aa",
+		HashType: artifact.HashSHA512,
+		Kind:     javascript.AssetType,
+		License:  "MIT",
+		Supplier: "OpenJS Foundation <info@openjsf.org>",
+		CPEs:     []string{"cpe:2.3:a:expressjs:express:4.18.2:*:*:*:*:node.js:*:*"},
+		Requires: []artifact.DepRef{{Name: "debug", Version: "2.6.9"}},
+	}, {
+		Name:     "debug",
+		Version:  "2.6.9",
+		Hash:     "bb",
+		HashType: artifact.HashSHA1,
+		Kind:     javascript.AssetType,
+		Type:     artifact.DepTransient,
+	}}
+	a := testArtifact{kind: javascript.AssetType, GenericArtifact: artifact.GenericArtifact{Deps: deps}}
+	dir := t.TempDir()
+
+	outputs := map[string]func(string) error{
+		"spdx://" + filepath.Join(dir, "bom.spdx"): func(filename string) error {
+			return OutputSpdxText(a, "abcd", filename)
+		},
+		"spdx://" + filepath.Join(dir, "bom.spdx.json"): func(filename string) error {
+			return OutputSpdxJSON(a, "abcd", filename)
+		},
+		"cyclonedx://" + filepath.Join(dir, "bom.cdx.json"): func(filename string) error {
+			return OutputCycloneDX(a, "abcd", filename, cdx.BOMFileFormatJSON)
+		},
+		"cyclonedx://" + filepath.Join(dir, "bom.cdx.xml"): func(filename string) error {
+			return OutputCycloneDX(a, "abcd", filename, cdx.BOMFileFormatXML)
+		},
+	}
+	for source, output := range outputs {
+		t.Run(source, func(t *testing.T) {
+			assert.True(t, IsBOMOnlyURI(source))
+			assert.NoError(t, output(filepath.Join(dir, filepath.Base(source))))
+			loaded, err := NewFromURI(source)
+			assert.NoError(t, err)
+			assert.Equal(t, "app", loaded.Path())
+			assert.Equal(t, javascript.AssetType, loaded.Type())
+			assert.Equal(t, deps, loaded.Dependencies())
+		})
+	}
+	assert.False(t, IsBOMOnlyURI("docker://alpine"))
+}
+
+func TestSpdx22JSON(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "bom.json")
+	assert.NoError(t, os.WriteFile(filename, []byte(`{
+  "spdxVersion": "SPDX-2.2",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "service",
+  "documentDescribes": ["SPDXRef-service"],
+  "packages": [
+    {"SPDXID": "SPDXRef-service", "name": "service"},
+    {"SPDXID": "SPDXRef-guava", "name": "guava", "versionInfo": "31.1-jre",
+      "licenseConcluded": "NOASSERTION", "licenseDeclared": "Apache-2.0",
+      "checksums": [{"algorithm": "MD5", "checksumValue": "00"}, {"algorithm": "SHA1", "checksumValue": "AB"}],
+      "externalRefs": [{"referenceCategory": "PACKAGE_MANAGER", "referenceType": "purl",
+        "referenceLocator": "pkg:maven/com.google.guava/guava@31.1-jre"}]},
+    {"SPDXID": "SPDXRef-failureaccess", "name": "com.google.guava:failureaccess", "versionInfo": "1.0.1",
+      "checksums": [{"algorithm": "SHA1", "checksumValue": "cd"}]},
+    {"SPDXID": "SPDXRef-unknown", "name": "unknown"}
+  ],
+  "relationships": [
+    {"spdxElementId": "SPDXRef-guava", "relationshipType": "DEPENDENCY_OF", "relatedSpdxElement": "SPDXRef-service"},
+    {"spdxElementId": "SPDXRef-guava", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-failureaccess"}
+  ]
+}`), 0644))
+
+	a, err := NewFromSPDX(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "service", a.Path())
+	assert.Equal(t, SchemeSPDX, a.Type())
+	assert.Equal(t, []string{"1 components have no supported hash and were skipped"}, a.Warnings)
+	assert.Equal(t, []artifact.Dependency{{
+		Name:     "com.google.guava:guava",
+		Version:  "31.1-jre",
+		Hash:     "ab",
+		HashType: artifact.HashSHA1,
+		Kind:     java.AssetType,
+		License:  "Apache-2.0",
+		Requires: []artifact.DepRef{{Name: "com.google.guava:failureaccess", Version: "1.0.1"}},
+	}, {
+		Name:     "com.google.guava:failureaccess",
+		Version:  "1.0.1",
+		Hash:     "cd",
+		HashType: artifact.HashSHA1,
+		Type:     artifact.DepTransient,
+	}}, a.Dependencies())
+}
+
+func TestCycloneDXNested(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "bom.json")
+	assert.NoError(t, os.WriteFile(filename, []byte(`{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.4",
+  "components": [
+    {"type": "library", "group": "org.apache.logging.log4j", "name": "log4j-core", "version": "2.17.1",
+      "purl": "pkg:maven/org.apache.logging.log4j/log4j-core@2.17.1",
+      "hashes": [{"alg": "SHA-1", "content": "ef"}],
+      "licenses": [{"license": {"id": "Apache-2.0"}}],
+      "components": [
+        {"type": "library", "name": "shaded", "version": "1.0", "hashes": [{"alg": "SHA-256", "content": "01"}]}
+      ]},
+    {"type": "library", "name": "libssl3", "version": "3.0.11-1",
+      "purl": "pkg:deb/debian/libssl3@3.0.11-1?arch=amd64&distro=debian-12&upstream=openssl",
+      "hashes": [{"alg": "SHA-256", "content": "02"}]}
+  ]
+}`), 0644))
+
+	a, err := NewFromCycloneDX(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, filename, a.Path())
+	assert.Equal(t, SchemeCycloneDX, a.Type())
+	assert.Equal(t, []artifact.Dependency{{
+		Name:     "org.apache.logging.log4j:log4j-core",
+		Version:  "2.17.1",
+		Hash:     "ef",
+		HashType: artifact.HashSHA1,
+		Kind:     java.AssetType,
+		License:  "Apache-2.0",
+	}, {
+		Name:     "shaded",
+		Version:  "1.0",
+		Hash:     "01",
+		HashType: artifact.HashSHA256,
+	}, {
+		Name:     "libssl3",
+		Version:  "3.0.11-1",
+		Hash:     "02",
+		HashType: artifact.HashSHA256,
+		Kind:     docker.DPKG,
+		Properties: map[string]string{
+			docker.PropArch: "amd64", docker.PropDistro: "debian-12", docker.PropSource: "openssl",
+		},
+	}}, a.Dependencies())
+}
+
+func TestIsBOMOnlyURI(t *testing.T) {
+	for _, source := range []string{"spdx://app.spdx", "cyclonedx://app.cdx.json", "docker-archive://alpine.tar",
+		"oci://./layout:3.14", "rootfs:///mnt/vm-disk", "host://"} {
+		assert.True(t, IsBOMOnlyURI(source), source)
+	}
+	for _, source := range []string{"docker://alpine", "dir://.", "git://.", "package.json"} {
+		assert.False(t, IsBOMOnlyURI(source), source)
+	}
+}
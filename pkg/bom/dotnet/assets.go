@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package dotnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+// assetsTarget is a package entry in 'targets' section of project.assets.json
+type assetsTarget struct {
+	Type         string            `json:"type"` // package or project
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+// assetsLibrary is a package entry in 'libraries' section of project.assets.json
+type assetsLibrary struct {
+	Type   string `json:"type"`
+	SHA512 string `json:"sha512"`
+}
+
+// assets is project.assets.json content. Targets and libraries are keyed by '<name>/<version>',
+// dependency groups are lists of '<name> >= <version>' requirements, keyed by target framework
+type assets struct {
+	Version                    int                                `json:"version"`
+	Targets                    map[string]map[string]assetsTarget `json:"targets"`
+	Libraries                  map[string]assetsLibrary           `json:"libraries"`
+	ProjectFileDependencyGroup map[string][]string                `json:"projectFileDependencyGroups"`
+}
+
+// assetsDeps adds dependencies, listed in project.assets.json, to the graph
+func assetsDeps(g *depgraph.Graph, filename string) error {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+	var a assets
+	if err := json.Unmarshal(buf, &a); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+
+	for target, entries := range a.Targets {
+		packages := make(map[string]*packageRef, len(entries))
+		for key, e := range entries {
+			fields := strings.SplitN(key, "/", 2)
+			if len(fields) != 2 {
+				return fmt.Errorf("cannot process %s: malformed target entry %s", filename, key)
+			}
+			packages[strings.ToLower(fields[0])] = &packageRef{
+				name:    fields[0],
+				version: fields[1],
+				hash:    a.Libraries[key].SHA512,
+				project: strings.EqualFold(e.Type, "project"),
+				deps:    lowerKeys(e.Dependencies),
+			}
+		}
+
+		// target is '<framework>' or '<framework>/<runtime identifier>', common group has empty key
+		framework := strings.SplitN(target, "/", 2)[0]
+		var direct []string
+		for _, group := range []string{"", framework} {
+			for _, req := range a.ProjectFileDependencyGroup[group] {
+				if fields := strings.Fields(req); len(fields) > 0 {
+					direct = append(direct, strings.ToLower(fields[0]))
+				}
+			}
+		}
+
+		if err := addTarget(g, packages, direct); err != nil {
+			return fmt.Errorf("cannot process %s: %w", filename, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package dotnet
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+const AssetType = "dotnet"
+
+const (
+	packagesLock = "packages.lock.json"
+	assetsFile   = "project.assets.json"
+	objDir       = "obj"
+	slnExt       = ".sln"
+)
+
+// project file extensions for C#, F# and Visual Basic
+var projectExts = map[string]struct{}{".csproj": {}, ".fsproj": {}, ".vbproj": {}}
+
+// 'Project("{type GUID}") = "name", "relative\path\to\project.csproj", "{project GUID}"' line in solution file
+var slnProjectPattern = regexp.MustCompile(`^Project\("[^"]*"\)\s*=\s*"[^"]*"\s*,\s*"([^"]+)"`)
+
+// dotnetArtifact implements Artifact interface for .NET solutions and projects
+type dotnetArtifact struct {
+	artifact.GenericArtifact
+	path     string
+	solution string   // solution file, empty if artifact is a single project
+	projects []string // directories of the projects
+}
+
+// New returns new Artifact object, or nil if filename doesn't refer to solution or project file, or to
+// directory containing one of those
+func New(filename string) artifact.Artifact {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil
+	}
+
+	if !fi.IsDir() {
+		ext := strings.ToLower(filepath.Ext(filename))
+		if ext == slnExt {
+			return &dotnetArtifact{path: filename, solution: filename}
+		}
+		if _, ok := projectExts[ext]; ok {
+			return &dotnetArtifact{path: filename, projects: []string{filepath.Dir(filename)}}
+		}
+		return nil
+	}
+
+	entries, err := os.ReadDir(filename)
+	if err != nil {
+		return nil
+	}
+	var project string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if ext == slnExt {
+			// solution takes precedence over project
+			return &dotnetArtifact{path: filename, solution: filepath.Join(filename, e.Name())}
+		}
+		if _, ok := projectExts[ext]; ok {
+			project = filename
+		}
+	}
+	if project != "" {
+		return &dotnetArtifact{path: filename, projects: []string{project}}
+	}
+
+	return nil
+}
+
+func (a dotnetArtifact) Type() string {
+	return AssetType
+}
+
+func (a dotnetArtifact) Path() string {
+	return a.path
+}
+
+func (a *dotnetArtifact) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	if a.Deps != nil {
+		return a.Deps, nil
+	}
+
+	projects := a.projects
+	if a.solution != "" {
+		var err error
+		projects, err = solutionProjects(a.solution)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	g := depgraph.NewGraph(a.path, "")
+	for _, dir := range projects {
+		err := resolveProject(&g, dir)
+		if errors.Is(err, errNotRestored) && a.solution != "" {
+			// projects without package references may be not restored
+			artifact.Warnf(output, "Project in %s has no %s or %s/%s, skipping\n", dir, packagesLock, objDir, assetsFile)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	a.Deps = g.FlatDeps()
+	return a.Deps, nil
+}
+
+var errNotRestored = fmt.Errorf("no %s or %s/%s found, run 'dotnet restore' first", packagesLock, objDir, assetsFile)
+
+// resolveProject adds project dependencies to the graph, using packages.lock.json, if present, or
+// obj/project.assets.json, created by 'dotnet restore'
+func resolveProject(g *depgraph.Graph, dir string) error {
+	lockFile := filepath.Join(dir, packagesLock)
+	if _, err := os.Stat(lockFile); err == nil {
+		return lockDeps(g, lockFile)
+	}
+	assets := filepath.Join(dir, objDir, assetsFile)
+	if _, err := os.Stat(assets); err == nil {
+		return assetsDeps(g, assets)
+	}
+	return fmt.Errorf("project in %s: %w", dir, errNotRestored)
+}
+
+// solutionProjects returns directories of C#, F# and Visual Basic projects, listed in solution file
+func solutionProjects(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	var res []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		match := slnProjectPattern.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		// solution files use Windows path separators
+		project := filepath.FromSlash(strings.ReplaceAll(match[1], `\`, "/"))
+		if _, ok := projectExts[strings.ToLower(filepath.Ext(project))]; !ok {
+			continue // solution folder or unsupported project type
+		}
+		res = append(res, filepath.Join(filepath.Dir(filename), filepath.Dir(project)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+	return res, nil
+}
+
+// packageRef is a package, resolved for target framework, common for lock and assets files
+type packageRef struct {
+	name    string
+	version string
+	hash    string   // base64-encoded SHA-512 of the package content, empty for projects
+	project bool     // referenced project
+	deps    []string // lowercase names of required packages
+}
+
+// addTarget adds resolved packages of a single target framework to the graph. Packages are identified by
+// lowercase names, as NuGet package IDs are case-insensitive. Packages, required by referenced projects,
+// are considered direct ones, as projects aren't included into dependency list
+func addTarget(g *depgraph.Graph, packages map[string]*packageRef, direct []string) error {
+	for _, p := range packages {
+		if p.project {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(p.hash)
+		if err != nil || len(value) == 0 {
+			return fmt.Errorf("package %s %s has invalid content hash", p.name, p.version)
+		}
+		g.NewNode(p.name, p.version, &artifact.Dependency{
+			Name:     p.name,
+			Version:  p.version,
+			Hash:     hex.EncodeToString(value),
+			HashType: artifact.HashSHA512,
+			Kind:     AssetType,
+		})
+	}
+
+	for _, p := range packages {
+		parent := g.Root
+		if !p.project {
+			parent = g.Node(p.name, p.version)
+		}
+		for _, id := range p.deps {
+			if child, ok := packages[id]; ok && !child.project {
+				g.AddChild(parent, child.name, child.version, nil)
+			}
+		}
+	}
+	for _, id := range direct {
+		if p, ok := packages[id]; ok && !p.project {
+			g.AddChild(g.Root, p.name, p.version, nil)
+		}
+	}
+
+	return nil
+}
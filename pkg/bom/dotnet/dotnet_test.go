@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package dotnet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+const testSolution = `
+Microsoft Visual Studio Solution File, Format Version 12.00
+Project("{2150E333-8FDC-42A3-9474-1A3956D46DE8}") = "src", "src", "{0F3A1C5E-1B59-4F1B-9C51-3A7D4A3D5A11}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "App", "src\App\App.csproj", "{7A0B4E37-9B4C-4C8E-8D3F-1E2F8B0C6A21}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Lib", "src\Lib\Lib.csproj", "{C2B5E8F1-3D4A-4B6C-8E9F-0A1B2C3D4E51}"
+EndProject
+`
+
+const testLock = `{
+  "version": 1,
+  "dependencies": {
+    "net6.0": {
+      "Newtonsoft.Json": {
+        "type": "Direct",
+        "requested": "[13.0.1, )",
+        "resolved": "13.0.1",
+        "contentHash": "AQID"
+      },
+      "Serilog.Sinks.Console": {
+        "type": "Transitive",
+        "resolved": "4.0.0",
+        "contentHash": "BAUG",
+        "dependencies": {
+          "serilog": "2.10.0"
+        }
+      },
+      "Serilog": {
+        "type": "Transitive",
+        "resolved": "2.10.0",
+        "contentHash": "BwgJ"
+      },
+      "lib": {
+        "type": "Project",
+        "dependencies": {
+          "Serilog.Sinks.Console": "[4.0.0, )"
+        }
+      }
+    }
+  }
+}`
+
+const testAssets = `{
+  "version": 3,
+  "targets": {
+    "net6.0": {
+      "Serilog/2.10.0": {
+        "type": "package"
+      },
+      "Serilog.Sinks.Console/4.0.0": {
+        "type": "package",
+        "dependencies": {
+          "Serilog": "2.10.0"
+        }
+      }
+    }
+  },
+  "libraries": {
+    "Serilog/2.10.0": {
+      "sha512": "BwgJ",
+      "type": "package"
+    },
+    "Serilog.Sinks.Console/4.0.0": {
+      "sha512": "BAUG",
+      "type": "package"
+    }
+  },
+  "projectFileDependencyGroups": {
+    "net6.0": [
+      "Serilog.Sinks.Console >= 4.0.0"
+    ]
+  }
+}`
+
+func writeFile(t *testing.T, filename, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+}
+
+func TestSolution(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "App.sln"), testSolution)
+	writeFile(t, filepath.Join(dir, "src", "App", "App.csproj"), "<Project/>")
+	writeFile(t, filepath.Join(dir, "src", "App", packagesLock), testLock)
+	writeFile(t, filepath.Join(dir, "src", "Lib", "Lib.csproj"), "<Project/>")
+	writeFile(t, filepath.Join(dir, "src", "Lib", objDir, assetsFile), testAssets)
+
+	a := New(dir)
+	assert.NotNil(t, a)
+	assert.Equal(t, AssetType, a.Type())
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+
+	expected := map[string]artifact.Dependency{
+		"Newtonsoft.Json":       {Version: "13.0.1", Hash: "010203", Type: artifact.DepDirect},
+		"Serilog.Sinks.Console": {Version: "4.0.0", Hash: "040506", Type: artifact.DepDirect},
+		"Serilog":               {Version: "2.10.0", Hash: "070809", Type: artifact.DepTransient},
+	}
+	assert.Len(t, deps, len(expected))
+	for _, d := range deps {
+		e, ok := expected[d.Name]
+		if !assert.True(t, ok, d.Name) {
+			continue
+		}
+		assert.Equal(t, e.Version, d.Version)
+		assert.Equal(t, e.Hash, d.Hash)
+		assert.Equal(t, e.Type, d.Type, d.Name)
+		assert.Equal(t, artifact.HashSHA512, d.HashType)
+		assert.Equal(t, AssetType, d.Kind)
+	}
+}
+
+func TestNotRestored(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "App.csproj"), "<Project/>")
+
+	a := New(filepath.Join(dir, "App.csproj"))
+	assert.NotNil(t, a)
+	_, err := a.ResolveDependencies(artifact.Silent)
+	assert.ErrorIs(t, err, errNotRestored)
+
+	assert.Nil(t, New(filepath.Join(dir, "missing.csproj")))
+}
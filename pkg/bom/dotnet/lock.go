@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package dotnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+// lockEntry is a package entry in packages.lock.json
+type lockEntry struct {
+	Type         string            `json:"type"` // Direct, Transitive, CentralTransitive or Project
+	Resolved     string            `json:"resolved"`
+	ContentHash  string            `json:"contentHash"`
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+// lockFile is packages.lock.json content, entries are grouped by target framework (and runtime identifier)
+type lockFile struct {
+	Version      int                             `json:"version"`
+	Dependencies map[string]map[string]lockEntry `json:"dependencies"`
+}
+
+// lockDeps adds dependencies, listed in packages.lock.json, to the graph
+func lockDeps(g *depgraph.Graph, filename string) error {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+	var lock lockFile
+	if err := json.Unmarshal(buf, &lock); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+
+	for _, entries := range lock.Dependencies {
+		packages := make(map[string]*packageRef, len(entries))
+		var direct []string
+		for name, e := range entries {
+			id := strings.ToLower(name)
+			packages[id] = &packageRef{
+				name:    name,
+				version: e.Resolved,
+				hash:    e.ContentHash,
+				project: strings.EqualFold(e.Type, "Project"),
+				deps:    lowerKeys(e.Dependencies),
+			}
+			if strings.EqualFold(e.Type, "Direct") {
+				direct = append(direct, id)
+			}
+		}
+		if err := addTarget(g, packages, direct); err != nil {
+			return fmt.Errorf("cannot process %s: %w", filename, err)
+		}
+	}
+
+	return nil
+}
+
+// lowerKeys returns lowercase map keys in stable order
+func lowerKeys(m map[string]string) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, strings.ToLower(k))
+	}
+	sort.Strings(res)
+	return res
+}
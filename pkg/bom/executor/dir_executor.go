@@ -0,0 +1,226 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package executor
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirExecutor serves files of root file system, located in the local directory, like unpacked or mounted
+// VM image, or the host root. Symbolic links are resolved relatively to the root directory. Commands
+// cannot be executed
+type DirExecutor struct {
+	root string
+}
+
+// NewDirExecutor returns an executor for root file system in the directory
+func NewDirExecutor(root string) (Executor, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	return &DirExecutor{root: root}, nil
+}
+
+// resolve follows symbolic links in the path, treating absolute link targets as relative to the root
+// directory, and returns the location of the file in the host file system
+func (e *DirExecutor) resolve(name string) (string, error) {
+	rest := strings.Split(strings.Trim(path.Clean("/"+name), "/"), "/")
+	cur := "/"
+	links := 0
+	for len(rest) > 0 {
+		if rest[0] == "" {
+			rest = rest[1:]
+			continue
+		}
+		next := path.Join(cur, rest[0])
+		fi, err := os.Lstat(e.hostPath(next))
+		if err != nil {
+			return "", &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			links++
+			if links > maxSymlinks {
+				return "", fmt.Errorf("%s: too many levels of symbolic links", name)
+			}
+			target, err := os.Readlink(e.hostPath(next))
+			if err != nil {
+				return "", err
+			}
+			target = filepath.ToSlash(target)
+			if !path.IsAbs(target) {
+				target = path.Join(cur, target)
+			}
+			// resolve the target from the root again, '..' cannot leave the root
+			rest = append(strings.Split(strings.Trim(path.Clean(target), "/"), "/"), rest[1:]...)
+			cur = "/"
+			continue
+		}
+		cur = next
+		rest = rest[1:]
+	}
+	return e.hostPath(cur), nil
+}
+
+func (e *DirExecutor) hostPath(name string) string {
+	return filepath.Join(e.root, filepath.FromSlash(name))
+}
+
+// Exec is not supported, root file system can only be read
+func (e *DirExecutor) Exec(cmd []string) ([]byte, []byte, int, error) {
+	return nil, nil, 0, ErrNotSupported
+}
+
+// ReadFile returns content of the file from root file system
+func (e *DirExecutor) ReadFile(name string) ([]byte, error) {
+	filename, err := e.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// Exists checks if the file or the directory exists in root file system
+func (e *DirExecutor) Exists(name string) bool {
+	filename, err := e.resolve(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filename)
+	return err == nil
+}
+
+// ReadDir returns the content of the directory as TAR stream. Entries are prefixed with directory base name,
+// the same way as Docker daemon does it. Symbolic links inside the directory aren't followed
+func (e *DirExecutor) ReadDir(name string) (io.ReadCloser, error) {
+	dir, err := e.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", name)
+	}
+
+	// stream the archive, as directory may be large
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeDirTar(writer, dir))
+	}()
+	return reader, nil
+}
+
+// writeDirTar writes directory content as TAR stream. Files, that cannot be read (f.e. because of permissions),
+// are skipped
+func writeDirTar(w io.Writer, dir string) error {
+	base := filepath.Base(dir)
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(filename string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if filename == dir {
+				return err
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filename)
+		if err != nil {
+			return err
+		}
+		link := ""
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(filename); err != nil {
+				return nil
+			}
+		}
+		if !fi.IsDir() && !fi.Mode().IsRegular() && link == "" {
+			return nil // devices, sockets etc.
+		}
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return nil
+		}
+		hdr.Name = path.Join(base, filepath.ToSlash(rel))
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		if !fi.Mode().IsRegular() {
+			return tw.WriteHeader(hdr)
+		}
+
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil // unreadable file
+		}
+		defer f.Close()
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		// file may change while being read, exactly header size is written
+		n, err := io.Copy(tw, io.LimitReader(f, hdr.Size))
+		if err == nil && n < hdr.Size {
+			_, err = tw.Write(make([]byte, hdr.Size-n))
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Glob expands shell patterns relatively to the root directory. Pattern is matched element by element, so
+// symbolic links are resolved within the root
+func (e *DirExecutor) Glob(patterns []string) ([]string, error) {
+	return globElements(patterns, e.matchChildren)
+}
+
+// matchChildren returns sorted paths of directory entries, matching the pattern element
+func (e *DirExecutor) matchChildren(dir, elem string) []string {
+	real, err := e.resolve(dir)
+	if err != nil {
+		return nil
+	}
+	f, err := os.Open(real)
+	if err != nil {
+		return nil
+	}
+	names, _ := f.Readdirnames(-1)
+	f.Close()
+	var res []string
+	for _, n := range names {
+		if ok, _ := path.Match(elem, n); ok {
+			res = append(res, path.Join(dir, n))
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Close does nothing, as executor doesn't hold any resources
+func (e *DirExecutor) Close() error {
+	return nil
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package executor
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirExecutor(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret")
+	assert.NoError(t, ioutil.WriteFile(outside, []byte("secret"), 0644))
+
+	files := map[string]string{
+		"etc/os-release":                       "ID=debian\n",
+		"var/lib/dpkg/status":                  "Package: base-files\n",
+		"var/lib/dpkg/info/base-files.list":    "/etc/debian_version\n",
+		"var/lib/dpkg/info/base-files.md5sums": "d41d8cd98f00b204e9800998ecf8427e  etc/debian_version\n",
+		"usr/lib/python3.9/site-packages/x":    "",
+	}
+	for name, content := range files {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+	}
+	// absolute links are resolved within the root
+	assert.NoError(t, os.Symlink("/usr/lib", filepath.Join(root, "lib")))
+	assert.NoError(t, os.Symlink("/etc/os-release", filepath.Join(root, "os-release")))
+	assert.NoError(t, os.Symlink("../../.."+outside, filepath.Join(root, "etc", "escape")))
+
+	_, err := NewDirExecutor(filepath.Join(root, "etc", "os-release"))
+	assert.Error(t, err)
+	e, err := NewDirExecutor(root)
+	assert.NoError(t, err)
+
+	buf, err := e.ReadFile("/os-release")
+	assert.NoError(t, err)
+	assert.Equal(t, "ID=debian\n", string(buf))
+
+	_, err = e.ReadFile("/etc/escape")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	_, err = e.ReadFile("/etc/missing")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	assert.True(t, Exists(e, "/lib/python3.9/site-packages"))
+	assert.True(t, Exists(e, "/os-release"))
+	assert.False(t, Exists(e, "/etc/escape"))
+	assert.False(t, Exists(e, "/etc/missing"))
+
+	reader, err := e.ReadDir("/var/lib/dpkg/info")
+	assert.NoError(t, err)
+	content := make(map[string]string)
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+		buf, _ := ioutil.ReadAll(tr)
+		content[hdr.Name] = string(buf)
+	}
+	assert.NoError(t, reader.Close())
+	assert.Equal(t, map[string]string{
+		"info/":                   "",
+		"info/base-files.list":    files["var/lib/dpkg/info/base-files.list"],
+		"info/base-files.md5sums": files["var/lib/dpkg/info/base-files.md5sums"],
+	}, content)
+
+	_, err = e.ReadDir("/etc/os-release")
+	assert.Error(t, err)
+
+	dirs, err := Glob(e, []string{"/lib/python3*/site-packages", "/usr/local/lib/python*"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/lib/python3.9/site-packages"}, dirs)
+
+	_, _, _, err = e.Exec([]string{"ls"})
+	assert.True(t, errors.Is(err, ErrNotSupported))
+	assert.NoError(t, e.Close())
+}
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -24,25 +25,34 @@ import (
 	"github.com/docker/docker/pkg/stdcopy"
 )
 
+// shell is used as container entrypoint, to keep the container running while commands are executed
+const shell = "/bin/sh"
+
 // timeout for kill ing the container if it doesn't properly shuts down
 var timeout = 5 * time.Second
 
 type DockerExecutor struct {
-	ctx    context.Context
-	client *docker.Client
-	contID string
+	ctx     context.Context
+	client  *docker.Client
+	contID  string
+	started bool // container without shell cannot be started, files are copied from created container
 }
 
-// NewDockerExecutor starts a new container and returns an executor for the container
+// NewDockerExecutor starts a new container and returns an executor for the container. If the image has no
+// shell (distroless images, images 'from scratch'), the container is only created - commands cannot be
+// executed, but files still can be read
 func NewDockerExecutor(image string) (Executor, error) {
 	ctx := context.Background()
 
-	dockerClient, _ := docker.NewClientWithOpts(docker.FromEnv, docker.WithAPIVersionNegotiation())
+	dockerClient, err := docker.NewClientWithOpts(docker.FromEnv, docker.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to docker: %w", err)
+	}
 	cont, err := dockerClient.ContainerCreate(
 		ctx,
 		&container.Config{
 			Image:       image,
-			Entrypoint:  []string{"/bin/sh"},
+			Entrypoint:  []string{shell},
 			AttachStdin: true,
 			Tty:         true,
 			OpenStdin:   true,
@@ -52,23 +62,40 @@ func NewDockerExecutor(image string) (Executor, error) {
 		return nil, fmt.Errorf("cannot create container: %w", err)
 	}
 
+	e := DockerExecutor{
+		ctx:    ctx,
+		client: dockerClient,
+		contID: cont.ID,
+	}
 	err = dockerClient.ContainerStart(ctx, cont.ID, types.ContainerStartOptions{})
 	if err != nil {
-		if errdefs.IsInvalidParameter(err) {
-			return nil, fmt.Errorf("cannot use container without shell. Images 'from scratch' are not supported")
+		if noShell(err) {
+			return e, nil
 		}
+		e.remove()
 		return nil, err
 	}
+	e.started = true
 
-	return DockerExecutor{
-		ctx:    ctx,
-		client: dockerClient,
-		contID: cont.ID,
-	}, nil
+	return e, nil
+}
+
+// noShell checks if the container cannot be started because the image has no shell. Depending on the version,
+// Docker daemon reports missing entrypoint as invalid parameter or as system error
+func noShell(err error) bool {
+	if !errdefs.IsInvalidParameter(err) && !errdefs.IsSystem(err) {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, shell) &&
+		(strings.Contains(msg, "no such file or directory") || strings.Contains(msg, "executable file not found"))
 }
 
 // Exec executes a command inside the container
 func (e DockerExecutor) Exec(cmd []string) ([]byte, []byte, int, error) {
+	if !e.started {
+		return nil, nil, 0, ErrNotSupported
+	}
 	var stdOut, stdErr bytes.Buffer
 	exec, err := e.client.ContainerExecCreate(e.ctx, e.contID, types.ExecConfig{
 		Cmd:          cmd,
@@ -111,9 +138,20 @@ func (e DockerExecutor) Exec(cmd []string) ([]byte, []byte, int, error) {
 	return stdOut.Bytes(), stdErr.Bytes(), res.ExitCode, nil
 }
 
-// Close stops previously started container by sending "exit" to shell - it is much faster than
-// stopping with container API
+// Close stops previously started container and removes it
 func (e DockerExecutor) Close() error {
+	var err error
+	if e.started {
+		err = e.stop()
+	}
+	if rmErr := e.remove(); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// stop stops the container by sending "exit" to shell - it is much faster than stopping with container API
+func (e DockerExecutor) stop() error {
 	hijacked, err := e.client.ContainerAttach(e.ctx, e.contID, types.ContainerAttachOptions{
 		Stdin:  true,
 		Stream: true,
@@ -139,6 +177,11 @@ func (e DockerExecutor) Close() error {
 	}
 }
 
+// remove removes the container, forcing it to stop if it's still running
+func (e DockerExecutor) remove() error {
+	return e.client.ContainerRemove(e.ctx, e.contID, types.ContainerRemoveOptions{Force: true})
+}
+
 // Read reads the file from container and returns its content
 func (e DockerExecutor) ReadFile(path string) ([]byte, error) {
 	reader, _, err := e.client.CopyFromContainer(e.ctx, e.contID, path)
@@ -156,6 +199,12 @@ func (e DockerExecutor) ReadFile(path string) ([]byte, error) {
 	return ioutil.ReadAll(tr)
 }
 
+// Exists checks if the file or the directory exists in the container, without copying it
+func (e DockerExecutor) Exists(path string) bool {
+	_, err := e.client.ContainerStatPath(e.ctx, e.contID, path)
+	return err == nil
+}
+
 // ReadDir reads the files from container directory and returns the content as TAR stream
 func (e DockerExecutor) ReadDir(path string) (io.ReadCloser, error) {
 	reader, _, err := e.client.CopyFromContainer(e.ctx, e.contID, path)
@@ -0,0 +1,31 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package executor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoShell(t *testing.T) {
+	// error messages of different Docker daemon versions
+	assert.True(t, noShell(errdefs.System(errors.New(
+		`OCI runtime create failed: container_linux.go:380: starting container process caused: exec: "/bin/sh": stat /bin/sh: no such file or directory: unknown`))))
+	assert.True(t, noShell(errdefs.InvalidParameter(errors.New(
+		`failed to create task for container: exec: "/bin/sh": executable file not found in $PATH: unknown`))))
+
+	assert.False(t, noShell(errdefs.System(errors.New(
+		`error mounting "/var/lib/docker/volumes/data": no space left on device`))))
+	assert.False(t, noShell(errdefs.System(errors.New(
+		`driver failed programming external connectivity on endpoint: no such file or directory`))))
+	assert.False(t, noShell(errors.New(`exec: "/bin/sh": stat /bin/sh: no such file or directory`)))
+}
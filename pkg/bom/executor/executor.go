@@ -10,6 +10,8 @@ package executor
 
 import (
 	"io"
+	"path"
+	"strings"
 )
 
 type Executor interface {
@@ -18,3 +20,76 @@ type Executor interface {
 	ReadDir(path string) (io.ReadCloser, error)
 	Close() error
 }
+
+// Identifier is implemented by executors for images, which know the image ID without Docker daemon
+type Identifier interface {
+	ImageID() string
+}
+
+// Globber is implemented by executors, which can expand shell patterns without running the shell
+type Globber interface {
+	Glob(patterns []string) ([]string, error)
+}
+
+// Checker is implemented by executors, which can check the file presence without reading the file
+type Checker interface {
+	Exists(path string) bool
+}
+
+// Exists checks if the file or the directory exists. Executors, which don't implement Checker, read the file
+// or the directory
+func Exists(e Executor, path string) bool {
+	if c, ok := e.(Checker); ok {
+		return c.Exists(path)
+	}
+	if _, err := e.ReadFile(path); err == nil {
+		return true
+	}
+	reader, err := e.ReadDir(path)
+	if err != nil {
+		return false
+	}
+	reader.Close()
+	return true
+}
+
+// Glob expands shell patterns and returns existing paths. Returns error if patterns cannot be expanded,
+// f.e. if environment has no shell
+func Glob(e Executor, patterns []string) ([]string, error) {
+	if g, ok := e.(Globber); ok {
+		return g.Glob(patterns)
+	}
+	stdout, _, _, err := e.Exec([]string{"sh", "-c", "ls -d " + strings.Join(patterns, " ") + " 2>/dev/null"})
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(stdout)), nil
+}
+
+// globElements expands patterns element by element, using children function for matching entries of
+// the directory. It is used by executors, which cannot run the shell
+func globElements(patterns []string, children func(dir, elem string) []string) ([]string, error) {
+	var res []string
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, err
+		}
+		matches := []string{"/"}
+		for _, elem := range strings.Split(strings.Trim(path.Clean("/"+p), "/"), "/") {
+			if elem == "" {
+				continue
+			}
+			var next []string
+			for _, m := range matches {
+				next = append(next, children(m, elem)...)
+			}
+			matches = next
+		}
+		for _, m := range matches {
+			if m != "/" {
+				res = append(res, m)
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,364 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package executor
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	archiveManifest = "manifest.json"
+	ociIndex        = "index.json"
+	ociLayoutFile   = "oci-layout"
+
+	mediaTypeOCIIndex    = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerList  = "application/vnd.docker.distribution.manifest.list.v2+json"
+	annotationRefName    = "org.opencontainers.image.ref.name"
+	annotationImageName  = "io.containerd.image.name"
+	maxArchiveLinkFollow = 10
+)
+
+// archiveImage is an entry of 'docker save' archive manifest.json
+type archiveImage struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+// ociDescriptor is OCI content descriptor, only fields used for image lookup are included
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	} `json:"platform"`
+}
+
+// ociManifest is OCI image index or image manifest
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Manifests []ociDescriptor `json:"manifests"`
+	Config    ociDescriptor   `json:"config"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+// splitImageRef splits '<path>[:<tag>]' reference into file system path and optional image tag. Path is
+// the longest prefix, existing in the file system, so it may contain colons
+func splitImageRef(ref string) (string, string, error) {
+	if _, err := os.Stat(ref); err == nil {
+		return ref, "", nil
+	}
+	for i := len(ref) - 1; i > 0; i-- {
+		if ref[i] != ':' {
+			continue
+		}
+		if _, err := os.Stat(ref[:i]); err == nil {
+			return ref[:i], ref[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("cannot find image %s: %w", ref, os.ErrNotExist)
+}
+
+// tagMatches checks if image name, like 'docker.io/library/alpine:3.14', matches the tag specified by the user,
+// like 'alpine:3.14' or '3.14'
+func tagMatches(name, tag string) bool {
+	return name == tag || strings.HasSuffix(name, "/"+tag) || strings.HasSuffix(name, ":"+tag)
+}
+
+// NewArchiveExecutor returns an executor for the image from 'docker save' archive. Image in archive with several
+// images is selected by tag: '<archive>:<tag>'
+func NewArchiveExecutor(ref string) (Executor, error) {
+	filename, tag, err := splitImageRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := openArchive(filename)
+	if err != nil {
+		return nil, err
+	}
+	e, err := archiveExecutor(archive, filename, tag)
+	if err != nil {
+		archive.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// archiveExecutor returns an executor for the image in opened archive, which layers are read from the archive
+// until executor is closed
+func archiveExecutor(archive seekableArchive, filename, tag string) (Executor, error) {
+	reader, err := archiveEntry(archive, archiveManifest)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a docker image archive: %w", filename, err)
+	}
+	var images []archiveImage
+	if err := json.NewDecoder(reader).Decode(&images); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", archiveManifest, err)
+	}
+
+	var image *archiveImage
+	for i := range images {
+		if tag == "" && len(images) == 1 {
+			image = &images[i]
+			break
+		}
+		for _, t := range images[i].RepoTags {
+			if tag != "" && tagMatches(t, tag) {
+				image = &images[i]
+			}
+		}
+	}
+	if image == nil {
+		if tag == "" {
+			return nil, fmt.Errorf("archive %s contains %d images, specify image tag as '<archive>:<tag>'", filename, len(images))
+		}
+		return nil, fmt.Errorf("image %s not found in archive %s", tag, filename)
+	}
+
+	// image ID is the digest of the configuration, which file name may differ between Docker versions
+	config, err := archiveEntry(archive, image.Config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read image configuration %s: %w", image.Config, err)
+	}
+	digest := sha256.New()
+	if _, err := io.Copy(digest, config); err != nil {
+		return nil, fmt.Errorf("cannot read image configuration %s: %w", image.Config, err)
+	}
+
+	layers := make([]func() (io.ReadCloser, error), len(image.Layers))
+	for i, name := range image.Layers {
+		r, err := archiveEntry(archive, name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read layer %s: %w", name, err)
+		}
+		layers[i] = func() (io.ReadCloser, error) {
+			// each reader has its own position
+			return sectionCloser{io.NewSectionReader(r, 0, r.Size())}, nil
+		}
+	}
+	return newImageExecutor(hex.EncodeToString(digest.Sum(nil)), layers, archive)
+}
+
+// seekableArchive is a TAR archive, that can be read several times
+type seekableArchive interface {
+	io.ReadSeeker
+	io.ReaderAt
+	io.Closer
+}
+
+// sectionCloser is an archive entry, that doesn't need to be closed
+type sectionCloser struct {
+	*io.SectionReader
+}
+
+func (s sectionCloser) Close() error {
+	return nil
+}
+
+type bytesArchive struct {
+	*bytes.Reader
+}
+
+func (b bytesArchive) Close() error {
+	return nil
+}
+
+// openArchive opens TAR archive. Compressed archive is decompressed into memory, as it cannot be read
+// several times efficiently
+func openArchive(filename string) (seekableArchive, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	magic := make([]byte, len(gzipMagic))
+	if _, err := io.ReadFull(f, magic); err != nil || !bytes.Equal(magic, gzipMagic) {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return f, nil
+	}
+
+	defer f.Close()
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decompress %s: %w", filename, err)
+	}
+	buf, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decompress %s: %w", filename, err)
+	}
+	return bytesArchive{bytes.NewReader(buf)}, nil
+}
+
+// archiveEntry finds the file in TAR archive and returns the reader for its content. Symbolic links, used
+// by 'docker save' for deduplication of layers, are followed
+func archiveEntry(archive seekableArchive, name string) (*io.SectionReader, error) {
+	name = path.Clean(name)
+	for i := 0; i < maxArchiveLinkFollow; i++ {
+		if _, err := archive.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		tr := tar.NewReader(archive)
+		var hdr *tar.Header
+		var err error
+		for {
+			hdr, err = tr.Next()
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if path.Clean(hdr.Name) == name {
+				break
+			}
+		}
+		if hdr.Typeflag != tar.TypeSymlink {
+			// TAR reader stops at the beginning of file content
+			offset, err := archive.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+			return io.NewSectionReader(archive, offset, hdr.Size), nil
+		}
+		if path.IsAbs(hdr.Linkname) {
+			name = path.Clean(strings.TrimPrefix(hdr.Linkname, "/"))
+		} else {
+			name = path.Join(path.Dir(name), hdr.Linkname)
+		}
+	}
+	return nil, fmt.Errorf("%s: too many levels of symbolic links", name)
+}
+
+// NewOCIExecutor returns an executor for the image from OCI image layout directory. Image in layout with several
+// images is selected by tag: '<directory>:<tag>'. For multi-platform images the one for the current
+// architecture is used
+func NewOCIExecutor(ref string) (Executor, error) {
+	dir, tag, err := splitImageRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, ociLayoutFile)); err != nil {
+		return nil, fmt.Errorf("%s is not an OCI image layout: %w", dir, err)
+	}
+	var index ociManifest
+	if err := readBlobJSON(filepath.Join(dir, ociIndex), &index); err != nil {
+		return nil, err
+	}
+
+	var desc *ociDescriptor
+	for i := range index.Manifests {
+		d := &index.Manifests[i]
+		if tag == "" && len(index.Manifests) == 1 {
+			desc = d
+			break
+		}
+		if tag != "" && (tagMatches(d.Annotations[annotationRefName], tag) ||
+			tagMatches(d.Annotations[annotationImageName], tag)) {
+			desc = d
+			break
+		}
+	}
+	if desc == nil {
+		if tag == "" {
+			return nil, fmt.Errorf("layout %s contains %d images, specify image tag as '<directory>:<tag>'", dir, len(index.Manifests))
+		}
+		return nil, fmt.Errorf("image %s not found in layout %s", tag, dir)
+	}
+
+	var manifest ociManifest
+	for i := 0; ; i++ {
+		if err := readBlobJSON(blobPath(dir, desc.Digest), &manifest); err != nil {
+			return nil, err
+		}
+		isIndex := desc.MediaType == mediaTypeOCIIndex || desc.MediaType == mediaTypeDockerList ||
+			(desc.MediaType == "" && len(manifest.Manifests) > 0)
+		if !isIndex {
+			break
+		}
+		if i > 0 || len(manifest.Manifests) == 0 {
+			return nil, fmt.Errorf("cannot find image manifest for %s", ref)
+		}
+		desc = platformManifest(manifest.Manifests)
+		manifest = ociManifest{}
+	}
+
+	layers := make([]func() (io.ReadCloser, error), len(manifest.Layers))
+	for i, l := range manifest.Layers {
+		filename := blobPath(dir, l.Digest)
+		layers[i] = func() (io.ReadCloser, error) {
+			return os.Open(filename)
+		}
+	}
+	return newImageExecutor(strings.TrimPrefix(manifest.Config.Digest, "sha256:"), layers, nil)
+}
+
+// platformManifest selects the manifest for the current architecture from multi-platform image index,
+// falling back to the first Linux one
+func platformManifest(manifests []ociDescriptor) *ociDescriptor {
+	var linux *ociDescriptor
+	for i := range manifests {
+		p := manifests[i].Platform
+		if p == nil || p.OS != "linux" {
+			continue
+		}
+		if p.Architecture == runtime.GOARCH {
+			return &manifests[i]
+		}
+		if linux == nil {
+			linux = &manifests[i]
+		}
+	}
+	if linux != nil {
+		return linux
+	}
+	return &manifests[0]
+}
+
+// blobPath returns location of the blob, identified by '<algorithm>:<hex>' digest
+func blobPath(dir, digest string) string {
+	fields := strings.SplitN(digest, ":", 2)
+	if len(fields) != 2 {
+		return filepath.Join(dir, "blobs", filepath.Base(digest))
+	}
+	// digest must not point outside of the layout
+	return filepath.Join(dir, "blobs", filepath.Base(fields[0]), filepath.Base(fields[1]))
+}
+
+func readBlobJSON(filename string, v interface{}) error {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+	if err := json.Unmarshal(buf, v); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,510 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package executor
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ErrNotSupported is returned by executors, that cannot run commands
+var ErrNotSupported = errors.New("not supported")
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+	maxSymlinks    = 40 // the same limit as Linux kernel has
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// imageFile is a file of merged image file system. Content of regular files isn't kept in memory, it is read
+// from the layer when requested
+type imageFile struct {
+	hdr      *tar.Header
+	layer    int                   // index of the layer the file comes from
+	offset   int64                 // offset of regular file content in uncompressed layer
+	children map[string]*imageFile // directory entries, keyed by base name
+}
+
+// ImageExecutor serves merged file system of image layers, read from 'docker save' archive or OCI image layout.
+// Layers are indexed when executor is created, whiteouts are applied, file content is read from the layers
+// on demand. Commands cannot be executed
+type ImageExecutor struct {
+	id     string
+	root   *imageFile
+	layers []func() (io.ReadCloser, error)
+	closer io.Closer // image archive, if layers are read from it
+}
+
+// newImageExecutor indexes layers of the image with the specified ID, starting from the base one. Layers are
+// opened again to read file content, closer is closed together with executor
+func newImageExecutor(id string, layers []func() (io.ReadCloser, error), closer io.Closer) (*ImageExecutor, error) {
+	e := &ImageExecutor{
+		id:     id,
+		root:   &imageFile{hdr: &tar.Header{Name: "/", Typeflag: tar.TypeDir, Mode: 0755}},
+		layers: layers,
+		closer: closer,
+	}
+	for i := range layers {
+		if err := e.applyLayer(i + 1); err != nil {
+			e.Close()
+			return nil, fmt.Errorf("cannot process image layer %d: %w", i+1, err)
+		}
+	}
+	return e, nil
+}
+
+// layerReader reads uncompressed layer and tracks the position in it. Uncompressed layer, stored in seekable
+// blob, is seeked instead of being read through
+type layerReader struct {
+	r       io.Reader
+	seeker  io.Seeker // nil if layer is compressed or its blob cannot be seeked
+	pos     int64
+	closers []io.Closer
+}
+
+// openLayer opens the layer with the specified index for reading. Layer can be compressed with gzip
+func (e *ImageExecutor) openLayer(layer int) (*layerReader, error) {
+	rc, err := e.layers[layer-1]()
+	if err != nil {
+		return nil, err
+	}
+	l := &layerReader{closers: []io.Closer{rc}}
+	br := bufio.NewReader(rc)
+	magic, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		l.r = zr
+		l.closers = append(l.closers, zr)
+	case bytes.HasPrefix(magic, zstdMagic):
+		rc.Close()
+		return nil, errors.New("zstd-compressed layers are not supported")
+	default:
+		l.r = br
+		if s, ok := rc.(io.Seeker); ok {
+			if _, err := s.Seek(0, io.SeekStart); err == nil {
+				l.r, l.seeker = rc, s
+			}
+		}
+	}
+	return l, nil
+}
+
+func (l *layerReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.pos += int64(n)
+	return n, err
+}
+
+// Seek lets TAR reader skip file content without reading it, if the layer can be seeked
+func (l *layerReader) Seek(offset int64, whence int) (int64, error) {
+	if l.seeker == nil {
+		return 0, ErrNotSupported
+	}
+	pos, err := l.seeker.Seek(offset, whence)
+	if err == nil {
+		l.pos = pos
+	}
+	return pos, err
+}
+
+// readAt reads size bytes at the offset, which must not be before the current position
+func (l *layerReader) readAt(offset, size int64) ([]byte, error) {
+	if l.seeker != nil {
+		if _, err := l.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	} else if _, err := io.CopyN(ioutil.Discard, l, offset-l.pos); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(l, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (l *layerReader) Close() error {
+	var err error
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		if cerr := l.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// applyLayer adds entries of the layer on top of merged file system
+func (e *ImageExecutor) applyLayer(layer int) error {
+	r, err := e.openLayer(layer)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean("/" + hdr.Name)
+		if name == "/" {
+			continue
+		}
+		dir, base := path.Split(name)
+		dir = path.Clean(dir)
+
+		if base == whiteoutOpaque {
+			// directory content from lower layers is hidden
+			if d := e.lookup(dir); d != nil {
+				d.removeLower(layer)
+			}
+			continue
+		}
+		if strings.HasPrefix(base, whiteoutPrefix) {
+			if d := e.lookup(dir); d != nil {
+				d.whiteout(strings.TrimPrefix(base, whiteoutPrefix), layer)
+			}
+			continue
+		}
+
+		f := &imageFile{hdr: hdr, layer: layer}
+		if hdr.Typeflag == tar.TypeReg {
+			f.offset = r.pos // TAR reader stops at the beginning of file content
+		}
+		e.add(name, f)
+	}
+}
+
+// add adds file to merged file system, creating missing parent directories - layers don't always
+// contain entries for them. Directory, that already exists, keeps its entries
+func (e *ImageExecutor) add(name string, f *imageFile) {
+	elems := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	parent := e.root
+	for i, elem := range elems[:len(elems)-1] {
+		next, ok := parent.children[elem]
+		if !ok {
+			next = &imageFile{
+				hdr:   &tar.Header{Name: "/" + strings.Join(elems[:i+1], "/"), Typeflag: tar.TypeDir, Mode: 0755},
+				layer: f.layer,
+			}
+			parent.setChild(elem, next)
+		}
+		parent = next
+	}
+	base := elems[len(elems)-1]
+	if existing, ok := parent.children[base]; ok && existing.hdr.Typeflag == tar.TypeDir && f.hdr.Typeflag == tar.TypeDir {
+		f.children = existing.children
+	}
+	parent.setChild(base, f) // directory, replaced with a file, loses its entries
+}
+
+func (f *imageFile) setChild(name string, child *imageFile) {
+	if f.children == nil {
+		f.children = make(map[string]*imageFile)
+	}
+	f.children[name] = child
+}
+
+// lookup returns the file by its path in merged file system, without following symbolic links
+func (e *ImageExecutor) lookup(name string) *imageFile {
+	f := e.root
+	for _, elem := range strings.Split(strings.Trim(name, "/"), "/") {
+		if elem == "" {
+			continue
+		}
+		next, ok := f.children[elem]
+		if !ok {
+			return nil
+		}
+		f = next
+	}
+	return f
+}
+
+// removeLower removes the entries of the directory, which come from the layers below the specified one.
+// Directories from the upper layers are kept with their upper layer entries
+func (f *imageFile) removeLower(layer int) {
+	for name, c := range f.children {
+		c.removeLower(layer)
+		if c.layer < layer && len(c.children) == 0 {
+			delete(f.children, name)
+		}
+	}
+}
+
+// whiteout removes the directory entry, if it comes from the layers below the specified one
+func (f *imageFile) whiteout(name string, layer int) {
+	c, ok := f.children[name]
+	if !ok {
+		return
+	}
+	c.removeLower(layer)
+	if c.layer < layer && len(c.children) == 0 {
+		delete(f.children, name)
+	}
+}
+
+// walk calls fn for all entries of the directory at any nesting level, sorted by name. Entry path is
+// relative to the directory
+func (f *imageFile) walk(prefix string, fn func(name string, f *imageFile)) {
+	names := make([]string, 0, len(f.children))
+	for n := range f.children {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		c := f.children[n]
+		fn(path.Join(prefix, n), c)
+		c.walk(path.Join(prefix, n), fn)
+	}
+}
+
+// resolve follows symbolic links in the path and returns the file. If followLast is false, the last path
+// element isn't resolved
+func (e *ImageExecutor) resolve(name string, followLast bool) (string, *imageFile, error) {
+	if e.root == nil {
+		return "", nil, os.ErrClosed
+	}
+	rest := strings.Split(strings.Trim(path.Clean("/"+name), "/"), "/")
+	cur, dir := "/", e.root
+	links := 0
+	for len(rest) > 0 {
+		if rest[0] == "" {
+			rest = rest[1:]
+			continue
+		}
+		f, ok := dir.children[rest[0]]
+		if !ok {
+			return "", nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		if f.hdr.Typeflag == tar.TypeSymlink && (len(rest) > 1 || followLast) {
+			links++
+			if links > maxSymlinks {
+				return "", nil, fmt.Errorf("%s: too many levels of symbolic links", name)
+			}
+			target := f.hdr.Linkname
+			if !path.IsAbs(target) {
+				target = path.Join(cur, target)
+			}
+			// resolve the target from the root again
+			rest = append(strings.Split(strings.Trim(path.Clean(target), "/"), "/"), rest[1:]...)
+			cur, dir = "/", e.root
+			continue
+		}
+		cur, dir = path.Join(cur, rest[0]), f
+		rest = rest[1:]
+	}
+	return cur, dir, nil
+}
+
+// contentFile returns the file, holding the content: the file itself, or hard link target
+func (e *ImageExecutor) contentFile(f *imageFile) (*imageFile, error) {
+	if f.hdr.Typeflag != tar.TypeLink {
+		return f, nil
+	}
+	target := e.lookup(path.Clean("/" + f.hdr.Linkname))
+	if target == nil || target.hdr.Typeflag != tar.TypeReg {
+		return nil, fmt.Errorf("broken hard link %s", f.hdr.Name)
+	}
+	return target, nil
+}
+
+// readContents reads content of regular files, nil entries and other file types are ignored. Files are grouped
+// by layer, so each layer is read only once
+func (e *ImageExecutor) readContents(files []*imageFile) (map[*imageFile][]byte, error) {
+	res := make(map[*imageFile][]byte, len(files))
+	byLayer := make(map[int][]*imageFile)
+	for _, f := range files {
+		if f == nil || f.hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if _, ok := res[f]; ok {
+			continue
+		}
+		res[f] = nil
+		byLayer[f.layer] = append(byLayer[f.layer], f)
+	}
+	for layer, lf := range byLayer {
+		sort.Slice(lf, func(i, j int) bool { return lf[i].offset < lf[j].offset })
+		r, err := e.openLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read image layer %d: %w", layer, err)
+		}
+		for _, f := range lf {
+			if res[f], err = r.readAt(f.offset, f.hdr.Size); err != nil {
+				r.Close()
+				return nil, fmt.Errorf("cannot read %s: %w", f.hdr.Name, err)
+			}
+		}
+		r.Close()
+	}
+	return res, nil
+}
+
+// ImageID returns the digest of image configuration without algorithm prefix, the same as Docker daemon
+// reports as image ID
+func (e *ImageExecutor) ImageID() string {
+	return e.id
+}
+
+// Exec is not supported, image file system can only be read
+func (e *ImageExecutor) Exec(cmd []string) ([]byte, []byte, int, error) {
+	return nil, nil, 0, ErrNotSupported
+}
+
+// ReadFile returns content of the file from merged image file system
+func (e *ImageExecutor) ReadFile(name string) ([]byte, error) {
+	_, f, err := e.resolve(name, true)
+	if err != nil {
+		return nil, err
+	}
+	if f.hdr.Typeflag == tar.TypeDir {
+		return nil, fmt.Errorf("%s is a directory", name)
+	}
+	if f, err = e.contentFile(f); err != nil {
+		return nil, err
+	}
+	contents, err := e.readContents([]*imageFile{f})
+	if err != nil {
+		return nil, err
+	}
+	return contents[f], nil
+}
+
+// Exists checks if the file or the directory exists in the image, without reading layer content
+func (e *ImageExecutor) Exists(name string) bool {
+	_, _, err := e.resolve(name, true)
+	return err == nil
+}
+
+// ReadDir returns the content of the directory as TAR stream. Entries are prefixed with directory base name,
+// the same way as Docker daemon does it. Hard links are replaced with regular files
+func (e *ImageExecutor) ReadDir(name string) (io.ReadCloser, error) {
+	dir, f, err := e.resolve(name, true)
+	if err != nil {
+		return nil, err
+	}
+	if f.hdr.Typeflag != tar.TypeDir {
+		return nil, fmt.Errorf("%s is not a directory", name)
+	}
+
+	base := path.Base(dir)
+	var names []string
+	var entries []*imageFile
+	f.walk(base, func(name string, f *imageFile) {
+		names = append(names, name)
+		entries = append(entries, f)
+	})
+	// regular files and hard link targets, which content is written to the stream
+	sources := make([]*imageFile, len(entries))
+	for i, entry := range entries {
+		if entry.hdr.Typeflag == tar.TypeReg || entry.hdr.Typeflag == tar.TypeLink {
+			if sources[i], err = e.contentFile(entry); err != nil {
+				return nil, err
+			}
+		}
+	}
+	contents, err := e.readContents(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{Name: base + "/", Typeflag: tar.TypeDir, Mode: f.hdr.Mode,
+		ModTime: f.hdr.ModTime}); err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		hdr := *entry.hdr
+		hdr.Name = names[i]
+		var content []byte
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			hdr.Name += "/"
+		case tar.TypeReg, tar.TypeLink:
+			content = contents[sources[i]]
+			hdr.Typeflag = tar.TypeReg
+			hdr.Linkname = ""
+			hdr.Size = int64(len(content))
+		default:
+			hdr.Size = 0
+		}
+		// PAX records refer to the original name
+		hdr.PAXRecords = nil
+		hdr.Format = tar.FormatUnknown
+		if err := tw.WriteHeader(&hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(buf), nil
+}
+
+// Glob expands shell patterns in merged image file system. Pattern is matched element by element, so symbolic
+// links to directories are followed
+func (e *ImageExecutor) Glob(patterns []string) ([]string, error) {
+	return globElements(patterns, e.matchChildren)
+}
+
+// matchChildren returns sorted paths of directory entries, matching the pattern element
+func (e *ImageExecutor) matchChildren(dir, elem string) []string {
+	_, f, err := e.resolve(dir, true)
+	if err != nil || f.hdr.Typeflag != tar.TypeDir {
+		return nil
+	}
+	var res []string
+	for n := range f.children {
+		if ok, _ := path.Match(elem, n); ok {
+			res = append(res, path.Join(dir, n))
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Close releases merged file system and closes image archive
+func (e *ImageExecutor) Close() error {
+	e.root = nil
+	if e.closer == nil {
+		return nil
+	}
+	err := e.closer.Close()
+	e.closer = nil
+	return err
+}
@@ -0,0 +1,302 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package executor
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testEntry struct {
+	name     string
+	content  string
+	typeflag byte
+	linkname string
+}
+
+func makeTar(t *testing.T, entries []testEntry) []byte {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, e := range entries {
+		typeflag := e.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		hdr := &tar.Header{Name: e.name, Typeflag: typeflag, Linkname: e.linkname, Mode: 0644}
+		if typeflag == tar.TypeReg {
+			hdr.Size = int64(len(e.content))
+		}
+		assert.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(e.content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, buf []byte) []byte {
+	out := new(bytes.Buffer)
+	zw := gzip.NewWriter(out)
+	_, err := zw.Write(buf)
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	return out.Bytes()
+}
+
+// test image has base layer with files, and the top one with whiteouts
+func testLayers(t *testing.T) [][]byte {
+	base := makeTar(t, []testEntry{
+		{name: "etc/", typeflag: tar.TypeDir},
+		{name: "etc/os-release", content: "ID=alpine\n"},
+		{name: "usr/lib/libfoo.so", content: "foo"},
+		{name: "usr/lib/libbar.so", content: "bar"},
+		{name: "lib", typeflag: tar.TypeSymlink, linkname: "usr/lib"},
+		{name: "var/cache/a", content: "a"},
+		{name: "var/cache/b", content: "b"},
+		{name: "opt/app/config", content: "old"},
+	})
+	top := makeTar(t, []testEntry{
+		{name: "usr/lib/.wh.libbar.so"},
+		{name: "var/cache/.wh..wh..opq"},
+		{name: "var/cache/c", content: "c"},
+		{name: "opt/app/config", content: "new"},
+		{name: "opt/app/link", typeflag: tar.TypeLink, linkname: "opt/app/config"},
+	})
+	return [][]byte{base, gzipped(t, top)}
+}
+
+func checkImage(t *testing.T, e Executor) {
+	buf, err := e.ReadFile("/etc/os-release")
+	assert.NoError(t, err)
+	assert.Equal(t, "ID=alpine\n", string(buf))
+
+	// symbolic link in path
+	buf, err = e.ReadFile("/lib/libfoo.so")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(buf))
+
+	// removed with whiteout
+	_, err = e.ReadFile("/usr/lib/libbar.so")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	buf, err = e.ReadFile("/opt/app/link")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(buf))
+
+	_, err = e.ReadFile("/etc")
+	assert.Error(t, err)
+
+	assert.True(t, Exists(e, "/etc"))
+	assert.True(t, Exists(e, "/lib/libfoo.so"))
+	assert.False(t, Exists(e, "/usr/lib/libbar.so"))
+
+	// opaque directory has only the files from the top layer
+	reader, err := e.ReadDir("/var/cache")
+	assert.NoError(t, err)
+	defer reader.Close()
+	var names []string
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+		content, _ := ioutil.ReadAll(tr)
+		names = append(names, hdr.Name+"="+string(content))
+	}
+	assert.Equal(t, []string{"cache/=", "cache/c=c"}, names)
+
+	// symbolic link to directory is followed
+	dirs, err := Glob(e, []string{"/lib/lib*.so", "/var/*/?"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/lib/libfoo.so", "/var/cache/c"}, dirs)
+
+	_, _, _, err = e.Exec([]string{"ls"})
+	assert.True(t, errors.Is(err, ErrNotSupported))
+	assert.NoError(t, e.Close())
+}
+
+func TestArchiveExecutor(t *testing.T) {
+	layers := testLayers(t)
+	manifest, _ := json.Marshal([]archiveImage{
+		{Config: "other.json", RepoTags: []string{"example/other:1.0"}, Layers: []string{"l1/layer.tar"}},
+		{Config: "image.json", RepoTags: []string{"example/image:latest"}, Layers: []string{"l1/layer.tar", "l2/layer.tar"}},
+	})
+	config := `{"architecture": "amd64", "os": "linux"}`
+	archive := makeTar(t, []testEntry{
+		{name: "l1/layer.tar", content: string(layers[0])},
+		{name: "l2/layer.tar", content: string(layers[1])},
+		{name: "other.json", content: "{}"},
+		{name: "image.json", content: config},
+		{name: archiveManifest, content: string(manifest)},
+	})
+	sum := sha256.Sum256([]byte(config))
+	filename := filepath.Join(t.TempDir(), "image.tar")
+	assert.NoError(t, ioutil.WriteFile(filename, archive, 0644))
+
+	// several images in archive
+	_, err := NewArchiveExecutor(filename)
+	assert.Error(t, err)
+	_, err = NewArchiveExecutor(filename + ":missing")
+	assert.Error(t, err)
+
+	e, err := NewArchiveExecutor(filename + ":example/image:latest")
+	assert.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(sum[:]), e.(Identifier).ImageID())
+	checkImage(t, e)
+
+	// compressed archive
+	assert.NoError(t, ioutil.WriteFile(filename, gzipped(t, archive), 0644))
+	e, err = NewArchiveExecutor(filename + ":image:latest")
+	assert.NoError(t, err)
+	checkImage(t, e)
+}
+
+func writeBlob(t *testing.T, dir string, buf []byte) string {
+	sum := sha256.Sum256(buf)
+	digest := hex.EncodeToString(sum[:])
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "blobs", "sha256", digest), buf, 0644))
+	return "sha256:" + digest
+}
+
+func TestOCIExecutor(t *testing.T) {
+	dir := t.TempDir()
+	layers := testLayers(t)
+	manifest := ociManifest{MediaType: "application/vnd.oci.image.manifest.v1+json"}
+	manifest.Config.Digest = writeBlob(t, dir, []byte(`{"architecture": "amd64", "os": "linux"}`))
+	for _, l := range layers {
+		manifest.Layers = append(manifest.Layers, ociDescriptor{Digest: writeBlob(t, dir, l)})
+	}
+	buf, _ := json.Marshal(manifest)
+	imageDigest := writeBlob(t, dir, buf)
+
+	// multi-platform image
+	buf, _ = json.Marshal(map[string]interface{}{
+		"mediaType": mediaTypeOCIIndex,
+		"manifests": []map[string]interface{}{
+			{"digest": imageDigest, "platform": map[string]string{"os": "linux", "architecture": "unknown"}},
+		},
+	})
+	indexDigest := writeBlob(t, dir, buf)
+	buf, _ = json.Marshal(ociManifest{Manifests: []ociDescriptor{
+		{MediaType: mediaTypeOCIIndex, Digest: indexDigest, Annotations: map[string]string{annotationRefName: "1.0"}},
+	}})
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ociIndex), buf, 0644))
+
+	_, err := NewOCIExecutor(dir)
+	assert.Error(t, err) // not a layout
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ociLayoutFile), []byte(`{"imageLayoutVersion": "1.0.0"}`), 0644))
+
+	_, err = NewOCIExecutor(dir + ":2.0")
+	assert.Error(t, err)
+
+	e, err := NewOCIExecutor(dir)
+	assert.NoError(t, err)
+	checkImage(t, e)
+
+	e, err = NewOCIExecutor(dir + ":1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, strings.TrimPrefix(manifest.Config.Digest, "sha256:"), e.(Identifier).ImageID())
+	checkImage(t, e)
+}
+
+func TestReadDirHardLink(t *testing.T) {
+	layer := makeTar(t, []testEntry{
+		{name: "usr/bin/a", content: "binary"},
+		{name: "usr/bin/b", typeflag: tar.TypeLink, linkname: "usr/bin/a"},
+		{name: "usr/bin/c", typeflag: tar.TypeSymlink, linkname: "a"},
+	})
+	e, err := newImageExecutor("", []func() (io.ReadCloser, error){
+		func() (io.ReadCloser, error) { return ioutil.NopCloser(bytes.NewReader(layer)), nil },
+	}, nil)
+	assert.NoError(t, err)
+
+	reader, err := e.ReadDir("/usr/bin")
+	assert.NoError(t, err)
+	files := make(map[string]string)
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+		content, _ := ioutil.ReadAll(tr)
+		files[hdr.Name] = string(content) + hdr.Linkname
+	}
+	names := make([]string, 0, len(files))
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"bin/", "bin/a", "bin/b", "bin/c"}, names)
+	assert.Equal(t, "binary", files["bin/b"])
+	assert.Equal(t, "a", files["bin/c"])
+
+	_, err = e.ReadDir("/usr/bin/a")
+	assert.Error(t, err)
+}
+
+func TestLayerDirectories(t *testing.T) {
+	base := makeTar(t, []testEntry{
+		{name: "d/x/y", content: "y"},
+		{name: "d/z/w", content: "w"},
+		{name: "f/a", content: "a"},
+	})
+	top := makeTar(t, []testEntry{
+		{name: "d/x/", typeflag: tar.TypeDir},
+		{name: "d/x/new", content: "new"},
+		{name: "d/.wh.z"},
+		{name: "f", content: "file"},
+	})
+	layers := [][]byte{base, top}
+	opens := make([]func() (io.ReadCloser, error), len(layers))
+	for i, l := range layers {
+		l := l
+		opens[i] = func() (io.ReadCloser, error) { return ioutil.NopCloser(bytes.NewReader(l)), nil }
+	}
+	e, err := newImageExecutor("", opens, nil)
+	assert.NoError(t, err)
+
+	// directory from upper layer keeps lower layer entries
+	dirs, err := Glob(e, []string{"/d/*/*", "/f/*"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/d/x/new", "/d/x/y"}, dirs)
+
+	buf, err := e.ReadFile("/d/x/y")
+	assert.NoError(t, err)
+	assert.Equal(t, "y", string(buf))
+
+	// directory is removed with all its entries, or replaced with a file
+	_, err = e.ReadFile("/d/z/w")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	buf, err = e.ReadFile("/f")
+	assert.NoError(t, err)
+	assert.Equal(t, "file", string(buf))
+
+	assert.NoError(t, e.Close())
+	_, err = e.ReadFile("/f")
+	assert.Error(t, err)
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package golang
+
+import (
+	"debug/buildinfo"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+// goArtifactFromExe implements Artifact interface for the Go executable, built with module support
+type goArtifactFromExe struct {
+	artifact.GenericArtifact
+	path string
+	info *debug.BuildInfo
+}
+
+func (a goArtifactFromExe) Type() string {
+	return AssetType
+}
+
+func (a goArtifactFromExe) Path() string {
+	return a.path
+}
+
+// ResolveDependencies returns the main module and all modules linked into executable, as recorded
+// by Go linker. Module replacements are taken into account
+func (a *goArtifactFromExe) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	if a.Deps != nil {
+		return a.Deps, nil
+	}
+
+	if a.info == nil {
+		info, err := buildinfo.ReadFile(a.path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read build info from %s: %w", a.path, err)
+		}
+		a.info = info
+	}
+
+	res := make([]artifact.Dependency, 0, len(a.info.Deps)+1)
+
+	main, err := a.mainModule()
+	if err != nil {
+		return nil, err
+	}
+	if main != nil {
+		res = append(res, *main)
+	}
+
+	for _, mod := range a.info.Deps {
+		if mod.Replace != nil {
+			mod = mod.Replace
+		}
+		if mod.Sum == "" {
+			// module replaced by local directory has no hash
+			artifact.Warnf(output, "Module %s@%s has no hash, skipping\n", mod.Path, mod.Version)
+			continue
+		}
+		hash, err := ModHash(mod.Sum)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, artifact.Dependency{
+			Name:     mod.Path,
+			Version:  mod.Version,
+			Hash:     hash,
+			HashType: artifact.HashSHA256,
+			Kind:     AssetType,
+			Type:     artifact.DepDirect,
+		})
+	}
+
+	a.Deps = res
+	return res, nil
+}
+
+// mainModule returns dependency for the main module, along with the build settings. Module checksum is
+// known only for executables built with 'go install <module>@<version>', otherwise VCS revision is used
+// as a hash of the module source, unless the working tree was modified. If none are available, main module
+// isn't included
+func (a *goArtifactFromExe) mainModule() (*artifact.Dependency, error) {
+	main := a.info.Main
+	if main.Path == "" {
+		return nil, nil
+	}
+
+	props := map[string]string{"GoVersion": a.info.GoVersion}
+	for _, s := range a.info.Settings {
+		props[s.Key] = s.Value
+	}
+
+	dep := artifact.Dependency{
+		Name:       main.Path,
+		Version:    main.Version,
+		Kind:       AssetType,
+		Type:       artifact.DepDirect,
+		Properties: props,
+	}
+
+	revision := props["vcs.revision"]
+	switch {
+	case main.Sum != "":
+		hash, err := ModHash(main.Sum)
+		if err != nil {
+			return nil, err
+		}
+		dep.Hash = hash
+		dep.HashType = artifact.HashSHA256
+	case props["vcs"] == "git" && props["vcs.modified"] != "true" && isHexHash(revision, 20):
+		dep.Hash = revision
+		dep.HashType = artifact.HashSHA1
+	default:
+		return nil, nil
+	}
+
+	return &dep, nil
+}
+
+func isHexHash(s string, size int) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == size
+}
+
+// IsExecutable returns true if artifact is Go executable, therefore all its Go dependencies are
+// linked statically
+func IsExecutable(a artifact.Artifact) bool {
+	_, ok := a.(*goArtifactFromExe)
+	return ok
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package golang
+
+import (
+	"debug/buildinfo"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+const AssetType = "gocom"
+
+// New returns new Artifact object, or nil if filename doesn't refer to Go module, directory containing one
+// or Go executable
+func New(filename string) artifact.Artifact {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil
+	}
+
+	if fi.IsDir() {
+		if _, err := os.Stat(filepath.Join(filename, "go.mod")); err == nil {
+			return &goArtifactFromSum{path: filename}
+		}
+		return nil
+	}
+
+	if filepath.Base(filename) == "go.mod" || filepath.Base(filename) == "go.sum" {
+		return &goArtifactFromSum{path: filepath.Dir(filename)}
+	}
+
+	info, err := buildinfo.ReadFile(filename)
+	if err != nil {
+		return nil // not a Go executable, or built without module support
+	}
+	return &goArtifactFromExe{path: filename, info: info}
+}
+
+// ModHash converts Go module hash in 'h1:<base64 SHA-256>' form into hex encoding
+func ModHash(h1 string) (string, error) {
+	if !strings.HasPrefix(h1, "h1:") {
+		return "", fmt.Errorf("unsupported module hash format: %s", h1)
+	}
+	hash, err := base64.StdEncoding.DecodeString(h1[3:])
+	if err != nil {
+		return "", fmt.Errorf("malformed module hash %s: %w", h1, err)
+	}
+	return hex.EncodeToString(hash), nil
+}
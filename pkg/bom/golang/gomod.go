@@ -0,0 +1,336 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package golang
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+// goArtifactFromSum implements Artifact interface for the Go module directory
+type goArtifactFromSum struct {
+	artifact.GenericArtifact
+	path string
+}
+
+type modRequire struct {
+	path     string
+	version  string
+	indirect bool
+}
+
+type modReplace struct {
+	oldVersion string // empty if replacement applies to all versions
+	path       string
+	version    string // empty if module is replaced by local directory
+}
+
+type goMod struct {
+	module    string
+	goVersion string // empty if go.mod has no go directive
+	require   []modRequire
+	replace   map[string][]modReplace
+}
+
+type modKey struct {
+	path    string
+	version string
+}
+
+func (a goArtifactFromSum) Type() string {
+	return AssetType
+}
+
+func (a goArtifactFromSum) Path() string {
+	return a.path
+}
+
+// ResolveDependencies returns list of modules listed in go.mod, and also in go.sum for modules before Go 1.17,
+// with go.mod direct requirements marked as direct dependencies, and all others - as transient
+func (a *goArtifactFromSum) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	if a.Deps != nil {
+		return a.Deps, nil
+	}
+
+	f, err := os.Open(filepath.Join(a.path, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read go.mod: %w", err)
+	}
+	defer f.Close()
+	mod, err := parseGoMod(f)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[modKey]string)
+	f, err = os.Open(filepath.Join(a.path, "go.sum"))
+	if err == nil {
+		defer f.Close()
+		hashes, err = parseGoSum(f)
+		if err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot read go.sum: %w", err)
+	}
+
+	g := depgraph.NewGraph(mod.module, "")
+
+	required := make(map[string]struct{}, len(mod.require))
+	for _, req := range mod.require {
+		required[req.path] = struct{}{}
+		path, version := mod.replaced(req.path, req.version)
+		if version == "" {
+			continue // local replacement - no hash available
+		}
+		dep := newDependency(path, version, hashes)
+		if dep == nil {
+			continue
+		}
+		if req.indirect {
+			g.NewNode(path, version, dep)
+		} else {
+			g.AddChild(g.Root, path, version, dep)
+		}
+	}
+
+	if mod.prunedGraph() {
+		a.Deps = g.FlatDeps()
+		return a.Deps, nil
+	}
+
+	// before Go 1.17, go.mod doesn't list all modules in the build list, so the remaining ones are
+	// taken from go.sum - if there are several versions of the module, the highest one is selected
+	selected := make(map[string]string)
+	for k := range hashes {
+		if _, ok := required[k.path]; ok {
+			continue
+		}
+		if v, ok := selected[k.path]; !ok || versionLess(v, k.version) {
+			selected[k.path] = k.version
+		}
+	}
+	for path, version := range selected {
+		if path == mod.module {
+			continue
+		}
+		dep := newDependency(path, version, hashes)
+		if dep != nil {
+			g.NewNode(path, version, dep)
+		}
+	}
+
+	a.Deps = g.FlatDeps()
+	return a.Deps, nil
+}
+
+func newDependency(path, version string, hashes map[modKey]string) *artifact.Dependency {
+	hash, ok := hashes[modKey{path, version}]
+	if !ok {
+		// go.sum may be missing or stale, or module may have no source code needed for the build
+		return nil
+	}
+	return &artifact.Dependency{
+		Name:     path,
+		Version:  version,
+		Hash:     hash,
+		HashType: artifact.HashSHA256,
+		Kind:     AssetType,
+	}
+}
+
+// replaced returns module path and version after applying 'replace' directives
+func (m *goMod) replaced(path, version string) (string, string) {
+	var res *modReplace
+	for i, r := range m.replace[path] {
+		if r.oldVersion == version {
+			res = &m.replace[path][i]
+			break // exact version match takes precedence
+		}
+		if r.oldVersion == "" {
+			res = &m.replace[path][i]
+		}
+	}
+	if res == nil {
+		return path, version
+	}
+	return res.path, res.version
+}
+
+// prunedGraph returns true if module requires Go 1.17 or later. Such go.mod lists all modules, providing
+// packages for the build, while go.sum may contain modules outside of the build list. go.mod without
+// go directive is treated as Go 1.16, like Go command does
+func (m *goMod) prunedGraph() bool {
+	fields := strings.SplitN(m.goVersion, ".", 3)
+	if len(fields) < 2 {
+		return false
+	}
+	major, err1 := strconv.Atoi(fields[0])
+	minor, err2 := strconv.Atoi(fields[1])
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return major > 1 || (major == 1 && minor >= 17)
+}
+
+// parseGoMod parses go.mod file, see https://go.dev/ref/mod#go-mod-file-grammar
+func parseGoMod(r io.Reader) (*goMod, error) {
+	mod := &goMod{replace: make(map[string][]modReplace)}
+
+	scanner := bufio.NewScanner(r)
+	block := ""
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		comment := ""
+		if i := strings.Index(line, "//"); i >= 0 {
+			comment = strings.TrimSpace(line[i+2:])
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+
+		verb := block
+		if block != "" {
+			if line == ")" {
+				block = ""
+				continue
+			}
+		} else {
+			verb = strings.Fields(line)[0]
+			line = strings.TrimSpace(strings.TrimPrefix(line, verb))
+			if line == "(" {
+				block = verb
+				continue
+			}
+		}
+
+		var err error
+		switch verb {
+		case "module":
+			mod.module, err = unquote(line)
+		case "go":
+			mod.goVersion = line
+		case "require":
+			err = mod.addRequire(line, comment)
+		case "replace":
+			err = mod.addReplace(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("go.mod:%d: %w", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read go.mod: %w", err)
+	}
+	if mod.module == "" {
+		return nil, fmt.Errorf("go.mod has no module directive")
+	}
+
+	return mod, nil
+}
+
+func (m *goMod) addRequire(line, comment string) error {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return fmt.Errorf("malformed require directive: %s", line)
+	}
+	path, err := unquote(fields[0])
+	if err != nil {
+		return err
+	}
+	m.require = append(m.require, modRequire{
+		path:     path,
+		version:  fields[1],
+		indirect: comment == "indirect" || strings.HasPrefix(comment, "indirect;"),
+	})
+	return nil
+}
+
+func (m *goMod) addReplace(line string) error {
+	parts := strings.SplitN(line, "=>", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("malformed replace directive: %s", line)
+	}
+	old := strings.Fields(parts[0])
+	repl := strings.Fields(parts[1])
+	if len(old) < 1 || len(old) > 2 || len(repl) < 1 || len(repl) > 2 {
+		return fmt.Errorf("malformed replace directive: %s", line)
+	}
+
+	oldPath, err := unquote(old[0])
+	if err != nil {
+		return err
+	}
+	r := modReplace{}
+	if len(old) == 2 {
+		r.oldVersion = old[1]
+	}
+	r.path, err = unquote(repl[0])
+	if err != nil {
+		return err
+	}
+	if len(repl) == 2 {
+		r.version = repl[1]
+	}
+	m.replace[oldPath] = append(m.replace[oldPath], r)
+	return nil
+}
+
+// parseGoSum parses go.sum file and returns module hashes. Hashes of go.mod files are ignored
+func parseGoSum(r io.Reader) (map[modKey]string, error) {
+	res := make(map[modKey]string)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		hash, err := ModHash(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		res[modKey{fields[0], fields[1]}] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read go.sum: %w", err)
+	}
+
+	return res, nil
+}
+
+func unquote(s string) (string, error) {
+	if strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "`") {
+		return strconv.Unquote(s)
+	}
+	return s, nil
+}
+
+// versionLess compares module versions according to semantic versioning rules
+func versionLess(v1, v2 string) bool {
+	sv1, err1 := semver.ParseTolerant(v1)
+	sv2, err2 := semver.ParseTolerant(v2)
+	if err1 != nil || err2 != nil {
+		return v1 < v2
+	}
+	return sv1.LT(sv2)
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package golang
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+const testGoMod = `module example.com/service
+
+go 1.17
+
+require github.com/spf13/cobra v1.3.0
+
+require (
+	"github.com/google/uuid" v1.3.0
+	golang.org/x/sys v0.0.0-20220520151302-bc2c85ada10a // indirect
+)
+
+replace (
+	github.com/google/uuid => github.com/google/uuid v1.2.0
+	example.com/local => ../local
+)
+`
+
+func TestParseGoMod(t *testing.T) {
+	mod, err := parseGoMod(strings.NewReader(testGoMod))
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/service", mod.module)
+	assert.Equal(t, "1.17", mod.goVersion)
+	assert.True(t, mod.prunedGraph())
+	assert.Equal(t, []modRequire{
+		{"github.com/spf13/cobra", "v1.3.0", false},
+		{"github.com/google/uuid", "v1.3.0", false},
+		{"golang.org/x/sys", "v0.0.0-20220520151302-bc2c85ada10a", true},
+	}, mod.require)
+
+	path, version := mod.replaced("github.com/google/uuid", "v1.3.0")
+	assert.Equal(t, "github.com/google/uuid", path)
+	assert.Equal(t, "v1.2.0", version)
+
+	path, version = mod.replaced("example.com/local", "v0.1.0")
+	assert.Equal(t, "../local", path)
+	assert.Empty(t, version)
+}
+
+func TestParseGoSum(t *testing.T) {
+	sum := `github.com/google/uuid v1.3.0 h1:t6JiXgmwXMjEs8VusXIJk2BXHsn+wx8BZdTaoZ5fu7I=
+github.com/google/uuid v1.3.0/go.mod h1:TIyPZe4MgqvfeYDBFedMoGGpEw/LqOeaOT+nhxU+yHo=
+`
+	hashes, err := parseGoSum(strings.NewReader(sum))
+	assert.NoError(t, err)
+	assert.Len(t, hashes, 1)
+	assert.Equal(t,
+		"b7a2625e09b05cc8c4b3c56eb172099360571ec9fec31f0165d4daa19e5fbbb2",
+		hashes[modKey{"github.com/google/uuid", "v1.3.0"}])
+
+	assert.True(t, versionLess("v1.2.0", "v1.10.0"))
+	assert.True(t, versionLess("v0.0.0-20210101000000-abcdef", "v0.1.0"))
+}
+
+func TestGoSumOutsideBuildList(t *testing.T) {
+	const hash = "h1:t6JiXgmwXMjEs8VusXIJk2BXHsn+wx8BZdTaoZ5fu7I="
+	sum := "github.com/google/uuid v1.3.0 " + hash + "\n" +
+		"github.com/google/uuid v1.1.0 " + hash + "\n" + // older version, not selected
+		"github.com/pkg/errors v0.9.1 " + hash + "\n" // not in build list of Go 1.17+ module
+
+	for goVersion, expected := range map[string][]string{
+		"go 1.17\n":   {"github.com/google/uuid@v1.3.0"},
+		"go 1.21.0\n": {"github.com/google/uuid@v1.3.0"},
+		"go 1.16\n":   {"github.com/google/uuid@v1.3.0", "github.com/pkg/errors@v0.9.1"},
+		"":            {"github.com/google/uuid@v1.3.0", "github.com/pkg/errors@v0.9.1"},
+	} {
+		dir := t.TempDir()
+		mod := "module example.com/service\n\n" + goVersion + "\nrequire github.com/google/uuid v1.3.0\n"
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "go.sum"), []byte(sum), 0644))
+
+		a := &goArtifactFromSum{path: dir}
+		deps, err := a.ResolveDependencies(artifact.Silent)
+		assert.NoError(t, err)
+		names := make([]string, 0, len(deps))
+		for _, d := range deps {
+			names = append(names, d.Name+"@"+d.Version)
+		}
+		assert.ElementsMatch(t, expected, names, goVersion)
+	}
+}
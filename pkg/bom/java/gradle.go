@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package java
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+// gradleCache returns location of Gradle module cache
+func gradleCache() string {
+	home := os.Getenv("GRADLE_USER_HOME")
+	if home == "" {
+		dir, err := homedir.Dir()
+		if err != nil {
+			return ""
+		}
+		home = filepath.Join(dir, ".gradle")
+	}
+	return filepath.Join(home, "caches", "modules-2", "files-2.1")
+}
+
+// gradleDeps resolves dependencies, listed in Gradle lock file. Archives are looked up in Gradle module cache
+// first, and then in the local Maven repository. Lock file has no dependency graph, so all dependencies
+// are considered direct ones
+func gradleDeps(filename string, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	locked, err := parseGradleLock(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+
+	cache := gradleCache()
+	maven := newMavenResolver()
+	res := make([]artifact.Dependency, 0, len(locked))
+	missing := 0
+	for _, c := range locked {
+		// cache layout is '<group>/<artifact>/<version>/<SHA-1>/<file>'
+		archive := ""
+		matches, _ := filepath.Glob(filepath.Join(cache, c.groupID, c.artifactID, c.version, "*", c.artifactID+"-"+c.version+".jar"))
+		if len(matches) > 0 {
+			archive = matches[0]
+		} else {
+			archive = maven.repoPath(c, "jar")
+		}
+		hash, err := fileSHA1(archive)
+		if err != nil {
+			missing++
+			continue
+		}
+		res = append(res, artifact.Dependency{
+			Name:     c.Name(),
+			Version:  c.version,
+			Hash:     hash,
+			HashType: artifact.HashSHA1,
+			Kind:     AssetType,
+			Type:     artifact.DepDirect,
+		})
+	}
+	if missing > 0 {
+		artifact.Warnf(output, "%d dependencies are missing in Gradle cache and were skipped, build the project first\n", missing)
+	}
+
+	return res, nil
+}
+
+// parseGradleLock parses lock file with '<group>:<artifact>:<version>=<configurations>' lines, and returns
+// dependencies used by non-test configurations
+func parseGradleLock(r io.Reader) ([]coordinates, error) {
+	var res []coordinates
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "empty=") {
+			continue
+		}
+		fields := strings.SplitN(line, "=", 2)
+		coords := strings.Split(fields[0], ":")
+		if len(coords) != 3 {
+			return nil, fmt.Errorf("malformed line: %s", line)
+		}
+		if len(fields) == 2 && !runtimeConfiguration(fields[1]) {
+			continue
+		}
+		res = append(res, coordinates{coords[0], coords[1], coords[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func runtimeConfiguration(configurations string) bool {
+	for _, c := range strings.Split(configurations, ",") {
+		c = strings.TrimSpace(c)
+		if c != "" && !strings.HasPrefix(c, "test") && !strings.HasPrefix(c, "annotationProcessor") {
+			return true
+		}
+	}
+	return false
+}
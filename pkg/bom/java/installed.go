@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package java
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+// common locations of application archives and system-wide libraries in JVM images
+var archiveGlobs = []string{"/*.jar", "/app/*.jar", "/app/lib/*.jar", "/app/libs/*.jar", "/deployments/*.jar",
+	"/opt/*/*.jar", "/opt/*/lib/*.jar", "/usr/share/java/*.jar", "/usr/local/tomcat/webapps/*.war",
+	"/opt/*/webapps/*.war"}
+
+// InstalledArchives finds JVM archives in common locations, accessible with executor. Identified archives
+// are reported as direct dependencies, libraries embedded into them - as transient ones
+func InstalledArchives(e executor.Executor) ([]artifact.Dependency, error) {
+	files, err := executor.Glob(e, archiveGlobs)
+	if err != nil {
+		return nil, nil // all locations have wildcards
+	}
+
+	var res []artifact.Dependency
+	for _, file := range files {
+		buf, err := e.ReadFile(file)
+		if err != nil {
+			continue // directory with archive-like name
+		}
+		zr, err := zip.NewReader(bytes.NewReader(buf), int64(len(buf)))
+		if err != nil {
+			continue // not a valid archive
+		}
+		self, shaded, err := mavenMeta(zr, path.Base(file))
+		if err != nil {
+			return nil, fmt.Errorf("cannot process %s: %w", file, err)
+		}
+		deps, err := embeddedDeps(zr, self, shaded)
+		if err != nil {
+			return nil, fmt.Errorf("cannot process %s: %w", file, err)
+		}
+		if self != nil {
+			hash := sha256.Sum256(buf)
+			res = append(res, artifact.Dependency{
+				Name:     self.Name(),
+				Version:  self.version,
+				Hash:     hex.EncodeToString(hash[:]),
+				HashType: artifact.HashSHA256,
+				Kind:     AssetType,
+				Type:     artifact.DepDirect,
+			})
+			for i := range deps {
+				deps[i].Type = artifact.DepTransient
+			}
+		}
+		res = append(res, deps...)
+	}
+	return res, nil
+}
@@ -0,0 +1,264 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package java
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+const mavenMetaDir = "META-INF/maven/"
+
+// '<artifactId>-<version>.jar' file name, version starts with a digit
+var archiveNamePattern = regexp.MustCompile(`^(.+?)-(\d[^/]*)\.[jwe]ar$`)
+
+// embedded is a library, embedded in the archive
+type embedded struct {
+	coordinates
+	hash     string
+	requires []string // names of the required libraries, from embedded pom.xml
+}
+
+// archiveDeps finds libraries, embedded in JAR/WAR/EAR archive (like Spring Boot fat JARs and WARs) at any
+// nesting level, or shaded into it. Each library is identified by its Maven metadata, or by the file name if
+// metadata is missing. Dependencies declared in the archive own pom.xml are considered direct, if present
+func archiveDeps(filename string, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open archive %s: %w", filename, err)
+	}
+	defer zr.Close()
+
+	self, shaded, err := mavenMeta(&zr.Reader, path.Base(filename))
+	if err != nil {
+		return nil, err
+	}
+	deps, err := embeddedDeps(&zr.Reader, self, shaded)
+	if err != nil {
+		return nil, fmt.Errorf("cannot process archive %s: %w", filename, err)
+	}
+	return deps, nil
+}
+
+// embeddedDeps builds dependency graph of libraries, shaded into the archive and embedded in it. If archive
+// itself is identified, its own pom.xml defines direct dependencies
+func embeddedDeps(zr *zip.Reader, self *embedded, shaded []embedded) ([]artifact.Dependency, error) {
+	libs := shaded
+	if err := nestedArchives(zr, &libs); err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*embedded, len(libs))
+	required := make(map[string]bool, len(libs))
+	for i := range libs {
+		byName[libs[i].Name()] = &libs[i]
+		for _, r := range libs[i].requires {
+			required[r] = true
+		}
+	}
+
+	root := "archive"
+	if self != nil {
+		root = self.Name()
+	}
+	g := depgraph.NewGraph(root, "")
+	for i := range libs {
+		l := &libs[i]
+		g.NewNode(l.Name(), l.version, &artifact.Dependency{
+			Name:     l.Name(),
+			Version:  l.version,
+			Hash:     l.hash,
+			HashType: artifact.HashSHA256,
+			Kind:     AssetType,
+		})
+	}
+	for i := range libs {
+		l := &libs[i]
+		parent := g.Node(l.Name(), l.version)
+		for _, r := range l.requires {
+			if child, ok := byName[r]; ok && child != l {
+				g.AddChild(parent, child.Name(), child.version, nil)
+			}
+		}
+		direct := !required[l.Name()]
+		if self != nil {
+			direct = false
+			for _, r := range self.requires {
+				if r == l.Name() {
+					direct = true
+				}
+			}
+		}
+		if direct {
+			g.AddChild(g.Root, l.Name(), l.version, nil)
+		}
+	}
+
+	return g.FlatDeps(), nil
+}
+
+// nestedArchives recursively collects libraries, embedded into the archive, and libraries shaded into them
+func nestedArchives(zr *zip.Reader, libs *[]embedded) error {
+	for _, f := range zr.File {
+		ext := strings.ToLower(path.Ext(f.Name))
+		if _, ok := archiveExts[ext]; !ok || f.FileInfo().IsDir() {
+			continue
+		}
+		buf, err := readZipFile(f)
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", f.Name, err)
+		}
+
+		nested, err := zip.NewReader(bytes.NewReader(buf), int64(len(buf)))
+		if err != nil {
+			continue // not a valid archive
+		}
+		if ext == ".jar" {
+			lib, shaded, err := mavenMeta(nested, path.Base(f.Name))
+			if err != nil {
+				return fmt.Errorf("cannot process %s: %w", f.Name, err)
+			}
+			if lib != nil {
+				hash := sha256.Sum256(buf)
+				lib.hash = hex.EncodeToString(hash[:])
+				*libs = append(*libs, *lib)
+			}
+			*libs = append(*libs, shaded...)
+		}
+		if err := nestedArchives(nested, libs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mavenMeta reads metadata of all artifacts in the archive from META-INF/maven/<groupId>/<artifactId>/pom.properties.
+// The artifact matching file name, or the only one, identifies the archive itself - if there is none, coordinates
+// are derived from the file name, and archive is left unidentified (nil) if this fails too. All other artifacts
+// were shaded into the archive - they have no file of their own, so they are hashed by their pom.properties, and
+// archive requires them
+func mavenMeta(zr *zip.Reader, filename string) (*embedded, []embedded, error) {
+	var libs []embedded
+	for _, f := range zr.File {
+		if !strings.HasPrefix(f.Name, mavenMetaDir) || path.Base(f.Name) != pomPropsFile ||
+			strings.Count(strings.TrimPrefix(f.Name, mavenMetaDir), "/") != 2 {
+			continue
+		}
+		buf, err := readZipFile(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		props, err := readProperties(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		lib := embedded{coordinates: coordinates{props["groupId"], props["artifactId"], props["version"]}}
+		if lib.artifactID == "" || lib.version == "" {
+			continue
+		}
+		hash := sha256.Sum256(buf)
+		lib.hash = hex.EncodeToString(hash[:])
+		if lib.requires, err = embeddedPomDeps(zr, lib.coordinates); err != nil {
+			return nil, nil, err
+		}
+		libs = append(libs, lib)
+	}
+
+	var self *embedded
+	var shaded []embedded
+	for i := range libs {
+		if len(libs) == 1 || filename == libs[i].artifactID+"-"+libs[i].version+path.Ext(filename) {
+			self = &libs[i]
+			shaded = append(append(shaded, libs[:i]...), libs[i+1:]...)
+			break
+		}
+	}
+	if self == nil {
+		shaded = libs
+		if match := archiveNamePattern.FindStringSubmatch(filename); match != nil {
+			self = &embedded{coordinates: coordinates{"", match[1], match[2]}}
+		}
+	}
+
+	if self != nil {
+		for _, l := range shaded {
+			self.requires = append(self.requires, l.Name())
+		}
+	}
+	return self, shaded, nil
+}
+
+// embeddedPomDeps returns names of runtime dependencies, listed in pom.xml of the artifact, embedded in the archive
+func embeddedPomDeps(zr *zip.Reader, c coordinates) ([]string, error) {
+	pomName := mavenMetaDir + c.groupID + "/" + c.artifactID + "/" + pomXML
+	for _, f := range zr.File {
+		if f.Name != pomName {
+			continue
+		}
+		buf, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		p, err := parsePom(buf)
+		if err != nil {
+			return nil, nil // ignore malformed POM
+		}
+		ep := &effectivePom{properties: map[string]string{
+			"project.groupId": c.groupID, "project.artifactId": c.artifactID, "project.version": c.version,
+		}}
+		for _, e := range p.Properties.Entries {
+			ep.properties[e.XMLName.Local] = strings.TrimSpace(e.Value)
+		}
+		var res []string
+		for _, d := range p.Dependencies {
+			if d = ep.interpolateDep(d); runtimeDep(d) {
+				res = append(res, d.GroupID+":"+d.ArtifactID)
+			}
+		}
+		return res, nil
+	}
+	return nil, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+// readProperties reads Java properties file with 'key=value' lines
+func readProperties(buf []byte) (map[string]string, error) {
+	res := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		fields := strings.SplitN(line, "=", 2)
+		if len(fields) == 2 {
+			res[strings.TrimSpace(fields[0])] = strings.TrimSpace(fields[1])
+		}
+	}
+	return res, scanner.Err()
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package java
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+const AssetType = "javacom"
+
+const (
+	pomXML       = "pom.xml"
+	gradleLock   = "gradle.lockfile"
+	pomPropsFile = "pom.properties"
+)
+
+// archive extensions, recognized as JVM assets
+var archiveExts = map[string]struct{}{".jar": {}, ".war": {}, ".ear": {}}
+
+// javaArtifact implements Artifact interface for Maven and Gradle projects and JVM archives
+type javaArtifact struct {
+	artifact.GenericArtifact
+	path    string
+	resolve func(string, artifact.OutputOptions) ([]artifact.Dependency, error)
+	source  string
+}
+
+// New returns new Artifact object, or nil if filename doesn't refer to JAR/WAR/EAR archive, pom.xml,
+// gradle.lockfile or directory containing one of those files
+func New(filename string) artifact.Artifact {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil
+	}
+
+	if fi.IsDir() {
+		if lock := filepath.Join(filename, gradleLock); exists(lock) {
+			return &javaArtifact{path: filename, source: lock, resolve: gradleDeps}
+		}
+		if pom := filepath.Join(filename, pomXML); exists(pom) {
+			return &javaArtifact{path: filename, source: pom, resolve: pomDeps}
+		}
+		return nil
+	}
+
+	switch base := filepath.Base(filename); {
+	case base == gradleLock:
+		return &javaArtifact{path: filename, source: filename, resolve: gradleDeps}
+	case base == pomXML:
+		return &javaArtifact{path: filename, source: filename, resolve: pomDeps}
+	}
+	if _, ok := archiveExts[strings.ToLower(filepath.Ext(filename))]; ok {
+		return &javaArtifact{path: filename, source: filename, resolve: archiveDeps}
+	}
+
+	return nil
+}
+
+func (a javaArtifact) Type() string {
+	return AssetType
+}
+
+func (a javaArtifact) Path() string {
+	return a.path
+}
+
+func (a *javaArtifact) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	if a.Deps != nil {
+		return a.Deps, nil
+	}
+
+	deps, err := a.resolve(a.source, output)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Deps = deps
+	return deps, nil
+}
+
+// coordinates identify Maven artifact
+type coordinates struct {
+	groupID    string
+	artifactID string
+	version    string
+}
+
+// Name returns dependency name in the form of '<groupId>:<artifactId>'
+func (c coordinates) Name() string {
+	return c.groupID + ":" + c.artifactID
+}
+
+// SplitName splits dependency name into group ID and artifact ID
+func SplitName(name string) (string, string) {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+func exists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+// fileSHA1 returns hex-encoded SHA-1 hash of the file, the same hash is used by Maven repositories
+func fileSHA1(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
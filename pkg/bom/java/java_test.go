@@ -0,0 +1,222 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package java
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+const testGradleLock = `# This is a Gradle generated file for dependency locking.
+# Manual edits can break the build and are not advised.
+# This file is expected to be part of source control.
+com.google.guava:guava:31.0.1-jre=compileClasspath,runtimeClasspath
+junit:junit:4.13.2=testCompileClasspath,testRuntimeClasspath
+org.slf4j:slf4j-api:1.7.32=runtimeClasspath,testRuntimeClasspath
+empty=annotationProcessor
+`
+
+func TestParseGradleLock(t *testing.T) {
+	coords, err := parseGradleLock(strings.NewReader(testGradleLock))
+	assert.NoError(t, err)
+	assert.Equal(t, []coordinates{
+		{"com.google.guava", "guava", "31.0.1-jre"},
+		{"org.slf4j", "slf4j-api", "1.7.32"},
+	}, coords)
+
+	_, err = parseGradleLock(strings.NewReader("guava=runtimeClasspath\n"))
+	assert.Error(t, err)
+}
+
+func zipFile(t *testing.T, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write(content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestArchiveDeps(t *testing.T) {
+	core := zipFile(t, map[string][]byte{
+		"META-INF/maven/org.example/core/pom.properties": []byte("groupId=org.example\nartifactId=core\nversion=1.0\n"),
+	})
+	// library with another one shaded into it
+	shadedProps := []byte("groupId=com.other\nartifactId=json\nversion=5.1\n")
+	web := zipFile(t, map[string][]byte{
+		"META-INF/maven/com.other/json/pom.properties":  shadedProps,
+		"META-INF/maven/org.example/web/pom.properties": []byte("groupId=org.example\nartifactId=web\nversion=2.0\n"),
+		"META-INF/maven/org.example/web/pom.xml": []byte(`<project>
+  <dependencies>
+    <dependency><groupId>${project.groupId}</groupId><artifactId>core</artifactId><version>1.0</version></dependency>
+    <dependency><groupId>junit</groupId><artifactId>junit</artifactId><version>4.13</version><scope>test</scope></dependency>
+  </dependencies>
+</project>`),
+	})
+	// library without Maven metadata is identified by the file name
+	plain := zipFile(t, map[string][]byte{"a/B.class": []byte("class")})
+	// application itself is not identified, but has libraries shaded into it
+	commonsProps := []byte("groupId=org.apache\nartifactId=commons\nversion=3.2\n")
+	gsonProps := []byte("groupId=com.google\nartifactId=gson\nversion=2.9\n")
+	fat := zipFile(t, map[string][]byte{
+		"META-INF/maven/org.apache/commons/pom.properties": commonsProps,
+		"META-INF/maven/com.google/gson/pom.properties":    gsonProps,
+		"BOOT-INF/lib/core-1.0.jar":                        core,
+		"BOOT-INF/lib/web-2.0.jar":                         web,
+		"BOOT-INF/lib/plain-0.3.jar":                       plain,
+		"BOOT-INF/lib/readme.txt":                          []byte("text"),
+	})
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.jar")
+	assert.NoError(t, os.WriteFile(filename, fat, 0644))
+
+	a := New(filename)
+	assert.NotNil(t, a)
+	assert.Equal(t, AssetType, a.Type())
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+
+	sum := func(b []byte) string {
+		h := sha256.Sum256(b)
+		return hex.EncodeToString(h[:])
+	}
+	expected := map[string]artifact.Dependency{
+		"org.example:core":   {Name: "org.example:core", Version: "1.0", Hash: sum(core), Type: artifact.DepTransient},
+		"org.example:web":    {Name: "org.example:web", Version: "2.0", Hash: sum(web), Type: artifact.DepDirect},
+		"com.other:json":     {Name: "com.other:json", Version: "5.1", Hash: sum(shadedProps), Type: artifact.DepTransient},
+		":plain":             {Name: ":plain", Version: "0.3", Hash: sum(plain), Type: artifact.DepDirect},
+		"org.apache:commons": {Name: "org.apache:commons", Version: "3.2", Hash: sum(commonsProps), Type: artifact.DepDirect},
+		"com.google:gson":    {Name: "com.google:gson", Version: "2.9", Hash: sum(gsonProps), Type: artifact.DepDirect},
+	}
+	assert.Len(t, deps, len(expected))
+	for _, d := range deps {
+		e, ok := expected[d.Name]
+		if !assert.True(t, ok, d.Name) {
+			continue
+		}
+		assert.Equal(t, e.Version, d.Version)
+		assert.Equal(t, e.Hash, d.Hash)
+		assert.Equal(t, artifact.HashSHA256, d.HashType)
+		assert.Equal(t, AssetType, d.Kind)
+		assert.Equal(t, e.Type, d.Type, d.Name)
+	}
+}
+
+func TestInstalledArchives(t *testing.T) {
+	core := zipFile(t, map[string][]byte{
+		"META-INF/maven/org.example/core/pom.properties": []byte("groupId=org.example\nartifactId=core\nversion=1.0\n"),
+	})
+	app := zipFile(t, map[string][]byte{
+		"META-INF/maven/org.example/app/pom.properties": []byte("groupId=org.example\nartifactId=app\nversion=3.0\n"),
+		"BOOT-INF/lib/core-1.0.jar":                     core,
+	})
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app", "app.jar"), app)
+	writeFile(t, filepath.Join(root, "usr", "share", "java", "core.jar"), core)
+	writeFile(t, filepath.Join(root, "opt", "tool", "broken.jar"), []byte("not an archive"))
+	e, err := executor.NewDirExecutor(root)
+	assert.NoError(t, err)
+
+	deps, err := InstalledArchives(e)
+	assert.NoError(t, err)
+	types := make(map[string]artifact.DepType)
+	for _, d := range deps {
+		types[d.Name+"@"+d.Version] = d.Type
+		assert.Equal(t, AssetType, d.Kind)
+	}
+	assert.Equal(t, map[string]artifact.DepType{
+		"org.example:app@3.0":  artifact.DepDirect,
+		"org.example:core@1.0": artifact.DepDirect, // embedded into the application and installed system-wide
+	}, types)
+	assert.Len(t, deps, 3)
+}
+
+func writeFile(t *testing.T, filename string, content []byte) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+	assert.NoError(t, os.WriteFile(filename, content, 0644))
+}
+
+func TestPomDeps(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	repo := filepath.Join(home, ".m2", "repository")
+
+	lib := []byte("lib jar")
+	util := []byte("util jar")
+	writeFile(t, filepath.Join(repo, "org/example/lib/1.2/lib-1.2.pom"), []byte(`<project>
+  <groupId>org.example</groupId><artifactId>lib</artifactId><version>1.2</version>
+  <dependencies>
+    <dependency><groupId>org.example</groupId><artifactId>util</artifactId><version>0.1</version></dependency>
+    <dependency><groupId>org.example</groupId><artifactId>excluded</artifactId><version>1.0</version></dependency>
+  </dependencies>
+</project>`))
+	writeFile(t, filepath.Join(repo, "org/example/lib/1.2/lib-1.2.jar"), lib)
+	writeFile(t, filepath.Join(repo, "org/example/util/0.2/util-0.2.pom"), []byte(`<project>
+  <groupId>org.example</groupId><artifactId>util</artifactId><version>0.2</version>
+</project>`))
+	writeFile(t, filepath.Join(repo, "org/example/util/0.2/util-0.2.jar"), util)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, pomXML), []byte(`<project>
+  <groupId>org.example</groupId><artifactId>app</artifactId><version>1.0</version>
+  <properties><lib.version>1.2</lib.version></properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency><groupId>org.example</groupId><artifactId>util</artifactId><version>0.2</version></dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>org.example</groupId><artifactId>lib</artifactId><version>${lib.version}</version>
+      <exclusions><exclusion><groupId>org.example</groupId><artifactId>excluded</artifactId></exclusion></exclusions>
+    </dependency>
+    <dependency><groupId>junit</groupId><artifactId>junit</artifactId><version>4.13</version><scope>test</scope></dependency>
+  </dependencies>
+</project>`))
+
+	a := New(dir)
+	assert.NotNil(t, a)
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+	assert.Len(t, deps, 2)
+	for _, d := range deps {
+		switch d.Name {
+		case "org.example:lib":
+			assert.Equal(t, "1.2", d.Version)
+			assert.Equal(t, artifact.DepDirect, d.Type)
+			h := sha1.Sum(lib)
+			assert.Equal(t, hex.EncodeToString(h[:]), d.Hash)
+		case "org.example:util":
+			// managed version takes precedence
+			assert.Equal(t, "0.2", d.Version)
+			assert.Equal(t, artifact.DepTransient, d.Type)
+			h := sha1.Sum(util)
+			assert.Equal(t, hex.EncodeToString(h[:]), d.Hash)
+		default:
+			t.Errorf("unexpected dependency %s", d.Name)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package java
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+// max depth of parent POM chain and property references, to avoid loops in malformed POMs
+const maxNesting = 16
+
+var propertyPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Type       string `xml:"type"`
+	Scope      string `xml:"scope"`
+	Optional   string `xml:"optional"`
+	Exclusions []struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+	} `xml:"exclusions>exclusion"`
+}
+
+type pomProperty struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type pomProject struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Parent     struct {
+		GroupID      string  `xml:"groupId"`
+		ArtifactID   string  `xml:"artifactId"`
+		Version      string  `xml:"version"`
+		RelativePath *string `xml:"relativePath"`
+	} `xml:"parent"`
+	Properties struct {
+		Entries []pomProperty `xml:",any"`
+	} `xml:"properties"`
+	DependencyManagement []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+	Dependencies         []pomDependency `xml:"dependencies>dependency"`
+}
+
+// effectivePom is a POM with inherited properties and managed dependency versions
+type effectivePom struct {
+	coordinates
+	properties   map[string]string
+	managed      map[string]string // '<groupId>:<artifactId>' -> version
+	dependencies []pomDependency
+}
+
+// mavenResolver resolves dependencies using POM files from the local Maven repository
+type mavenResolver struct {
+	repo  string
+	cache map[coordinates]*effectivePom
+}
+
+// mavenRepository returns location of the local Maven repository
+func mavenRepository() string {
+	home, err := homedir.Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".m2", "repository")
+}
+
+func newMavenResolver() *mavenResolver {
+	return &mavenResolver{repo: mavenRepository(), cache: make(map[coordinates]*effectivePom)}
+}
+
+// repoPath returns the location of artifact file with given extension in the local repository
+func (r *mavenResolver) repoPath(c coordinates, ext string) string {
+	return filepath.Join(r.repo, filepath.FromSlash(strings.ReplaceAll(c.groupID, ".", "/")),
+		c.artifactID, c.version, c.artifactID+"-"+c.version+"."+ext)
+}
+
+func parsePom(buf []byte) (*pomProject, error) {
+	var p pomProject
+	if err := xml.Unmarshal(buf, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// load reads POM and its parents, and builds effective POM. Parent is looked up at the relative path first,
+// if POM is a local file, and in the local repository otherwise
+func (r *mavenResolver) load(filename string, depth int) (*effectivePom, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	p, err := parsePom(buf)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+
+	res := &effectivePom{properties: make(map[string]string), managed: make(map[string]string)}
+	if p.Parent.ArtifactID != "" && depth < maxNesting {
+		parent := coordinates{p.Parent.GroupID, p.Parent.ArtifactID, p.Parent.Version}
+		var pp *effectivePom
+		relPath := "../pom.xml"
+		if p.Parent.RelativePath != nil {
+			relPath = *p.Parent.RelativePath
+		}
+		if relPath != "" && !strings.HasPrefix(filename, r.repo) {
+			local := filepath.Join(filepath.Dir(filename), filepath.FromSlash(relPath))
+			if fi, err := os.Stat(local); err == nil && fi.IsDir() {
+				local = filepath.Join(local, pomXML)
+			}
+			if candidate, err := r.load(local, depth+1); err == nil && candidate.coordinates == parent {
+				pp = candidate
+			}
+		}
+		if pp == nil {
+			pp, _ = r.loadRepo(parent, depth+1) // missing parent isn't fatal
+		}
+		if pp != nil {
+			for k, v := range pp.properties {
+				res.properties[k] = v
+			}
+			for k, v := range pp.managed {
+				res.managed[k] = v
+			}
+			res.dependencies = append(res.dependencies, pp.dependencies...)
+		}
+		res.groupID, res.version = parent.groupID, parent.version
+		res.properties["project.parent.groupId"] = parent.groupID
+		res.properties["project.parent.version"] = parent.version
+	}
+
+	if p.GroupID != "" {
+		res.groupID = p.GroupID
+	}
+	if p.Version != "" {
+		res.version = p.Version
+	}
+	res.artifactID = p.ArtifactID
+	for _, e := range p.Properties.Entries {
+		res.properties[e.XMLName.Local] = strings.TrimSpace(e.Value)
+	}
+	for _, k := range []string{"project", "pom"} {
+		res.properties[k+".groupId"] = res.groupID
+		res.properties[k+".artifactId"] = res.artifactID
+		res.properties[k+".version"] = res.version
+	}
+	res.groupID = res.interpolate(res.groupID)
+	res.version = res.interpolate(res.version)
+
+	for _, d := range p.DependencyManagement {
+		d = res.interpolateDep(d)
+		if d.Scope == "import" && d.Type == "pom" && depth < maxNesting {
+			// BOM import - merge its managed versions, without overriding the ones declared here
+			if bom, err := r.loadRepo(coordinates{d.GroupID, d.ArtifactID, d.Version}, depth+1); err == nil {
+				for k, v := range bom.managed {
+					if _, ok := res.managed[k]; !ok {
+						res.managed[k] = v
+					}
+				}
+			}
+			continue
+		}
+		res.managed[d.GroupID+":"+d.ArtifactID] = d.Version
+	}
+	for _, d := range p.Dependencies {
+		res.dependencies = append(res.dependencies, res.interpolateDep(d))
+	}
+
+	return res, nil
+}
+
+// loadRepo loads effective POM of the artifact from the local repository
+func (r *mavenResolver) loadRepo(c coordinates, depth int) (*effectivePom, error) {
+	if p, ok := r.cache[c]; ok {
+		return p, nil
+	}
+	p, err := r.load(r.repoPath(c, "pom"), depth)
+	if err != nil {
+		return nil, err
+	}
+	r.cache[c] = p
+	return p, nil
+}
+
+func (p *effectivePom) interpolate(s string) string {
+	for i := 0; i < maxNesting && strings.Contains(s, "${"); i++ {
+		s = propertyPattern.ReplaceAllStringFunc(s, func(ref string) string {
+			if v, ok := p.properties[ref[2:len(ref)-1]]; ok {
+				return v
+			}
+			return ref
+		})
+	}
+	return s
+}
+
+func (p *effectivePom) interpolateDep(d pomDependency) pomDependency {
+	d.GroupID = p.interpolate(strings.TrimSpace(d.GroupID))
+	d.ArtifactID = p.interpolate(strings.TrimSpace(d.ArtifactID))
+	d.Version = p.interpolate(strings.TrimSpace(d.Version))
+	d.Scope = strings.TrimSpace(d.Scope)
+	d.Type = strings.TrimSpace(d.Type)
+	return d
+}
+
+// runtimeDep returns true if dependency is needed at run time and is a library
+func runtimeDep(d pomDependency) bool {
+	return (d.Scope == "" || d.Scope == "compile" || d.Scope == "runtime") &&
+		strings.TrimSpace(d.Optional) != "true" &&
+		(d.Type == "" || d.Type == "jar" || d.Type == "bundle")
+}
+
+type pomQueueItem struct {
+	parent     *depgraph.GraphNode
+	coords     coordinates
+	exclusions map[string]struct{}
+}
+
+// pomDeps resolves dependencies of Maven project. Transitive dependencies are resolved using POMs from the
+// local Maven repository, with the nearest declaration winning in case of version conflicts, like Maven does.
+// Dependencies, missing in the local repository, are skipped - run 'mvn dependency:resolve' first
+func pomDeps(filename string, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	r := newMavenResolver()
+	root, err := r.load(filename, 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+
+	g := depgraph.NewGraph(root.Name(), root.version)
+	var queue []pomQueueItem
+	seen := make(map[string]string)
+	enqueue := func(parent *depgraph.GraphNode, p *effectivePom, exclusions map[string]struct{}) {
+		for _, d := range p.dependencies {
+			if !runtimeDep(d) {
+				continue
+			}
+			name := d.GroupID + ":" + d.ArtifactID
+			if _, ok := exclusions[name]; ok {
+				continue
+			}
+			if _, ok := exclusions[d.GroupID+":*"]; ok {
+				continue
+			}
+			// versions managed by the project take precedence over the ones declared by dependencies
+			version, ok := root.managed[name]
+			if !ok {
+				version = d.Version
+				if version == "" {
+					version = p.managed[name]
+				}
+			}
+			excl := make(map[string]struct{}, len(exclusions)+len(d.Exclusions))
+			for k := range exclusions {
+				excl[k] = struct{}{}
+			}
+			for _, e := range d.Exclusions {
+				excl[strings.TrimSpace(e.GroupID)+":"+strings.TrimSpace(e.ArtifactID)] = struct{}{}
+			}
+			queue = append(queue, pomQueueItem{parent, coordinates{d.GroupID, d.ArtifactID, version}, excl})
+		}
+	}
+	enqueue(g.Root, root, nil)
+
+	missing := 0
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+		name := item.coords.Name()
+		if version, ok := seen[name]; ok {
+			if version != "" {
+				g.AddChild(item.parent, name, version, nil)
+			}
+			continue
+		}
+		seen[name] = ""
+
+		hash, err := fileSHA1(r.repoPath(item.coords, "jar"))
+		if err != nil {
+			missing++
+			continue
+		}
+		seen[name] = item.coords.version
+		node := g.AddChild(item.parent, name, item.coords.version, &artifact.Dependency{
+			Name:     name,
+			Version:  item.coords.version,
+			Hash:     hash,
+			HashType: artifact.HashSHA1,
+			Kind:     AssetType,
+		})
+
+		p, err := r.loadRepo(item.coords, 0)
+		if err != nil {
+			continue // no POM - no transitive dependencies
+		}
+		enqueue(node, p, item.exclusions)
+	}
+	if missing > 0 {
+		artifact.Warnf(output, "%d dependencies are missing in local Maven repository and were skipped, run 'mvn dependency:resolve' first\n", missing)
+	}
+
+	return g.FlatDeps(), nil
+}
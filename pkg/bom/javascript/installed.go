@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package javascript
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+// hidden lock file, maintained by npm 7+ inside node_modules
+const hiddenLock = ".package-lock.json"
+
+// global module directories and common application locations in Node.js images
+var moduleDirGlobs = []string{"/usr/lib/node_modules", "/usr/local/lib/node_modules", "/app/node_modules",
+	"/usr/src/app/node_modules", "/home/node/app/node_modules", "/opt/*/node_modules", "/srv/*/node_modules",
+	"/var/www/*/node_modules"}
+
+// package directory inside node_modules tree, possibly scoped: 'node_modules/a/node_modules/@scope/b'
+var modulePattern = regexp.MustCompile(`(^|/)node_modules/(@[^/]+/)?[^/@.][^/]*$`)
+
+// installedManifest is a subset of package.json of installed package. npm 6 and older adds integrity and
+// other install metadata to the fields with '_' prefix
+type installedManifest struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Integrity            string            `json:"_integrity"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// InstalledPackages finds npm packages in global module directories and in common application locations,
+// accessible with executor
+func InstalledPackages(e executor.Executor, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	dirs, err := executor.Glob(e, moduleDirGlobs)
+	if err != nil {
+		// no shell - try locations without wildcards
+		dirs = dirs[:0]
+		for _, glob := range moduleDirGlobs {
+			if !strings.Contains(glob, "*") {
+				dirs = append(dirs, glob)
+			}
+		}
+	}
+
+	var res []artifact.Dependency
+	for _, dir := range dirs {
+		lock, err := installedModules(e, dir)
+		if err != nil {
+			return nil, fmt.Errorf("cannot process %s: %w", dir, err)
+		}
+		if lock == nil {
+			continue // no such directory
+		}
+		missing := 0
+		for _, p := range lock.packages {
+			if p.integrity == "" {
+				missing++
+			}
+		}
+		if missing > 0 {
+			artifact.Warnf(output, "%d packages in %s have no integrity and were skipped\n", missing, dir)
+		}
+		deps, err := lock.dependencies(dir)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, deps...)
+	}
+	return res, nil
+}
+
+// installedModules reads the hidden lock file of node_modules directory, or, if there is none, package.json
+// files of all installed packages. Returns nil if directory doesn't exist
+func installedModules(e executor.Executor, dir string) (*lockContent, error) {
+	if buf, err := e.ReadFile(path.Join(dir, hiddenLock)); err == nil {
+		return parseNpmLock(buf, nil)
+	}
+	reader, err := e.ReadDir(dir)
+	if err != nil {
+		return nil, nil
+	}
+	defer reader.Close()
+	return parseModuleTree(reader)
+}
+
+// parseModuleTree collects packages from node_modules tree in TAR stream, entries must be prefixed with
+// 'node_modules/'. Packages are identified by their location in the same way as in npm lock file
+func parseModuleTree(r io.Reader) (*lockContent, error) {
+	manifests := make(map[string]*installedManifest)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		location := path.Dir(hdr.Name)
+		if path.Base(hdr.Name) != packageJSON || !modulePattern.MatchString(location) ||
+			!hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		buf, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", hdr.Name, err)
+		}
+		var m installedManifest
+		if err := json.Unmarshal(buf, &m); err != nil {
+			continue // not a package manifest, f.e. test fixture
+		}
+		manifests[location] = &m
+	}
+
+	exists := func(location string) bool {
+		_, ok := manifests[location]
+		return ok
+	}
+	res := &lockContent{packages: make(map[string]*lockPackage, len(manifests))}
+	for location, m := range manifests {
+		name := m.Name
+		if name == "" {
+			name = location[strings.LastIndex(location, nodeModules)+len(nodeModules):]
+		}
+		pkg := &lockPackage{name: name, version: m.Version, integrity: m.Integrity}
+		for _, deps := range []map[string]string{m.Dependencies, m.OptionalDependencies} {
+			for _, dep := range sortedKeys(deps) {
+				if id := resolveNpm(exists, location, dep); id != "" {
+					pkg.deps = append(pkg.deps, id)
+				}
+			}
+		}
+		res.packages[location] = pkg
+	}
+	return res, nil
+}
@@ -0,0 +1,277 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package javascript
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+const AssetType = "nodecom"
+
+const (
+	packageLock = "package-lock.json"
+	shrinkwrap  = "npm-shrinkwrap.json"
+	yarnLock    = "yarn.lock"
+	pnpmLock    = "pnpm-lock.yaml"
+	packageJSON = "package.json"
+)
+
+// lock files in order of preference, if directory contains several of them
+var lockFiles = []string{shrinkwrap, packageLock, yarnLock, pnpmLock}
+
+// jsArtifact implements Artifact interface for JavaScript lock files
+type jsArtifact struct {
+	artifact.GenericArtifact
+	path     string
+	lockFile string
+}
+
+// lockPackage is a lock file entry, common for all package managers
+type lockPackage struct {
+	name      string
+	version   string
+	integrity string   // Subresource Integrity string, see https://www.w3.org/TR/SRI/
+	deps      []string // IDs of required packages
+	dev       bool
+}
+
+// lockContent is a lock file content, common for all package managers. Packages are identified by
+// package-manager specific IDs
+type lockContent struct {
+	packages map[string]*lockPackage
+	direct   []string // IDs of packages required by the project, nil if unknown
+}
+
+// New returns new Artifact object, or nil if filename doesn't refer to npm, yarn or pnpm lock file or
+// directory containing one
+func New(filename string) artifact.Artifact {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil
+	}
+
+	if !fi.IsDir() {
+		for _, name := range lockFiles {
+			if filepath.Base(filename) == name {
+				return &jsArtifact{path: filename, lockFile: filename}
+			}
+		}
+		return nil
+	}
+
+	for _, name := range lockFiles {
+		lockFile := filepath.Join(filename, name)
+		if _, err := os.Stat(lockFile); err == nil {
+			return &jsArtifact{path: filename, lockFile: lockFile}
+		}
+	}
+
+	return nil
+}
+
+func (a jsArtifact) Type() string {
+	return AssetType
+}
+
+func (a jsArtifact) Path() string {
+	return a.path
+}
+
+func (a *jsArtifact) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	if a.Deps != nil {
+		return a.Deps, nil
+	}
+
+	buf, err := ioutil.ReadFile(a.lockFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", a.lockFile, err)
+	}
+	manifest, err := readManifest(filepath.Join(filepath.Dir(a.lockFile), packageJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	var lock *lockContent
+	switch filepath.Base(a.lockFile) {
+	case yarnLock:
+		lock, err = parseYarnLock(buf, manifest)
+	case pnpmLock:
+		lock, err = parsePnpmLock(buf)
+	default:
+		lock, err = parseNpmLock(buf, manifest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", a.lockFile, err)
+	}
+
+	deps, err := lock.dependencies(a.lockFile)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Deps = deps
+	return deps, nil
+}
+
+// manifest is a subset of package.json content
+type manifest struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// readManifest reads package.json, returns nil if it doesn't exist
+func readManifest(filename string) (*manifest, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+	var m manifest
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+	return &m, nil
+}
+
+// prodDependencies returns names and version ranges of non-development dependencies in stable order
+func (m *manifest) prodDependencies() [][2]string {
+	res := make([][2]string, 0, len(m.Dependencies)+len(m.OptionalDependencies))
+	for _, deps := range []map[string]string{m.Dependencies, m.OptionalDependencies} {
+		for name, spec := range deps {
+			res = append(res, [2]string{name, spec})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i][0] < res[j][0] })
+	return res
+}
+
+// dependencies builds dependency graph from lock content. Only packages needed in production are included:
+// the ones reachable from the project non-development dependencies, if those are known, otherwise the ones
+// that aren't marked as development dependencies in lock file
+func (l *lockContent) dependencies(root string) ([]artifact.Dependency, error) {
+	included := make(map[string]bool, len(l.packages))
+	direct := l.direct
+	if direct == nil {
+		// project dependencies are unknown - all top-level packages are considered direct
+		required := make(map[string]bool, len(l.packages))
+		for _, p := range l.packages {
+			for _, id := range p.deps {
+				required[id] = true
+			}
+		}
+		for id, p := range l.packages {
+			if !p.dev {
+				included[id] = true
+				if !required[id] {
+					direct = append(direct, id)
+				}
+			}
+		}
+	} else {
+		queue := append([]string{}, direct...)
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			p, ok := l.packages[id]
+			if !ok || included[id] {
+				continue
+			}
+			included[id] = true
+			queue = append(queue, p.deps...)
+		}
+	}
+
+	g := depgraph.NewGraph(root, "")
+	for id := range included {
+		p := l.packages[id]
+		if p.integrity == "" {
+			continue // linked or local package
+		}
+		hash, hashType, err := sriHash(p.integrity)
+		if err != nil {
+			return nil, fmt.Errorf("package %s@%s: %w", p.name, p.version, err)
+		}
+		g.NewNode(p.name, p.version, &artifact.Dependency{
+			Name:     p.name,
+			Version:  p.version,
+			Hash:     hash,
+			HashType: hashType,
+			Kind:     AssetType,
+		})
+	}
+	for id := range included {
+		p := l.packages[id]
+		parent := g.Node(p.name, p.version)
+		for _, childID := range p.deps {
+			if child, ok := l.packages[childID]; ok && included[childID] {
+				g.AddChild(parent, child.name, child.version, nil)
+			}
+		}
+	}
+	for _, id := range direct {
+		if p, ok := l.packages[id]; ok && included[id] {
+			g.AddChild(g.Root, p.name, p.version, nil)
+		}
+	}
+
+	return g.FlatDeps(), nil
+}
+
+// sriHash converts Subresource Integrity string into hex-encoded hash. If SRI has several hashes, the strongest
+// one is used
+func sriHash(sri string) (string, artifact.HashType, error) {
+	var hash string
+	hashType := artifact.HashInvalid
+	for _, h := range strings.Fields(sri) {
+		fields := strings.SplitN(h, "-", 2)
+		if len(fields) != 2 {
+			return "", artifact.HashInvalid, fmt.Errorf("malformed integrity %s", sri)
+		}
+		t := artifact.HashTypeByName(strings.ToUpper(fields[0]))
+		if t == artifact.HashInvalid || t > artifact.HashSHA512 || t < hashType {
+			continue
+		}
+		// options, separated by '?', are reserved for future use
+		value, err := base64.StdEncoding.DecodeString(strings.SplitN(fields[1], "?", 2)[0])
+		if err != nil {
+			return "", artifact.HashInvalid, fmt.Errorf("malformed integrity %s", sri)
+		}
+		hash = hex.EncodeToString(value)
+		hashType = t
+	}
+	if hash == "" {
+		return "", artifact.HashInvalid, fmt.Errorf("unsupported integrity %s", sri)
+	}
+	return hash, hashType, nil
+}
+
+// SplitName splits package name into scope and name, scope is empty for non-scoped packages
+func SplitName(name string) (string, string) {
+	if strings.HasPrefix(name, "@") {
+		if i := strings.Index(name, "/"); i > 0 {
+			return name[:i], name[i+1:]
+		}
+	}
+	return "", name
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package javascript
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+const (
+	testSHA512 = "sha512-z4PhNX7vuL3xVChQ1m2AB9Yg5AULVxXcg/SpIdNs6c5H0NE8XYXysP+DGNKHfuwvY7kxvUdBeoGlODJ6+SfaPg=="
+	testSHA1   = "sha1-qvuvqQiYOfBvZkGc/JGVeWtrXzQ="
+)
+
+var testManifest = &manifest{Dependencies: map[string]string{"@babel/code-frame": "^7.12.13", "ms": "2.1.3"}}
+
+func depTypes(deps []artifact.Dependency) map[string]artifact.DepType {
+	res := make(map[string]artifact.DepType, len(deps))
+	for _, d := range deps {
+		res[d.Name+"@"+d.Version] = d.Type
+	}
+	return res
+}
+
+func TestNpmLockV2(t *testing.T) {
+	lock, err := parseNpmLock([]byte(`{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"dependencies": {"@babel/code-frame": "^7.12.13", "ms": "2.1.3"}, "devDependencies": {"is-number": "7.0.0"}},
+    "node_modules/@babel/code-frame": {"version": "7.12.13", "integrity": "`+testSHA512+`", "dependencies": {"ms": "^2.0.0"}},
+    "node_modules/@babel/code-frame/node_modules/ms": {"version": "2.0.0", "integrity": "`+testSHA1+`"},
+    "node_modules/ms": {"version": "2.1.3", "integrity": "`+testSHA512+`"},
+    "node_modules/is-number": {"version": "7.0.0", "integrity": "`+testSHA512+`", "dev": true}
+  }
+}`), nil)
+	assert.NoError(t, err)
+
+	deps, err := lock.dependencies("package-lock.json")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]artifact.DepType{
+		"@babel/code-frame@7.12.13": artifact.DepDirect,
+		"ms@2.1.3":                  artifact.DepDirect,
+		"ms@2.0.0":                  artifact.DepTransient,
+	}, depTypes(deps))
+
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Version < deps[j].Version })
+	assert.Equal(t, artifact.HashSHA1, deps[0].HashType)
+	assert.Equal(t, "aafbafa9089839f06f66419cfc9195796b6b5f34", deps[0].Hash)
+}
+
+func TestNpmLockV1(t *testing.T) {
+	lock, err := parseNpmLock([]byte(`{
+  "lockfileVersion": 1,
+  "dependencies": {
+    "@babel/code-frame": {"version": "7.12.13", "integrity": "`+testSHA512+`", "requires": {"ms": "^2.0.0"},
+      "dependencies": {"ms": {"version": "2.0.0", "integrity": "`+testSHA1+`"}}},
+    "ms": {"version": "2.1.3", "integrity": "`+testSHA512+`"},
+    "is-number": {"version": "7.0.0", "integrity": "`+testSHA512+`", "dev": true}
+  }
+}`), testManifest)
+	assert.NoError(t, err)
+
+	deps, err := lock.dependencies("package-lock.json")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]artifact.DepType{
+		"@babel/code-frame@7.12.13": artifact.DepDirect,
+		"ms@2.1.3":                  artifact.DepDirect,
+		"ms@2.0.0":                  artifact.DepTransient,
+	}, depTypes(deps))
+}
+
+func TestYarnLock(t *testing.T) {
+	lock, err := parseYarnLock([]byte(`# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@babel/code-frame@^7.12.13":
+  version "7.12.13"
+  resolved "https://registry.yarnpkg.com/@babel/code-frame/-/code-frame-7.12.13.tgz"
+  integrity `+testSHA512+`
+  dependencies:
+    ms "^2.0.0"
+
+ms@2.1.3, ms@^2.0.0:
+  version "2.1.3"
+  resolved "https://registry.yarnpkg.com/ms/-/ms-2.1.3.tgz#aafbafa9089839f06f66419cfc9195796b6b5f34"
+
+is-number@7.0.0:
+  version "7.0.0"
+  integrity `+testSHA512+`
+`), testManifest)
+	assert.NoError(t, err)
+
+	deps, err := lock.dependencies("yarn.lock")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]artifact.DepType{
+		"@babel/code-frame@7.12.13": artifact.DepDirect,
+		"ms@2.1.3":                  artifact.DepDirect,
+	}, depTypes(deps))
+
+	_, err = parseYarnLock([]byte("__metadata:\n  version: 6\n"), nil)
+	assert.Error(t, err)
+}
+
+func TestPnpmLock(t *testing.T) {
+	v6 := `lockfileVersion: '6.0'
+dependencies:
+  '@babel/code-frame':
+    specifier: ^7.12.13
+    version: 7.12.13(ms@2.1.3)
+devDependencies:
+  is-number:
+    specifier: 7.0.0
+    version: 7.0.0
+packages:
+  /@babel/code-frame@7.12.13(ms@2.1.3):
+    resolution: {integrity: ` + testSHA512 + `}
+    dependencies:
+      ms: 2.1.3
+  /ms@2.1.3:
+    resolution: {integrity: ` + testSHA1 + `}
+  /is-number@7.0.0:
+    resolution: {integrity: ` + testSHA512 + `}
+    dev: true
+`
+	v9 := `lockfileVersion: '9.0'
+importers:
+  .:
+    dependencies:
+      '@babel/code-frame':
+        specifier: ^7.12.13
+        version: 7.12.13(ms@2.1.3)
+packages:
+  '@babel/code-frame@7.12.13':
+    resolution: {integrity: ` + testSHA512 + `}
+  ms@2.1.3:
+    resolution: {integrity: ` + testSHA1 + `}
+snapshots:
+  '@babel/code-frame@7.12.13(ms@2.1.3)':
+    dependencies:
+      ms: 2.1.3
+  ms@2.1.3: {}
+`
+	for _, content := range []string{v6, v9} {
+		lock, err := parsePnpmLock([]byte(content))
+		assert.NoError(t, err)
+		deps, err := lock.dependencies("pnpm-lock.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]artifact.DepType{
+			"@babel/code-frame@7.12.13": artifact.DepDirect,
+			"ms@2.1.3":                  artifact.DepTransient,
+		}, depTypes(deps))
+	}
+
+	name, version, ref := parsePnpmKey("/@types/react-dom/17.0.2_@types+react@17.0.3", true)
+	assert.Equal(t, "@types/react-dom", name)
+	assert.Equal(t, "17.0.2", version)
+	assert.Equal(t, "17.0.2_@types+react@17.0.3", ref)
+}
+
+func TestSplitName(t *testing.T) {
+	scope, name := SplitName("@babel/code-frame")
+	assert.Equal(t, "@babel", scope)
+	assert.Equal(t, "code-frame", name)
+	scope, name = SplitName("ms")
+	assert.Empty(t, scope)
+	assert.Equal(t, "ms", name)
+}
+
+func TestInstalledPackages(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"usr/local/lib/node_modules/npm/package.json": `{"name": "npm", "version": "6.14.18", "_integrity": "` + testSHA512 +
+			`", "dependencies": {"ms": "^2.0.0", "@scope/abbrev": "1.1.1"}}`,
+		"usr/local/lib/node_modules/npm/node_modules/ms/package.json": `{"name": "ms", "version": "2.1.3", "_integrity": "` +
+			testSHA1 + `"}`,
+		"usr/local/lib/node_modules/npm/node_modules/@scope/abbrev/package.json": `{"version": "1.1.1", "_integrity": "` +
+			testSHA1 + `"}`,
+		"usr/local/lib/node_modules/npm/node_modules/ms/test/package.json": `{"name": "fixture", "version": "0.0.1"}`,
+		"usr/local/lib/node_modules/npm/node_modules/local/package.json":   `{"name": "local", "version": "1.0.0"}`,
+		"app/node_modules/.package-lock.json": `{"lockfileVersion": 3, "packages": {
+			"node_modules/ms": {"version": "2.1.2", "integrity": "` + testSHA512 + `"}}}`,
+	}
+	for name, content := range files {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	}
+	e, err := executor.NewDirExecutor(root)
+	assert.NoError(t, err)
+
+	deps, err := InstalledPackages(e, artifact.Silent)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]artifact.DepType{
+		"npm@6.14.18":         artifact.DepDirect,
+		"ms@2.1.3":            artifact.DepTransient,
+		"@scope/abbrev@1.1.1": artifact.DepTransient,
+		"ms@2.1.2":            artifact.DepDirect,
+	}, depTypes(deps))
+	for _, d := range deps {
+		assert.Equal(t, AssetType, d.Kind)
+	}
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package javascript
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+const nodeModules = "node_modules/"
+
+// npmPackageV1 is an entry of lockfile v1 'dependencies' section, where dependencies are nested
+type npmPackageV1 struct {
+	Version      string                   `json:"version"`
+	Integrity    string                   `json:"integrity"`
+	Dev          bool                     `json:"dev"`
+	Requires     map[string]string        `json:"requires"`
+	Dependencies map[string]*npmPackageV1 `json:"dependencies"`
+}
+
+// npmPackageV2 is an entry of lockfile v2/v3 'packages' section, keyed by package location
+type npmPackageV2 struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Integrity            string            `json:"integrity"`
+	Resolved             string            `json:"resolved"`
+	Link                 bool              `json:"link"`
+	Dev                  bool              `json:"dev"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+type npmLockContent struct {
+	LockfileVersion int                      `json:"lockfileVersion"`
+	Packages        map[string]*npmPackageV2 `json:"packages"`
+	Dependencies    map[string]*npmPackageV1 `json:"dependencies"`
+}
+
+// parseNpmLock parses package-lock.json or npm-shrinkwrap.json. Packages are identified by their location
+// in node_modules tree, see https://docs.npmjs.com/cli/configuring-npm/package-lock-json
+func parseNpmLock(buf []byte, m *manifest) (*lockContent, error) {
+	var lock npmLockContent
+	if err := json.Unmarshal(buf, &lock); err != nil {
+		return nil, err
+	}
+
+	if lock.Packages != nil {
+		return npmLockV2(&lock), nil
+	}
+	return npmLockV1(&lock, m), nil
+}
+
+func npmLockV2(lock *npmLockContent) *lockContent {
+	res := &lockContent{packages: make(map[string]*lockPackage, len(lock.Packages))}
+	exists := func(location string) bool {
+		_, ok := lock.Packages[location]
+		return ok
+	}
+	for location, p := range lock.Packages {
+		if location == "" {
+			continue // root project
+		}
+		name := p.Name
+		if name == "" {
+			name = location[strings.LastIndex(location, nodeModules)+len(nodeModules):]
+		}
+		pkg := &lockPackage{
+			name:      name,
+			version:   p.Version,
+			integrity: p.Integrity,
+			dev:       p.Dev,
+		}
+		if p.Link {
+			// workspace package - dependencies are listed in the link target entry
+			pkg.deps = []string{p.Resolved}
+		}
+		for _, deps := range []map[string]string{p.Dependencies, p.OptionalDependencies, p.PeerDependencies} {
+			for _, dep := range sortedKeys(deps) {
+				if id := resolveNpm(exists, location, dep); id != "" {
+					pkg.deps = append(pkg.deps, id)
+				}
+			}
+		}
+		res.packages[location] = pkg
+	}
+
+	if root, ok := lock.Packages[""]; ok {
+		res.direct = []string{}
+		for _, deps := range []map[string]string{root.Dependencies, root.OptionalDependencies} {
+			for _, dep := range sortedKeys(deps) {
+				if id := resolveNpm(exists, "", dep); id != "" {
+					res.direct = append(res.direct, id)
+				}
+			}
+		}
+	}
+
+	return res
+}
+
+func npmLockV1(lock *npmLockContent, m *manifest) *lockContent {
+	res := &lockContent{packages: make(map[string]*lockPackage)}
+	// flatten nested dependencies into location-keyed map, to resolve them in the same way as for v2
+	flat := make(map[string]*npmPackageV1)
+	var walk func(prefix string, deps map[string]*npmPackageV1)
+	walk = func(prefix string, deps map[string]*npmPackageV1) {
+		for name, p := range deps {
+			location := prefix + nodeModules + name
+			flat[location] = p
+			walk(location+"/", p.Dependencies)
+		}
+	}
+	walk("", lock.Dependencies)
+	exists := func(location string) bool {
+		_, ok := flat[location]
+		return ok
+	}
+
+	for location, p := range flat {
+		pkg := &lockPackage{
+			name:      location[strings.LastIndex(location, nodeModules)+len(nodeModules):],
+			version:   p.Version,
+			integrity: p.Integrity,
+			dev:       p.Dev,
+		}
+		for _, dep := range sortedKeys(p.Requires) {
+			if id := resolveNpm(exists, location, dep); id != "" {
+				pkg.deps = append(pkg.deps, id)
+			}
+		}
+		res.packages[location] = pkg
+	}
+
+	if m != nil {
+		res.direct = []string{}
+		for _, dep := range m.prodDependencies() {
+			if id := resolveNpm(exists, "", dep[0]); id != "" {
+				res.direct = append(res.direct, id)
+			}
+		}
+	}
+
+	return res
+}
+
+// resolveNpm finds the location of the package, required by the package at 'from' location, using Node.js
+// module resolution algorithm: closest node_modules directory up the tree wins. Returns empty string if not found
+func resolveNpm(exists func(string) bool, from, name string) string {
+	dir := from
+	for {
+		location := nodeModules + name
+		if dir != "" {
+			location = dir + "/" + location
+		}
+		if exists(location) {
+			return location
+		}
+		if dir == "" {
+			return ""
+		}
+		if i := strings.LastIndex(dir, "/"+nodeModules); i >= 0 {
+			dir = dir[:i]
+		} else {
+			dir = ""
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
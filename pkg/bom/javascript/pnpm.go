@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package javascript
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type pnpmPackage struct {
+	Resolution struct {
+		Integrity string `yaml:"integrity"`
+	} `yaml:"resolution"`
+	Dev                  bool              `yaml:"dev"`
+	Dependencies         map[string]string `yaml:"dependencies"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies"`
+}
+
+// pnpmImporter is a project in the workspace. Dependency values are versions in lockfile v5 and
+// {specifier, version} maps in v6+
+type pnpmImporter struct {
+	Dependencies         map[string]yaml.Node `yaml:"dependencies"`
+	OptionalDependencies map[string]yaml.Node `yaml:"optionalDependencies"`
+}
+
+type pnpmLockContent struct {
+	LockfileVersion string `yaml:"lockfileVersion"`
+	pnpmImporter    `yaml:",inline"`
+	Importers       map[string]pnpmImporter `yaml:"importers"`
+	Packages        map[string]*pnpmPackage `yaml:"packages"`
+	Snapshots       map[string]*pnpmPackage `yaml:"snapshots"` // lockfile v9+
+}
+
+// parsePnpmLock parses pnpm-lock.yaml v5 to v9. Packages are identified by their keys, in the form of
+// '/<name>/<version>[_<peers>]' (v5), '/<name>@<version>[(<peers>)]' (v6) or '<name>@<version>[(<peers>)]' (v9)
+func parsePnpmLock(buf []byte) (*lockContent, error) {
+	var lock pnpmLockContent
+	if err := yaml.Unmarshal(buf, &lock); err != nil {
+		return nil, err
+	}
+
+	// since v9, 'packages' only has resolutions, and dependencies are listed in 'snapshots'
+	graph := lock.Packages
+	if lock.Snapshots != nil {
+		graph = lock.Snapshots
+	}
+
+	v5 := strings.HasPrefix(lock.LockfileVersion, "5")
+	// map '<name>@<version with peers suffix>' to the package ID
+	ids := make(map[string]string, len(graph))
+	res := &lockContent{packages: make(map[string]*lockPackage, len(graph))}
+	for key, p := range graph {
+		name, version, ref := parsePnpmKey(key, v5)
+		if name == "" {
+			continue
+		}
+		ids[name+"@"+ref] = key
+		pkg := &lockPackage{name: name, version: version, dev: p.Dev, integrity: p.Resolution.Integrity}
+		if lock.Snapshots != nil {
+			if r, ok := lock.Packages[name+"@"+version]; ok {
+				pkg.integrity = r.Resolution.Integrity
+			}
+		}
+		res.packages[key] = pkg
+	}
+
+	for key, p := range graph {
+		pkg, ok := res.packages[key]
+		if !ok {
+			continue
+		}
+		for _, deps := range []map[string]string{p.Dependencies, p.OptionalDependencies} {
+			for _, name := range sortedKeys(deps) {
+				if id, ok := ids[name+"@"+deps[name]]; ok {
+					pkg.deps = append(pkg.deps, id)
+				}
+			}
+		}
+	}
+
+	importers := lock.Importers
+	if importers == nil {
+		importers = map[string]pnpmImporter{".": lock.pnpmImporter}
+	}
+	res.direct = []string{}
+	for _, imp := range importers {
+		for _, deps := range []map[string]yaml.Node{imp.Dependencies, imp.OptionalDependencies} {
+			for name, node := range deps {
+				ref := node.Value
+				if node.Kind == yaml.MappingNode {
+					var v struct {
+						Version string `yaml:"version"`
+					}
+					if err := node.Decode(&v); err != nil {
+						return nil, err
+					}
+					ref = v.Version
+				}
+				if id, ok := ids[name+"@"+ref]; ok {
+					res.direct = append(res.direct, id)
+				}
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// parsePnpmKey returns package name, version, and version with peer dependencies suffix
+func parsePnpmKey(key string, v5 bool) (string, string, string) {
+	key = strings.TrimPrefix(key, "/")
+	separator, suffix := "@", "("
+	if v5 {
+		separator, suffix = "/", "_"
+	}
+
+	i := strings.LastIndex(strings.SplitN(key, suffix, 2)[0], separator)
+	if i <= 0 {
+		return "", "", ""
+	}
+	name, ref := key[:i], key[i+1:]
+	return name, strings.SplitN(ref, suffix, 2)[0], ref
+}
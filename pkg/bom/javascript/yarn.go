@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package javascript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// parseYarnLock parses yarn.lock v1. Packages are identified by the first specifier ('<name>@<range>')
+// in the entry header. Yarn 2+ lock files (YAML) aren't supported
+func parseYarnLock(buf []byte, m *manifest) (*lockContent, error) {
+	res := &lockContent{packages: make(map[string]*lockPackage)}
+	specs := make(map[string]string)     // specifier -> entry ID
+	pending := make(map[string][]string) // entry ID -> dependency specifiers
+	var cur *lockPackage
+	var curID, block string
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		switch {
+		case indent == 0:
+			if strings.HasPrefix(line, "__metadata:") {
+				return nil, errors.New("yarn 2+ lock files are not supported")
+			}
+			// entry header: "<spec>", "<spec>":
+			curID = ""
+			for _, spec := range strings.Split(strings.TrimSuffix(trimmed, ":"), ",") {
+				spec = yarnUnquote(strings.TrimSpace(spec))
+				if curID == "" {
+					curID = spec
+				}
+				specs[spec] = curID
+			}
+			cur = &lockPackage{name: yarnSpecName(curID)}
+			res.packages[curID] = cur
+			block = ""
+		case cur == nil:
+			continue
+		case indent == 2:
+			fields := strings.SplitN(trimmed, " ", 2)
+			if len(fields) == 1 {
+				block = strings.TrimSuffix(fields[0], ":")
+				continue
+			}
+			block = ""
+			value := yarnUnquote(strings.TrimSpace(fields[1]))
+			switch fields[0] {
+			case "version":
+				cur.version = value
+			case "integrity":
+				cur.integrity = value
+			case "resolved":
+				if cur.integrity == "" {
+					cur.integrity = resolvedSHA1(value)
+				}
+			}
+		default:
+			if block != "dependencies" && block != "optionalDependencies" {
+				continue
+			}
+			fields := strings.SplitN(trimmed, " ", 2)
+			if len(fields) != 2 {
+				continue
+			}
+			spec := yarnUnquote(fields[0]) + "@" + yarnUnquote(strings.TrimSpace(fields[1]))
+			pending[curID] = append(pending[curID], spec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for id, deps := range pending {
+		for _, spec := range deps {
+			if depID, ok := specs[spec]; ok {
+				res.packages[id].deps = append(res.packages[id].deps, depID)
+			}
+		}
+	}
+
+	if m != nil {
+		res.direct = []string{}
+		for _, dep := range m.prodDependencies() {
+			if id, ok := specs[dep[0]+"@"+dep[1]]; ok {
+				res.direct = append(res.direct, id)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func yarnUnquote(s string) string {
+	if strings.HasPrefix(s, "\"") {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	return s
+}
+
+// yarnSpecName returns package name from '<name>@<range>' specifier, name may be scoped
+func yarnSpecName(spec string) string {
+	if i := strings.Index(spec[1:], "@"); i >= 0 {
+		return spec[:i+1]
+	}
+	return spec
+}
+
+// resolvedSHA1 converts SHA-1 hash in the resolved URL fragment into SRI form, old yarn versions
+// don't record integrity
+func resolvedSHA1(url string) string {
+	i := strings.LastIndex(url, "#")
+	if i < 0 {
+		return ""
+	}
+	hash, err := hex.DecodeString(url[i+1:])
+	if err != nil || len(hash) != 20 {
+		return ""
+	}
+	return "sha1-" + base64.StdEncoding.EncodeToString(hash)
+}
@@ -0,0 +1,193 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+// Package license normalizes and evaluates licenses of BOM dependencies
+package license
+
+import (
+	"fmt"
+	"strings"
+)
+
+// operators of SPDX license expression
+const (
+	OpAnd  = "AND"
+	OpOr   = "OR"
+	opWith = "WITH"
+)
+
+// Expression is a node of parsed SPDX license expression, see
+// https://spdx.github.io/spdx-spec/v2.3/SPDX-license-expressions/
+type Expression struct {
+	Op        string        // OpAnd or OpOr for compound expression, empty for single license
+	Args      []*Expression // operands of compound expression
+	License   string        // license ID, optionally with '+' suffix
+	Exception string        // license exception ID, added by WITH operator
+}
+
+// String returns license or license with exception for single license, or the whole expression
+func (e *Expression) String() string {
+	if e.Op == "" {
+		if e.Exception != "" {
+			return e.License + " " + opWith + " " + e.Exception
+		}
+		return e.License
+	}
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = arg.String()
+		if arg.Op != "" {
+			args[i] = "(" + args[i] + ")"
+		}
+	}
+	return strings.Join(args, " "+e.Op+" ")
+}
+
+// Licenses returns all single licenses of the expression, in order of their appearance
+func (e *Expression) Licenses() []*Expression {
+	if e.Op == "" {
+		return []*Expression{e}
+	}
+	var res []*Expression
+	for _, arg := range e.Args {
+		res = append(res, arg.Licenses()...)
+	}
+	return res
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+// Parse parses SPDX license expression. Operators are case-insensitive, as many package managers use
+// lower-case ones
+func Parse(s string) (*Expression, error) {
+	p := parser{tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s' in license expression '%s'", p.tokens[p.pos], s)
+	}
+	return e, nil
+}
+
+func tokenize(s string) []string {
+	s = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s)
+	return strings.Fields(s)
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) isOperator(op string) bool {
+	return strings.EqualFold(p.peek(), op)
+}
+
+func (p *parser) parseOr() (*Expression, error) {
+	return p.parseCompound(OpOr, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (*Expression, error) {
+	return p.parseCompound(OpAnd, p.parseWith)
+}
+
+// parseCompound parses operands, joined by the operator. Nested expressions with the same operator are
+// flattened, so 'A AND (B AND C)' has three operands, and repeated operands are omitted
+func (p *parser) parseCompound(op string, operand func() (*Expression, error)) (*Expression, error) {
+	var args []*Expression
+	seen := make(map[string]bool)
+	for {
+		arg, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		nested := []*Expression{arg}
+		if arg.Op == op {
+			nested = arg.Args
+		}
+		for _, e := range nested {
+			if text := e.String(); !seen[text] {
+				args = append(args, e)
+				seen[text] = true
+			}
+		}
+		if !p.isOperator(op) {
+			break
+		}
+		p.pos++
+	}
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	return &Expression{Op: op, Args: args}, nil
+}
+
+func (p *parser) parseWith() (*Expression, error) {
+	e, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator(opWith) {
+		return e, nil
+	}
+	if e.Op != "" || e.Exception != "" {
+		return nil, fmt.Errorf("exception must follow single license")
+	}
+	p.pos++
+	exception := p.peek()
+	if !isIdentifier(exception) {
+		return nil, fmt.Errorf("missing license exception after %s", opWith)
+	}
+	p.pos++
+	return &Expression{License: e.License, Exception: exception}, nil
+}
+
+func (p *parser) parsePrimary() (*Expression, error) {
+	token := p.peek()
+	switch {
+	case token == "(":
+		p.pos++
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis in license expression")
+		}
+		p.pos++
+		return e, nil
+	case isIdentifier(token):
+		p.pos++
+		return &Expression{License: token}, nil
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of license expression")
+	}
+	return nil, fmt.Errorf("unexpected '%s' in license expression", token)
+}
+
+func isIdentifier(token string) bool {
+	if token == "" || token == "(" || token == ")" {
+		return false
+	}
+	for _, op := range []string{OpAnd, OpOr, opWith} {
+		if strings.EqualFold(token, op) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package license
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for expr, expected := range map[string]string{
+		"MIT":                                "MIT",
+		" ( MIT ) ":                          "MIT",
+		"MIT or Apache-2.0":                  "MIT OR Apache-2.0",
+		"MIT AND (BSD-3-Clause OR GPL-2.0+)": "MIT AND (BSD-3-Clause OR GPL-2.0+)",
+		"A OR B AND C":                       "A OR (B AND C)",
+		"(A OR B) OR (C OR D)":               "A OR B OR C OR D",
+		"A AND B AND (A AND C)":              "A AND B AND C",
+		"GPL-2.0-only WITH Classpath-exception-2.0 OR MIT": "GPL-2.0-only WITH Classpath-exception-2.0 OR MIT",
+	} {
+		e, err := Parse(expr)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, expected, e.String(), expr)
+	}
+
+	e, err := Parse("MIT AND (ISC OR BSD-2-Clause)")
+	assert.NoError(t, err)
+	var licenses []string
+	for _, l := range e.Licenses() {
+		licenses = append(licenses, l.License)
+	}
+	assert.Equal(t, []string{"MIT", "ISC", "BSD-2-Clause"}, licenses)
+
+	for _, expr := range []string{"", "MIT AND", "(MIT", "MIT)", "MIT Apache-2.0", "OR MIT", "(A OR B) WITH X", "A WITH"} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p := &Policy{
+		Allow: []string{"MIT", "Apache-2.0", "BSD-*", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		Deny:  []string{"GPL-*", "AGPL-*"},
+		Exceptions: []Exception{
+			{Name: "bash", Licenses: []string{"GPL-3.0-or-later"}},
+			{Name: "@internal/*"},
+		},
+	}
+	for license, reason := range map[string]string{
+		"MIT":                      "",
+		"mit":                      "",
+		"BSD-3-Clause":             "",
+		"MIT OR GPL-3.0-only":      "",
+		"MIT AND GPL-3.0-only":     "license GPL-3.0-only is denied",
+		"GPL-2.0-only OR LGPL-2.1": "license GPL-2.0-only is denied; license LGPL-2.1 is not allowed",
+		"GPL-2.0-only WITH Classpath-exception-2.0": "",
+		"GPL-2.0-only WITH Autoconf-exception-2.0":  "license GPL-2.0-only WITH Autoconf-exception-2.0 is denied",
+		"NOASSERTION":         "license is unknown",
+		"":                    "license is unknown",
+		"Some custom license": "license Some custom license is not allowed",
+	} {
+		assert.Equal(t, reason, p.Check("pkg", license), license)
+	}
+
+	assert.Equal(t, "", p.Check("bash", "GPL-3.0-or-later"))
+	assert.Equal(t, "license GPL-2.0-only is denied", p.Check("bash", "GPL-2.0-only"))
+	assert.Equal(t, "", p.Check("@internal/lib", "Proprietary"))
+	assert.Equal(t, "", p.Check("@internal/lib", ""))
+
+	// deny list takes precedence over wildcard in allow list
+	p = &Policy{Allow: []string{"GPL-*", "MIT"}, Deny: []string{"GPL-3.0-only"}}
+	assert.Equal(t, "license GPL-3.0-only is denied", p.Check("pkg", "GPL-3.0-only"))
+	assert.Equal(t, "license GPL-3.0-only WITH GCC-exception-3.1 is denied", p.Check("pkg", "GPL-3.0-only WITH GCC-exception-3.1"))
+	assert.Equal(t, "", p.Check("pkg", "GPL-2.0-only"))
+	assert.Equal(t, "license GPL-3.0-only is denied", p.Check("pkg", "MIT AND GPL-3.0-only"))
+
+	p = &Policy{Deny: []string{"AGPL-3.0*"}, AllowUnknown: true}
+	assert.Equal(t, "", p.Check("pkg", "Some custom license"))
+	assert.Equal(t, "", p.Check("pkg", "NONE"))
+	assert.Equal(t, "license AGPL-3.0-only is denied", p.Check("pkg", "AGPL-3.0-only"))
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "policy.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte(`
+allow: [MIT, Apache-2.0]
+deny:
+  - GPL-*
+allow-unknown: true
+exceptions:
+  - name: bash
+    licenses: [GPL-3.0-or-later]
+  - name: internal
+`), 0644))
+	p, err := LoadPolicy(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, &Policy{
+		Allow:        []string{"MIT", "Apache-2.0"},
+		Deny:         []string{"GPL-*"},
+		AllowUnknown: true,
+		Exceptions:   []Exception{{Name: "bash", Licenses: []string{"GPL-3.0-or-later"}}, {Name: "internal"}},
+	}, p)
+
+	assert.NoError(t, os.WriteFile(filename, []byte("allowed: [MIT]\n"), 0644))
+	_, err = LoadPolicy(filename)
+	assert.Error(t, err)
+
+	_, err = LoadPolicy(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestNormalize(t *testing.T) {
+	for declared, expected := range map[string]string{
+		"":                                     "",
+		"NONE":                                 "NONE",
+		"noassertion":                          "NOASSERTION",
+		"MIT":                                  "MIT",
+		"mit":                                  "MIT",
+		"Apache-2.0":                           "Apache-2.0",
+		"ASL 2.0":                              "Apache-2.0",
+		"GPL-2":                                "GPL-2.0-only",
+		"GPL-2+":                               "GPL-2.0-or-later",
+		"GPL-2.0+":                             "GPL-2.0-or-later",
+		"GPLv2 or later":                       "GPL-2.0-or-later",
+		"LGPL-2.1":                             "LGPL-2.1-only",
+		"GPL+ or Artistic":                     "GPL-1.0-or-later OR Artistic-1.0-Perl",
+		"GPLv2+ and LGPLv2+":                   "GPL-2.0-or-later AND LGPL-2.0-or-later",
+		"GPLv3+ and (BSD or MIT)":              "GPL-3.0-or-later AND (LicenseRef-BSD OR MIT)",
+		"MIT BSD-3-Clause GPL2+":               "MIT AND BSD-3-Clause AND GPL-2.0-or-later",
+		"Apache-2.0+":                          "Apache-2.0+",
+		"public-domain":                        "LicenseRef-public-domain",
+		"Expat":                                "MIT",
+		"LicenseRef-custom":                    "LicenseRef-custom",
+		"GPL-2+ or Artistic, and BSD-3-clause": "(GPL-2.0-or-later OR Artistic-1.0-Perl) AND BSD-3-Clause",
+		"GPL-3+ with Bison exception":          "GPL-3.0-or-later WITH Bison-exception-2.2",
+		"GPL-2+ with OpenSSL exception":        "LicenseRef-GPL-2-with-OpenSSL-exception",
+		"Some proprietary license":             "LicenseRef-Some-proprietary-license",
+		"GPL-2.0-only WITH Classpath-exception-2.0 OR MIT": "GPL-2.0-only WITH Classpath-exception-2.0 OR MIT",
+	} {
+		assert.Equal(t, expected, Normalize(declared), declared)
+	}
+}
+
+func TestNormalizeSeveral(t *testing.T) {
+	assert.Equal(t, "GPL-2.0-or-later AND (MIT OR Apache-2.0) AND LicenseRef-public-domain",
+		Normalize("GPL-2+", "MIT or Apache-2.0", "GPL-2.0+", "NONE", "public-domain"))
+	assert.Equal(t, "", Normalize("", "NOASSERTION"))
+	assert.Equal(t, "", Normalize())
+}
@@ -0,0 +1,280 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package license
+
+import (
+	"regexp"
+	"strings"
+)
+
+// LicenseRefPrefix starts IDs of licenses, which are not in SPDX license list
+const LicenseRefPrefix = "LicenseRef-"
+
+// commonly used IDs from SPDX license list, see https://spdx.org/licenses/
+var spdxIDs = []string{
+	"0BSD", "AFL-2.1", "AFL-3.0", "AGPL-1.0-only", "AGPL-1.0-or-later", "AGPL-3.0-only", "AGPL-3.0-or-later",
+	"Apache-1.0", "Apache-1.1", "Apache-2.0", "APSL-2.0", "Artistic-1.0", "Artistic-1.0-Perl", "Artistic-2.0",
+	"Beerware", "BitTorrent-1.1", "BlueOak-1.0.0", "BSD-1-Clause", "BSD-2-Clause", "BSD-2-Clause-Patent",
+	"BSD-3-Clause", "BSD-3-Clause-Clear", "BSD-4-Clause", "BSD-4-Clause-UC", "BSD-Source-Code", "BSL-1.0",
+	"bzip2-1.0.6", "CC-BY-3.0", "CC-BY-4.0", "CC-BY-SA-3.0", "CC-BY-SA-4.0", "CC0-1.0", "CDDL-1.0", "CDDL-1.1",
+	"CECILL-2.1", "CPL-1.0", "curl", "ECL-2.0", "EFL-2.0", "EPL-1.0", "EPL-2.0", "EUPL-1.1", "EUPL-1.2",
+	"FSFAP", "FSFUL", "FSFULLR", "FTL", "GFDL-1.1-only", "GFDL-1.1-or-later", "GFDL-1.2-only",
+	"GFDL-1.2-or-later", "GFDL-1.3-only", "GFDL-1.3-or-later", "GPL-1.0-only", "GPL-1.0-or-later",
+	"GPL-2.0-only", "GPL-2.0-or-later", "GPL-3.0-only", "GPL-3.0-or-later", "HPND", "ICU", "IJG", "Imlib2",
+	"IPA", "ISC", "LGPL-2.0-only", "LGPL-2.0-or-later", "LGPL-2.1-only", "LGPL-2.1-or-later", "LGPL-3.0-only",
+	"LGPL-3.0-or-later", "Libpng", "libpng-2.0", "libtiff", "LPPL-1.3c", "MIT", "MIT-0", "MIT-CMU",
+	"MPL-1.0", "MPL-1.1", "MPL-2.0", "MPL-2.0-no-copyleft-exception", "MS-PL", "MS-RL", "NCSA", "Ruby",
+	"OFL-1.0", "OFL-1.1", "OLDAP-2.8", "OpenSSL", "PHP-3.0", "PHP-3.01", "PostgreSQL", "PSF-2.0",
+	"Python-2.0", "Qhull", "Sendmail", "SGI-B-2.0", "Sleepycat", "SMLNJ", "TCL", "Unicode-DFS-2015",
+	"Unicode-DFS-2016", "Unlicense", "UPL-1.0", "Vim", "W3C", "WTFPL", "X11", "XFree86-1.1", "Zlib",
+	"zlib-acknowledgement", "ZPL-2.0", "ZPL-2.1",
+}
+
+// commonly used IDs from SPDX license exception list, see https://spdx.org/licenses/exceptions-index.html
+var spdxExceptionIDs = []string{
+	"Autoconf-exception-2.0", "Autoconf-exception-3.0", "Bison-exception-2.2", "Classpath-exception-2.0",
+	"Font-exception-2.0", "GCC-exception-2.0", "GCC-exception-3.1", "Libtool-exception", "Linux-syscall-note",
+	"LLVM-exception", "OCaml-LGPL-linking-exception", "openvpn-openssl-exception", "Qt-GPL-exception-1.0",
+	"Qt-LGPL-exception-1.1", "u-boot-exception-2.0",
+}
+
+// aliases map license names, used by Debian, Alpine and RPM-based distributions, to SPDX IDs. Keys are
+// normalized by licenseKey
+var aliases = map[string]string{
+	"gpl+":              "GPL-1.0-or-later",
+	"lgpl+":             "LGPL-2.0-or-later",
+	"asl1.1":            "Apache-1.1",
+	"asl2":              "Apache-2.0",
+	"apachelicense2":    "Apache-2.0",
+	"artistic":          "Artistic-1.0-Perl",
+	"artisticclarified": "Artistic-1.0-Perl",
+	"bsd2":              "BSD-2-Clause",
+	"bsd3":              "BSD-3-Clause",
+	"bsd4":              "BSD-4-Clause",
+	"boost":             "BSL-1.0",
+	"cc0":               "CC0-1.0",
+	"expat":             "MIT",
+	"mitlicense":        "MIT",
+	"isclicense":        "ISC",
+	"psf":               "PSF-2.0",
+	"python":            "Python-2.0",
+	"ofl":               "OFL-1.1",
+	"publicdomain":      LicenseRefPrefix + "public-domain",
+	"zlib/libpng":       "Zlib",
+}
+
+// exceptionAliases map names of license exceptions, used by distributions, to SPDX IDs
+var exceptionAliases = map[string]string{
+	"autoconfexception":  "Autoconf-exception-3.0",
+	"bisonexception":     "Bison-exception-2.2",
+	"classpathexception": "Classpath-exception-2.0",
+	"fontexception":      "Font-exception-2.0",
+	"gccexception":       "GCC-exception-3.1",
+	"libtoolexception":   "Libtool-exception",
+}
+
+var (
+	licensesByKey   = indexByKey(spdxIDs, aliases)
+	exceptionsByKey = indexByKey(spdxExceptionIDs, exceptionAliases)
+
+	keyVersionPrefix = regexp.MustCompile(`([a-z])v(\d)`)
+	keyZeroMinor     = regexp.MustCompile(`(\d)\.0+(\D|$)`)
+	orLaterPhrase    = regexp.MustCompile(`(?i)\s+or\s+(any\s+)?later(\s+version)?\b`)
+	licenseRefChars  = regexp.MustCompile(`[^A-Za-z0-9.]+`)
+)
+
+func indexByKey(ids []string, aliases map[string]string) map[string]string {
+	res := make(map[string]string, len(ids)+len(aliases))
+	for _, id := range ids {
+		res[licenseKey(id)] = id
+	}
+	for key, id := range aliases {
+		res[key] = id
+	}
+	return res
+}
+
+// licenseKey makes variants of license name comparable: 'GPLv2+', 'GPL-2+' and 'GPL-2.0+' have the same key
+func licenseKey(name string) string {
+	key := strings.ToLower(name)
+	key = strings.NewReplacer(" ", "", "-", "", "_", "", "version", "").Replace(key)
+	key = keyVersionPrefix.ReplaceAllString(key, "$1$2")
+	key = keyZeroMinor.ReplaceAllString(key, "$1$2")
+	return key
+}
+
+// Normalize converts license names and free-text license expressions, used by distributions, like 'GPL-2+',
+// 'GPLv2+ and LGPLv2+' or DEP-5 'GPL-2+ or Artistic, and BSD-3-clause', to valid SPDX license expression.
+// Licenses, which are not in SPDX license list, become 'LicenseRef-<name>'. Several declared licenses, f.e.
+// of different files of the package, are all applied. Empty, NONE and NOASSERTION values are kept as is,
+// unless there are other licenses
+func Normalize(declared ...string) string {
+	if len(declared) == 1 {
+		return normalize(declared[0])
+	}
+	var args []string
+	seen := make(map[string]bool, len(declared))
+	for _, d := range declared {
+		n := normalize(d)
+		if !unknownLicenses[n] && !seen[n] {
+			args = append(args, "("+n+")")
+			seen[n] = true
+		}
+	}
+	if len(args) == 0 {
+		return ""
+	}
+	e, err := Parse(strings.Join(args, " "+OpAnd+" "))
+	if err != nil {
+		return "" // never happens, normalized expressions are valid
+	}
+	return e.String()
+}
+
+func normalize(declared string) string {
+	declared = strings.TrimSpace(declared)
+	if unknownLicenses[strings.ToUpper(declared)] {
+		return strings.ToUpper(declared)
+	}
+	declared = orLaterPhrase.ReplaceAllString(declared, "+")
+
+	// in DEP-5, comma has lower precedence than other operators
+	var expr string
+	for i, part := range strings.Split(declared, ",") {
+		op := OpAnd
+		fields := strings.Fields(part)
+		if len(fields) > 0 && (strings.EqualFold(fields[0], OpAnd) || strings.EqualFold(fields[0], OpOr)) {
+			op = strings.ToUpper(fields[0])
+			part = strings.Join(fields[1:], " ")
+		}
+		normalized, ok := normalizeTokens(part)
+		if !ok {
+			return licenseRef(declared)
+		}
+		if i == 0 {
+			expr = "(" + normalized + ")"
+		} else {
+			expr = "(" + expr + " " + op + " (" + normalized + "))"
+		}
+	}
+
+	e, err := Parse(expr)
+	if err != nil {
+		return licenseRef(declared)
+	}
+	return e.String()
+}
+
+// normalizeTokens replaces license names of expression without commas with SPDX IDs, keeping operators and
+// parentheses. Names may consist of several words, like 'ASL 2.0'. Unknown license exception can't be
+// referenced, so such expression can't be normalized
+func normalizeTokens(s string) (string, bool) {
+	s = strings.NewReplacer("(", " ( ", ")", " ) ", "&", " and ", "|", " or ").Replace(s)
+	var res, words []string
+	exception, ok := false, true
+	flush := func() {
+		if len(words) == 0 {
+			return
+		}
+		if exception {
+			id, known := normalizeException(strings.Join(words, " "))
+			res = append(res, id)
+			ok = ok && known
+		} else {
+			res = append(res, normalizeLicense(words))
+		}
+		words, exception = nil, false
+	}
+	for _, token := range strings.Fields(s) {
+		switch {
+		case token == "(" || token == ")" || strings.EqualFold(token, OpAnd) || strings.EqualFold(token, OpOr):
+			flush()
+			res = append(res, strings.ToUpper(token))
+		case strings.EqualFold(token, opWith):
+			flush()
+			res = append(res, opWith)
+			exception = true
+		default:
+			words = append(words, token)
+		}
+	}
+	flush()
+	return strings.Join(res, " "), ok
+}
+
+// normalizeLicense returns SPDX ID of the license, named by words. If the whole name isn't known, but all
+// of the words are, like in Alpine 'MIT BSD GPL2+', they are treated as list of licenses, all of them applied
+func normalizeLicense(words []string) string {
+	if id, ok := lookupLicense(strings.Join(words, " ")); ok {
+		return id
+	}
+	if len(words) > 1 {
+		ids := make([]string, len(words))
+		for i, word := range words {
+			id, ok := lookupLicense(word)
+			if !ok {
+				return licenseRef(strings.Join(words, " "))
+			}
+			ids[i] = id
+		}
+		return "(" + strings.Join(ids, " "+OpAnd+" ") + ")"
+	}
+	return licenseRef(words[0])
+}
+
+func lookupLicense(name string) (string, bool) {
+	if strings.HasPrefix(strings.ToLower(name), strings.ToLower(LicenseRefPrefix)) ||
+		strings.HasPrefix(strings.ToLower(name), "documentref-") {
+		return name, true
+	}
+	key := licenseKey(name)
+	if id, ok := licensesByKey[key]; ok {
+		return id, true
+	}
+	// GNU licenses without suffix, like Debian 'GPL-2', mean the exact version
+	if id, ok := licensesByKey[key+"only"]; ok {
+		return id, true
+	}
+	if !strings.HasSuffix(key, "+") {
+		return "", false
+	}
+	id, ok := lookupLicense(strings.TrimSuffix(name, "+"))
+	if !ok || strings.HasPrefix(id, LicenseRefPrefix) {
+		return "", false
+	}
+	if strings.HasSuffix(id, "-only") {
+		return strings.TrimSuffix(id, "-only") + "-or-later", true
+	}
+	if strings.HasSuffix(id, "-or-later") {
+		return id, true
+	}
+	return id + "+", true
+}
+
+// normalizeException returns SPDX ID of license exception
+func normalizeException(name string) (string, bool) {
+	key := licenseKey(name)
+	if id, ok := exceptionsByKey[key]; ok {
+		return id, true
+	}
+	if id, ok := exceptionsByKey[key+"exception"]; ok {
+		return id, true
+	}
+	id, ok := exceptionsByKey[strings.TrimSuffix(key, "exception")]
+	return id, ok
+}
+
+// licenseRef returns the ID of license, which is not in SPDX license list
+func licenseRef(name string) string {
+	id := strings.Trim(licenseRefChars.ReplaceAllString(name, "-"), "-.")
+	if id == "" {
+		return ""
+	}
+	return LicenseRefPrefix + id
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package license
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// licenses, meaning that the license of package is not known
+var unknownLicenses = map[string]bool{"": true, "NOASSERTION": true, "NONE": true}
+
+// Policy defines which licenses are acceptable. Licenses in lists may contain shell wildcards,
+// like 'GPL-*', and are matched case-insensitively
+type Policy struct {
+	Allow        []string    `yaml:"allow"`         // if not empty, only these licenses are allowed
+	Deny         []string    `yaml:"deny"`          // licenses, which are never allowed
+	AllowUnknown bool        `yaml:"allow-unknown"` // accept packages without license
+	Exceptions   []Exception `yaml:"exceptions"`
+}
+
+// Exception allows additional licenses for packages with given name, or any license, if list is empty
+type Exception struct {
+	Name     string   `yaml:"name"`
+	Licenses []string `yaml:"licenses"`
+}
+
+// LoadPolicy reads license policy from YAML file
+func LoadPolicy(filename string) (*Policy, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	dec := yaml.NewDecoder(bytes.NewReader(buf))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("cannot parse license policy %s: %w", filename, err)
+	}
+	return &p, nil
+}
+
+// Check evaluates license expression of the package against the policy, and returns the reason of violation,
+// or empty string if license is compliant. Expression with AND requires all licenses to be acceptable, with OR -
+// at least one of them. Malformed expression is evaluated as single license
+func (p *Policy) Check(name, license string) string {
+	license = strings.TrimSpace(license)
+	var exception *Exception
+	for i := range p.Exceptions {
+		if matches(p.Exceptions[i].Name, name) {
+			exception = &p.Exceptions[i]
+			if len(exception.Licenses) == 0 {
+				return ""
+			}
+			break
+		}
+	}
+
+	if unknownLicenses[strings.ToUpper(license)] {
+		if p.AllowUnknown {
+			return ""
+		}
+		return "license is unknown"
+	}
+
+	e, err := Parse(license)
+	if err != nil {
+		e = &Expression{License: license}
+	}
+	return p.check(e, exception)
+}
+
+func (p *Policy) check(e *Expression, exception *Exception) string {
+	var reasons []string
+	for _, arg := range e.Args {
+		reason := p.check(arg, exception)
+		if reason == "" && e.Op == OpOr {
+			return ""
+		}
+		if reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	if e.Op != "" {
+		return strings.Join(reasons, "; ")
+	}
+
+	text := e.String()
+	if exception != nil && matchesAny(exception.Licenses, text, e.License) {
+		return ""
+	}
+	// license with exception, allowed explicitly, overrides denied license without exception
+	if e.Exception != "" && matchesAny(withExceptions(p.Allow), text) {
+		return ""
+	}
+	// deny list takes precedence over allow list
+	if matchesAny(p.Deny, text, e.License) {
+		return fmt.Sprintf("license %s is denied", text)
+	}
+	if len(p.Allow) == 0 || matchesAny(p.Allow, text, e.License) {
+		return ""
+	}
+	return fmt.Sprintf("license %s is not allowed", text)
+}
+
+// withExceptions returns patterns of licenses with exception, like 'GPL-2.0-only WITH Classpath-exception-2.0'
+func withExceptions(patterns []string) []string {
+	var res []string
+	for _, pattern := range patterns {
+		if strings.Contains(strings.ToUpper(pattern), " "+opWith+" ") {
+			res = append(res, pattern)
+		}
+	}
+	return res
+}
+
+// matchesAny checks if any of the values matches any of patterns
+func matchesAny(patterns []string, values ...string) bool {
+	for _, pattern := range patterns {
+		for _, value := range values {
+			if matches(pattern, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matches(pattern, value string) bool {
+	pattern, value = strings.ToLower(pattern), strings.ToLower(value)
+	if pattern == value {
+		return true
+	}
+	ok, err := path.Match(pattern, value)
+	return err == nil && ok
+}
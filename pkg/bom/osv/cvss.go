@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package osv
+
+import (
+	"math"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+// weights of CVSS v3 base metrics, see https://www.first.org/cvss/v3.1/specification-document
+var cvssWeights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// privileges required have higher weights, if scope is changed
+var cvssScopeChangedPR = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+
+// cvss3Score calculates base score from CVSS v3 vector, like 'CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H'
+func cvss3Score(vector string) (float64, bool) {
+	fields := strings.Split(vector, "/")
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "CVSS:3") {
+		return 0, false
+	}
+	metrics := make(map[string]string, len(fields)-1)
+	for _, f := range fields[1:] {
+		kv := strings.SplitN(f, ":", 2)
+		if len(kv) == 2 {
+			metrics[kv[0]] = kv[1]
+		}
+	}
+	changed := metrics["S"] == "C"
+	if !changed && metrics["S"] != "U" {
+		return 0, false
+	}
+	w := make(map[string]float64, len(cvssWeights))
+	for metric, weights := range cvssWeights {
+		weight, ok := weights[metrics[metric]]
+		if !ok {
+			return 0, false
+		}
+		w[metric] = weight
+	}
+	if changed {
+		w["PR"] = cvssScopeChangedPR[metrics["PR"]]
+	}
+
+	iss := 1 - (1-w["C"])*(1-w["I"])*(1-w["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+	exploitability := 8.22 * w["AV"] * w["AC"] * w["PR"] * w["UI"]
+	score := impact + exploitability
+	if changed {
+		score *= 1.08
+	}
+	return roundUp(math.Min(score, 10)), true
+}
+
+// roundUp returns the smallest number with one decimal place, equal or higher than the input,
+// avoiding floating point errors as CVSS v3.1 specifies
+func roundUp(f float64) float64 {
+	i := int(math.Round(f * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
+
+// cvssSeverity returns qualitative severity rating of CVSS score
+func cvssSeverity(score float64) artifact.Severity {
+	switch {
+	case score >= 9:
+		return artifact.SeverityCritical
+	case score >= 7:
+		return artifact.SeverityHigh
+	case score >= 4:
+		return artifact.SeverityMedium
+	case score > 0:
+		return artifact.SeverityLow
+	}
+	return artifact.SeverityUnknown
+}
@@ -0,0 +1,399 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+// Package osv matches BOM dependencies against vulnerabilities from locally mirrored OSV database,
+// see https://ossf.github.io/osv-schema/
+package osv
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	purl "github.com/package-url/packageurl-go"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+// purl types with ecosystem-specific version comparison
+const (
+	typeDeb  = "deb"
+	typeApk  = "apk"
+	typeRPM  = "rpm"
+	typePyPI = "pypi"
+)
+
+// ecosystem identifies packages of OSV ecosystem by purl type and, for OS distributions, by purl namespace
+type ecosystem struct {
+	purlType  string
+	namespace string
+}
+
+// ecosystems maps supported OSV ecosystems to purls
+var ecosystems = map[string]ecosystem{
+	"Debian":      {typeDeb, "debian"},
+	"Ubuntu":      {typeDeb, "ubuntu"},
+	"Alpine":      {typeApk, "alpine"},
+	"Red Hat":     {typeRPM, "redhat"},
+	"Rocky Linux": {typeRPM, "rocky"},
+	"AlmaLinux":   {typeRPM, "almalinux"},
+	"openSUSE":    {typeRPM, "opensuse"},
+	"SUSE":        {typeRPM, "suse"},
+	"Mageia":      {typeRPM, "mageia"},
+	"PyPI":        {typePyPI, ""},
+	"npm":         {"npm", ""},
+	"Go":          {"golang", ""},
+	"Maven":       {"maven", ""},
+	"NuGet":       {"nuget", ""},
+	"crates.io":   {"cargo", ""},
+	"RubyGems":    {"gem", ""},
+	"Packagist":   {"composer", ""},
+	"Pub":         {"pub", ""},
+	"Hex":         {"hex", ""},
+}
+
+// OS packages are matched by name only, because purl namespace identifies distribution
+var osTypes = map[string]bool{typeDeb: true, typeApk: true, typeRPM: true}
+
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// distribution release version in ecosystem suffix, like '12', 'v3.18', 'Leap 15.5' or 'enterprise_linux:9::appstream'
+var releaseVersion = regexp.MustCompile(`v?\d+(\.\d+)*`)
+
+type entry struct {
+	ID               string                 `json:"id"`
+	Withdrawn        string                 `json:"withdrawn"`
+	Aliases          []string               `json:"aliases"`
+	Summary          string                 `json:"summary"`
+	Details          string                 `json:"details"`
+	Severity         []severity             `json:"severity"`
+	Affected         []affected             `json:"affected"`
+	DatabaseSpecific map[string]interface{} `json:"database_specific"`
+}
+
+type severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type affected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Severity          []severity             `json:"severity"`
+	Ranges            []versionRange         `json:"ranges"`
+	Versions          []string               `json:"versions"`
+	EcosystemSpecific map[string]interface{} `json:"ecosystem_specific"`
+	DatabaseSpecific  map[string]interface{} `json:"database_specific"`
+
+	namespace string // distribution, empty for language ecosystems
+	release   string // distribution release, f.e. '12' for 'Debian:12', empty if unknown
+	compare   compareFunc
+	vuln      *artifact.Vulnerability
+}
+
+type versionRange struct {
+	Type   string  `json:"type"`
+	Events []event `json:"events"`
+}
+
+type event struct {
+	Introduced   string `json:"introduced"`
+	Fixed        string `json:"fixed"`
+	LastAffected string `json:"last_affected"`
+	Limit        string `json:"limit"`
+}
+
+func (e event) version() string {
+	switch {
+	case e.Introduced != "":
+		return e.Introduced
+	case e.Fixed != "":
+		return e.Fixed
+	case e.LastAffected != "":
+		return e.LastAffected
+	}
+	return e.Limit
+}
+
+// Database is OSV database, indexed by package
+type Database struct {
+	index map[string][]*affected
+}
+
+// Load reads OSV database from the JSON file with single OSV entry, from the ZIP archive with such files,
+// like https://osv-vulnerabilities.storage.googleapis.com/<ecosystem>/all.zip, or from directory tree,
+// containing them. Withdrawn entries and packages of unsupported ecosystems are ignored
+func Load(path string) (*Database, error) {
+	db := &Database{index: make(map[string][]*affected)}
+	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			buf, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return db.add(path, buf)
+		case ".zip":
+			return db.addArchive(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *Database) addArchive(path string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if !strings.EqualFold(filepath.Ext(f.Name), ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		buf, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if err := db.add(path+"/"+f.Name, buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *Database) add(name string, buf []byte) error {
+	var e entry
+	if err := json.Unmarshal(buf, &e); err != nil {
+		return fmt.Errorf("cannot parse OSV entry %s: %w", name, err)
+	}
+	if e.ID == "" || e.Withdrawn != "" {
+		return nil
+	}
+
+	vuln := &artifact.Vulnerability{ID: e.ID, Aliases: e.Aliases, Summary: e.Summary}
+	if vuln.Summary == "" {
+		vuln.Summary = strings.SplitN(strings.TrimSpace(e.Details), "\n", 2)[0]
+	}
+	rate(vuln, e.Severity, e.DatabaseSpecific)
+
+	for i := range e.Affected {
+		a := &e.Affected[i]
+		ecosystemName, release := a.Package.Ecosystem, ""
+		if j := strings.IndexByte(ecosystemName, ':'); j >= 0 {
+			ecosystemName, release = ecosystemName[:j], ecosystemName[j+1:]
+		}
+		eco, ok := ecosystems[ecosystemName]
+		if !ok || a.Package.Name == "" {
+			continue
+		}
+		a.namespace, a.release = eco.namespace, releaseVersion.FindString(release)
+		a.compare = comparator(eco.purlType)
+
+		// package-specific severity, if any, overrides the one of vulnerability
+		a.vuln = vuln
+		specific := &artifact.Vulnerability{}
+		rate(specific, a.Severity, a.EcosystemSpecific, a.DatabaseSpecific)
+		if specific.Severity != artifact.SeverityUnknown {
+			v := *vuln
+			v.Severity, v.Score, v.Vector = specific.Severity, specific.Score, specific.Vector
+			a.vuln = &v
+		}
+
+		key := packageKey(eco.purlType, strings.Replace(a.Package.Name, ":", "/", 1))
+		db.index[key] = append(db.index[key], a)
+	}
+	return nil
+}
+
+// rate sets the severity of vulnerability from the highest CVSS v3 score or, if there is none,
+// from qualitative severity rating
+func rate(v *artifact.Vulnerability, severities []severity, specific ...map[string]interface{}) {
+	for _, s := range severities {
+		if s.Type != "CVSS_V3" {
+			continue
+		}
+		if score, ok := cvss3Score(s.Score); ok && score >= v.Score {
+			v.Score, v.Vector, v.Severity = score, s.Score, cvssSeverity(score)
+		}
+	}
+	if v.Severity != artifact.SeverityUnknown {
+		return
+	}
+	for _, s := range severities {
+		if severity, ok := artifact.SeverityByName(s.Score); ok && s.Type == "Ubuntu" {
+			v.Severity = severity
+			return
+		}
+	}
+	for _, m := range specific {
+		if text, ok := m["severity"].(string); ok {
+			if severity, ok := artifact.SeverityByName(text); ok {
+				v.Severity = severity
+				return
+			}
+		}
+	}
+}
+
+// packageKey identifies package by purl type and name, including namespace for language ecosystems
+func packageKey(purlType, name string) string {
+	if purlType == typePyPI {
+		name = pypiSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	}
+	return purlType + ":" + name
+}
+
+// Match returns vulnerabilities, affecting the package with given purl, sorted by ID. OS packages are also
+// matched by the name of the source package, they are built from, if it is known. If purl has 'distro'
+// qualifier, only vulnerabilities of this distribution release are matched
+func (db *Database) Match(packageURL, source string) []artifact.Vulnerability {
+	p, err := purl.FromString(packageURL)
+	if err != nil || p.Version == "" {
+		return nil
+	}
+	var candidates []*affected
+	if osTypes[p.Type] {
+		candidates = db.index[packageKey(p.Type, p.Name)]
+		if source != "" && source != p.Name {
+			candidates = append(candidates[:len(candidates):len(candidates)], db.index[packageKey(p.Type, source)]...)
+		}
+	} else {
+		name := p.Name
+		if p.Namespace != "" {
+			name = p.Namespace + "/" + name
+		}
+		candidates = db.index[packageKey(p.Type, name)]
+	}
+	var distro string
+	for _, q := range p.Qualifiers {
+		if q.Key == "distro" {
+			distro = strings.ToLower(q.Value)
+		}
+	}
+
+	var res []artifact.Vulnerability
+	seen := make(map[string]bool)
+	for _, a := range candidates {
+		if seen[a.vuln.ID] || !a.matchesDistro(p.Namespace, distro) {
+			continue
+		}
+		if fixed, ok := a.affects(p.Version); ok {
+			v := *a.vuln
+			v.Fixed = fixed
+			res = append(res, v)
+			seen[v.ID] = true
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+// matchesDistro checks if affected package belongs to the distribution, identified by purl namespace
+// and 'distro' qualifier like 'debian-12' or 'alpine-3.18.4'. Unknown distribution matches any
+func (a *affected) matchesDistro(namespace, distro string) bool {
+	if namespace != "" && a.namespace != "" && namespace != a.namespace {
+		return false
+	}
+	if distro == "" || a.release == "" {
+		return true
+	}
+	release := strings.TrimPrefix(strings.ToLower(a.release), "v")
+	for _, part := range strings.Split(distro, "-") {
+		part = strings.TrimPrefix(part, "v")
+		if part == release || strings.HasPrefix(part, release+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// affects checks if the version of the package is affected, and returns the version with fix, if known
+func (a *affected) affects(version string) (string, bool) {
+	affected := false
+	for _, v := range a.Versions {
+		if v == version {
+			affected = true
+			break
+		}
+	}
+	for _, r := range a.Ranges {
+		compare := a.compare
+		switch r.Type {
+		case "SEMVER":
+			compare = compareSemver
+		case "ECOSYSTEM":
+		default:
+			continue // commit ranges cannot be evaluated without repository
+		}
+		if fixed, ok := r.affects(version, compare); ok {
+			return fixed, true
+		}
+	}
+	return "", affected
+}
+
+// affects evaluates range events in version order, as described by
+// https://ossf.github.io/osv-schema/#evaluation
+func (r versionRange) affects(version string, compare compareFunc) (string, bool) {
+	events := make([]event, 0, len(r.Events))
+	for _, e := range r.Events {
+		if e.Limit == "" {
+			events = append(events, e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Introduced == "0" || events[j].Introduced == "0" {
+			return events[j].Introduced != "0"
+		}
+		return compare(events[i].version(), events[j].version()) < 0
+	})
+
+	affected, fixed := false, ""
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || compare(version, e.Introduced) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if compare(version, e.Fixed) >= 0 {
+				affected = false
+			} else if affected && fixed == "" {
+				fixed = e.Fixed
+			}
+		case e.LastAffected != "":
+			if compare(version, e.LastAffected) > 0 {
+				affected = false
+			}
+		}
+	}
+	if !affected {
+		return "", false
+	}
+	return fixed, true
+}
@@ -0,0 +1,182 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package osv
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+var testEntries = map[string]string{
+	"GHSA-35jh-r3h4-6jhm.json": `{
+  "id": "GHSA-35jh-r3h4-6jhm",
+  "summary": "Command Injection in lodash",
+  "aliases": ["CVE-2021-23337"],
+  "severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H"}],
+  "affected": [{
+    "package": {"ecosystem": "npm", "name": "lodash"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]
+  }, {
+    "package": {"ecosystem": "npm", "name": "@types/lodash"},
+    "versions": ["4.14.170"]
+  }],
+  "database_specific": {"severity": "HIGH"}
+}`,
+	"PYSEC-2021-1.json": `{
+  "id": "PYSEC-2021-1",
+  "details": "Django before 3.1.6 allows path traversal.\nMore details.",
+  "affected": [{
+    "package": {"ecosystem": "PyPI", "name": "Django"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [
+      {"introduced": "3.1"}, {"fixed": "3.1.6"}, {"introduced": "2.2"}, {"fixed": "2.2.18"}
+    ]}]
+  }]
+}`,
+	"withdrawn.json": `{"id": "GHSA-withdrawn", "withdrawn": "2022-01-01T00:00:00Z",
+  "affected": [{"package": {"ecosystem": "npm", "name": "lodash"}, "versions": ["4.17.20"]}]}`,
+	"not-osv.json": `{"name": "some other file"}`,
+}
+
+var testArchive = map[string]string{
+	"DSA-5417-1.json": `{
+  "id": "DSA-5417-1",
+  "summary": "openssl - security update",
+  "aliases": ["CVE-2023-0464"],
+  "affected": [{
+    "package": {"ecosystem": "Debian:11", "name": "openssl"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1.1.1n-0+deb11u5"}]}]
+  }, {
+    "package": {"ecosystem": "Debian:12", "name": "openssl"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "3.0.9-1"}]}],
+    "ecosystem_specific": {"urgency": "medium"},
+    "database_specific": {"severity": "critical"}
+  }]
+}`,
+	"RHSA-2023:1.json": `{
+  "id": "RHSA-2023:1",
+  "affected": [{
+    "package": {"ecosystem": "Red Hat:enterprise_linux:9::appstream", "name": "bash"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "0:5.1.8-9.el9"}]}]
+  }]
+}`,
+	"ALPINE-CVE-2023-1.json": `{
+  "id": "ALPINE-CVE-2023-1",
+  "affected": [{
+    "package": {"ecosystem": "Alpine:v3.18", "name": "busybox"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"last_affected": "1.36.1-r2"}]}]
+  }]
+}`,
+}
+
+func writeArchive(t *testing.T, filename string, files map[string]string) {
+	f, err := os.Create(filename)
+	assert.NoError(t, err)
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		assert.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+}
+
+func TestMatch(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "npm"), 0755))
+	for name, content := range testEntries {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "npm", name), []byte(content), 0644))
+	}
+	writeArchive(t, filepath.Join(dir, "all.zip"), testArchive)
+
+	db, err := Load(dir)
+	assert.NoError(t, err)
+
+	lodash := artifact.Vulnerability{
+		ID:       "GHSA-35jh-r3h4-6jhm",
+		Aliases:  []string{"CVE-2021-23337"},
+		Summary:  "Command Injection in lodash",
+		Severity: artifact.SeverityHigh,
+		Score:    7.2,
+		Vector:   "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H",
+		Fixed:    "4.17.21",
+	}
+	assert.Equal(t, []artifact.Vulnerability{lodash}, db.Match("pkg:npm/lodash@4.17.20", ""))
+	assert.Empty(t, db.Match("pkg:npm/lodash@4.17.21", ""))
+	assert.Empty(t, db.Match("pkg:npm/lodash", ""))
+	lodash.Fixed = ""
+	assert.Equal(t, []artifact.Vulnerability{lodash}, db.Match("pkg:npm/%40types/lodash@4.14.170", ""))
+	assert.Empty(t, db.Match("pkg:npm/%40types/lodash@4.14.171", ""))
+
+	django := artifact.Vulnerability{ID: "PYSEC-2021-1", Summary: "Django before 3.1.6 allows path traversal.", Fixed: "2.2.18"}
+	assert.Equal(t, []artifact.Vulnerability{django}, db.Match("pkg:pypi/django@2.2.17", ""))
+	assert.Empty(t, db.Match("pkg:pypi/django@3.0", ""))
+	django.Fixed = "3.1.6"
+	assert.Equal(t, []artifact.Vulnerability{django}, db.Match("pkg:pypi/django@3.1.6rc1", ""))
+
+	openssl := artifact.Vulnerability{
+		ID:       "DSA-5417-1",
+		Aliases:  []string{"CVE-2023-0464"},
+		Summary:  "openssl - security update",
+		Severity: artifact.SeverityCritical,
+		Fixed:    "3.0.9-1",
+	}
+	// binary package is matched by source package, any Debian release matches, unless distro is known
+	assert.Equal(t, []artifact.Vulnerability{openssl}, db.Match("pkg:deb/libssl3@3.0.8-1", "openssl"))
+	assert.Empty(t, db.Match("pkg:deb/libssl3@3.0.8-1", ""))
+	assert.Empty(t, db.Match("pkg:deb/debian/libssl3@3.0.8-1?distro=debian-11", "openssl"))
+	assert.Equal(t, []artifact.Vulnerability{openssl}, db.Match("pkg:deb/debian/openssl@3.0.8-1?distro=debian-12", ""))
+	assert.Empty(t, db.Match("pkg:deb/ubuntu/openssl@3.0.8-1", ""))
+
+	bash := []artifact.Vulnerability{{ID: "RHSA-2023:1", Fixed: "0:5.1.8-9.el9"}}
+	assert.Equal(t, bash, db.Match("pkg:rpm/redhat/bash@5.1.8-6.el9?arch=x86_64&distro=rhel-9.2", ""))
+	assert.Empty(t, db.Match("pkg:rpm/redhat/bash@5.1.8-6.el9?distro=rhel-8.8", ""))
+
+	busybox := []artifact.Vulnerability{{ID: "ALPINE-CVE-2023-1"}}
+	assert.Equal(t, busybox, db.Match("pkg:apk/alpine/busybox@1.36.1-r2?distro=alpine-3.18.4", ""))
+	assert.Empty(t, db.Match("pkg:apk/alpine/busybox@1.36.1-r3", ""))
+	assert.Empty(t, db.Match("pkg:apk/alpine/busybox@1.36.1-r2?distro=alpine-3.19.0", ""))
+}
+
+func TestLoadInvalid(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "invalid.json")
+	assert.NoError(t, os.WriteFile(filename, []byte(`{"id": 1}`), 0644))
+	_, err := Load(filename)
+	assert.Error(t, err)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestCVSS3Score(t *testing.T) {
+	for vector, score := range map[string]float64{
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H": 9.8,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H": 10,
+		"CVSS:3.0/AV:N/AC:L/PR:L/UI:R/S:C/C:L/I:L/A:N": 5.4,
+		"CVSS:3.1/AV:L/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N": 1.8,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N": 0,
+	} {
+		s, ok := cvss3Score(vector)
+		assert.True(t, ok, vector)
+		assert.Equal(t, score, s, vector)
+	}
+	for _, vector := range []string{"AV:N/AC:L/Au:N/C:P/I:P/A:P", "CVSS:3.1/AV:N/AC:L", "CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N"} {
+		_, ok := cvss3Score(vector)
+		assert.False(t, ok, vector)
+	}
+	assert.Equal(t, artifact.SeverityMedium, cvssSeverity(5.4))
+	assert.Equal(t, artifact.SeverityCritical, cvssSeverity(9.0))
+}
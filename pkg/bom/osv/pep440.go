@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package osv
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// version scheme, defined by https://peps.python.org/pep-0440/, including permitted non-normalized forms
+var pep440Pattern = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+	`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+var pep440PreRelease = map[string]int{"a": 0, "alpha": 0, "b": 1, "beta": 1, "c": 2, "rc": 2, "pre": 2, "preview": 2}
+
+type pep440Version struct {
+	epoch   int
+	release []int
+	pre     [2]int // phase and number, absent pre-release sorts after any pre-release
+	post    int    // absent post-release sorts before any post-release
+	dev     int    // absent development release sorts after any development release
+	local   []string
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
+
+func parsePEP440(v string) (pep440Version, bool) {
+	m := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(v)))
+	if m == nil {
+		return pep440Version{}, false
+	}
+	res := pep440Version{
+		epoch: atoi(m[1]),
+		pre:   [2]int{math.MaxInt32, 0},
+		post:  -1,
+		dev:   math.MaxInt32,
+	}
+	for _, n := range strings.Split(m[2], ".") {
+		res.release = append(res.release, atoi(n))
+	}
+	// trailing zeros are insignificant, 1.0 == 1.0.0
+	for len(res.release) > 1 && res.release[len(res.release)-1] == 0 {
+		res.release = res.release[:len(res.release)-1]
+	}
+	if m[3] != "" {
+		res.pre = [2]int{pep440PreRelease[m[3]], atoi(m[4])}
+	}
+	if m[5] != "" {
+		res.post = atoi(m[5])
+	} else if m[6] != "" {
+		res.post = atoi(m[7])
+	}
+	if m[8] != "" {
+		res.dev = atoi(m[9])
+		if m[3] == "" && res.post < 0 {
+			res.pre[0] = -1 // development release of final version sorts before its pre-releases
+		}
+	}
+	if m[10] != "" {
+		res.local = strings.FieldsFunc(m[10], func(r rune) bool { return r == '-' || r == '_' || r == '.' })
+	}
+	return res, true
+}
+
+func compareInts(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return sign(a[i] - b[i])
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+// comparePEP440 compares Python package versions
+func comparePEP440(a, b string) int {
+	va, okA := parsePEP440(a)
+	vb, okB := parsePEP440(b)
+	if !okA || !okB {
+		return rpmvercmp(a, b)
+	}
+	if c := compareInts([]int{va.epoch}, []int{vb.epoch}); c != 0 {
+		return c
+	}
+	if c := compareInts(va.release, vb.release); c != 0 {
+		return c
+	}
+	if c := compareInts(va.pre[:], vb.pre[:]); c != 0 {
+		return c
+	}
+	if c := compareInts([]int{va.post, va.dev}, []int{vb.post, vb.dev}); c != 0 {
+		return c
+	}
+	// local version segments - numeric ones sort after alphanumeric ones
+	for i := 0; i < len(va.local) && i < len(vb.local); i++ {
+		na, errA := strconv.Atoi(va.local[i])
+		nb, errB := strconv.Atoi(vb.local[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return sign(na - nb)
+			}
+		case errA == nil:
+			return 1
+		case errB == nil:
+			return -1
+		default:
+			if c := strings.Compare(va.local[i], vb.local[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(va.local) - len(vb.local))
+}
@@ -0,0 +1,325 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package osv
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// compareFunc compares two versions, returning -1, 0 or 1. It never fails - versions, not following
+// the expected scheme, are compared on best effort basis
+type compareFunc func(a, b string) int
+
+// comparators for ecosystem-specific versions, keyed by purl type. All other ecosystems use semver
+var comparators = map[string]compareFunc{
+	typeDeb:  compareDebian,
+	typeApk:  compareAlpine,
+	typeRPM:  compareRPM,
+	typePyPI: comparePEP440,
+}
+
+// CompareVersions compares versions of packages with given purl type by the rules of their ecosystem,
+// returning -1, 0 or 1
+func CompareVersions(purlType, a, b string) int {
+	return comparator(purlType)(a, b)
+}
+
+func comparator(purlType string) compareFunc {
+	if compare, ok := comparators[purlType]; ok {
+		return compare
+	}
+	return compareSemver
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	}
+	return 0
+}
+
+// compareNumbers compares decimal numbers of arbitrary length
+func compareNumbers(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return sign(len(a) - len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+// compareSemver compares semantic versions, leading 'v' and missing minor or patch numbers are accepted
+func compareSemver(a, b string) int {
+	va, errA := semver.ParseTolerant(a)
+	vb, errB := semver.ParseTolerant(b)
+	if errA != nil || errB != nil {
+		return rpmvercmp(a, b)
+	}
+	return va.Compare(vb)
+}
+
+// splitEpoch splits '[epoch:]version' into numeric epoch and version
+func splitEpoch(v string) (string, string) {
+	if i := strings.IndexByte(v, ':'); i > 0 {
+		if _, err := strconv.ParseUint(v[:i], 10, 64); err == nil {
+			return v[:i], v[i+1:]
+		}
+	}
+	return "0", v
+}
+
+// compareDebian compares Debian versions '[epoch:]upstream[-revision]', see
+// https://www.debian.org/doc/debian-policy/ch-controlfields.html#version
+func compareDebian(a, b string) int {
+	epochA, a := splitEpoch(a)
+	epochB, b := splitEpoch(b)
+	if c := compareNumbers(epochA, epochB); c != 0 {
+		return c
+	}
+	revA, revB := "", ""
+	if i := strings.LastIndexByte(a, '-'); i >= 0 {
+		a, revA = a[:i], a[i+1:]
+	}
+	if i := strings.LastIndexByte(b, '-'); i >= 0 {
+		b, revB = b[:i], b[i+1:]
+	}
+	if c := verrevcmp(a, b); c != 0 {
+		return c
+	}
+	return verrevcmp(revA, revB)
+}
+
+// debianOrder returns sort weight of the character in the non-digit part of Debian version: tilde sorts
+// before anything, even the end of the part, letters sort before other characters
+func debianOrder(s string) int {
+	switch {
+	case s == "":
+		return 0
+	case s[0] == '~':
+		return -1
+	case isDigit(s[0]):
+		return 0
+	case isAlpha(s[0]):
+		return int(s[0])
+	}
+	return int(s[0]) + 256
+}
+
+// verrevcmp compares upstream version or revision parts of Debian version, as dpkg does
+func verrevcmp(a, b string) int {
+	for a != "" || b != "" {
+		for a != "" && !isDigit(a[0]) || b != "" && !isDigit(b[0]) {
+			if c := debianOrder(a) - debianOrder(b); c != 0 {
+				return sign(c)
+			}
+			if a != "" {
+				a = a[1:]
+			}
+			if b != "" {
+				b = b[1:]
+			}
+		}
+		i, j := 0, 0
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+		if c := compareNumbers(a[:i], b[:j]); c != 0 {
+			return c
+		}
+		a, b = a[i:], b[j:]
+	}
+	return 0
+}
+
+// compareRPM compares RPM versions '[epoch:]version[-release]'
+func compareRPM(a, b string) int {
+	epochA, a := splitEpoch(a)
+	epochB, b := splitEpoch(b)
+	if c := compareNumbers(epochA, epochB); c != 0 {
+		return c
+	}
+	relA, relB := "", ""
+	if i := strings.LastIndexByte(a, '-'); i >= 0 {
+		a, relA = a[:i], a[i+1:]
+	}
+	if i := strings.LastIndexByte(b, '-'); i >= 0 {
+		b, relB = b[:i], b[i+1:]
+	}
+	if c := rpmvercmp(a, b); c != 0 || relA == "" || relB == "" {
+		return c // release is compared only if both versions have it
+	}
+	return rpmvercmp(relA, relB)
+}
+
+// rpmvercmp compares version or release parts of RPM version, as rpm does: alphanumeric segments are
+// compared one by one, numeric segments are newer than alphabetic ones, tilde sorts before anything and
+// caret sorts after the end of the version, but before any other segment
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+	separator := func(c rune) bool {
+		return c > 127 || !isDigit(byte(c)) && !isAlpha(byte(c)) && c != '~' && c != '^'
+	}
+	for {
+		a = strings.TrimLeftFunc(a, separator)
+		b = strings.TrimLeftFunc(b, separator)
+
+		tildeA, tildeB := strings.HasPrefix(a, "~"), strings.HasPrefix(b, "~")
+		if tildeA || tildeB {
+			if !tildeA {
+				return 1
+			}
+			if !tildeB {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		caretA, caretB := strings.HasPrefix(a, "^"), strings.HasPrefix(b, "^")
+		if caretA || caretB {
+			switch {
+			case a == "":
+				return -1
+			case b == "":
+				return 1
+			case !caretA:
+				return 1
+			case !caretB:
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if a == "" || b == "" {
+			break
+		}
+
+		segment := isAlpha
+		numeric := isDigit(a[0])
+		if numeric {
+			segment = isDigit
+		}
+		i, j := 0, 0
+		for i < len(a) && segment(a[i]) {
+			i++
+		}
+		for j < len(b) && segment(b[j]) {
+			j++
+		}
+		if j == 0 {
+			// segments of different types, numeric one is newer
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+		var c int
+		if numeric {
+			c = compareNumbers(a[:i], b[:j])
+		} else {
+			c = strings.Compare(a[:i], b[:j])
+		}
+		if c != 0 {
+			return c
+		}
+		a, b = a[i:], b[j:]
+	}
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+// alpineSuffixes are the ranks of Alpine version suffixes, pre-release ones sort before the version without suffix
+var alpineSuffixes = map[string]int{"alpha": -4, "beta": -3, "pre": -2, "rc": -1, "cvs": 1, "svn": 2, "git": 3, "hg": 4, "p": 5}
+
+type alpineVersion struct {
+	numbers  []string
+	letter   byte
+	suffixes [][2]string // suffix name and number
+	revision string
+}
+
+// parseAlpine parses Alpine version 'number{.number}[letter]{_suffix[number]}[-rrevision]'
+func parseAlpine(v string) alpineVersion {
+	var res alpineVersion
+	if i := strings.LastIndex(v, "-r"); i >= 0 {
+		v, res.revision = v[:i], v[i+2:]
+	}
+	parts := strings.Split(v, "_")
+	for _, n := range strings.Split(parts[0], ".") {
+		if n != "" && isAlpha(n[len(n)-1]) {
+			res.letter = n[len(n)-1]
+			n = n[:len(n)-1]
+		}
+		res.numbers = append(res.numbers, n)
+	}
+	for _, s := range parts[1:] {
+		i := len(s)
+		for i > 0 && isDigit(s[i-1]) {
+			i--
+		}
+		res.suffixes = append(res.suffixes, [2]string{s[:i], s[i:]})
+	}
+	return res
+}
+
+// compareAlpine compares Alpine package versions, see
+// https://wiki.alpinelinux.org/wiki/APKBUILD_Reference#pkgver
+func compareAlpine(a, b string) int {
+	va, vb := parseAlpine(a), parseAlpine(b)
+	for i := 0; i < len(va.numbers) && i < len(vb.numbers); i++ {
+		if c := compareNumbers(va.numbers[i], vb.numbers[i]); c != 0 {
+			return c
+		}
+	}
+	if len(va.numbers) != len(vb.numbers) {
+		return sign(len(va.numbers) - len(vb.numbers))
+	}
+	if va.letter != vb.letter {
+		return sign(int(va.letter) - int(vb.letter))
+	}
+	for i := 0; i < len(va.suffixes) || i < len(vb.suffixes); i++ {
+		var sa, sb [2]string
+		if i < len(va.suffixes) {
+			sa = va.suffixes[i]
+		}
+		if i < len(vb.suffixes) {
+			sb = vb.suffixes[i]
+		}
+		if c := sign(alpineSuffixes[sa[0]] - alpineSuffixes[sb[0]]); c != 0 {
+			return c
+		}
+		if c := compareNumbers(sa[1], sb[1]); c != 0 {
+			return c
+		}
+	}
+	return compareNumbers(va.revision, vb.revision)
+}
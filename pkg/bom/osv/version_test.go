@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package osv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// each list is in ascending order, equal versions are grouped
+var versionOrder = map[string][][]string{
+	"debian": {
+		{"1.0~rc1-1"},
+		{"1.0-1", "0:1.0-1"},
+		{"1.0-1+b1"},
+		{"1.0-1.1"},
+		{"1.0-10"},
+		{"1.0a-1"},
+		{"1.0+dfsg-1"},
+		{"1.00.1-1"},
+		{"2.36-9+deb12u3"},
+		{"2.36-9+deb12u10"},
+		{"1:0.9-1"},
+	},
+	"alpine": {
+		{"1.2_alpha1"},
+		{"1.2_beta"},
+		{"1.2_rc2"},
+		{"1.2", "1.2-r0"},
+		{"1.2-r1"},
+		{"1.2_p1"},
+		{"1.2.1"},
+		{"1.2.1a"},
+		{"1.10"},
+	},
+	"rpm": {
+		{"1.0~rc1-1.el9"},
+		{"1.0-1.el9"},
+		{"1.0-1.el9_1"},
+		{"1.0^git1-1.el9"},
+		{"1.0a-1"},
+		{"1.0.1-1"},
+		{"1.10-1"},
+		{"1:0.1-1"},
+	},
+	"pypi": {
+		{"1.0.dev1"},
+		{"1.0a1.dev1"},
+		{"1.0a1", "1.0alpha1", "1.0-a.1"},
+		{"1.0b2"},
+		{"1.0rc1", "1.0c1"},
+		{"1.0", "1.0.0", "v1.0"},
+		{"1.0+local.1"},
+		{"1.0+local.2"},
+		{"1.0.post1.dev1"},
+		{"1.0.post1", "1.0-1", "1.0.rev1"},
+		{"1.1"},
+		{"1!0.1"},
+	},
+	"semver": {
+		{"0.9.9"},
+		{"1.0.0-alpha"},
+		{"1.0.0-beta.2"},
+		{"1.0.0", "v1.0.0", "1.0", "v1"},
+		{"1.2.3"},
+		{"1.10.0"},
+	},
+}
+
+var versionComparators = map[string]compareFunc{
+	"debian": compareDebian,
+	"alpine": compareAlpine,
+	"rpm":    compareRPM,
+	"pypi":   comparePEP440,
+	"semver": compareSemver,
+}
+
+func TestCompareVersions(t *testing.T) {
+	for scheme, groups := range versionOrder {
+		compare := versionComparators[scheme]
+		for i, group := range groups {
+			for j, other := range groups {
+				for _, a := range group {
+					for _, b := range other {
+						assert.Equal(t, sign(i-j), compare(a, b), "%s: %s vs %s", scheme, a, b)
+					}
+				}
+			}
+		}
+	}
+}
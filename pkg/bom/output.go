@@ -16,6 +16,7 @@ import (
 
 	"github.com/codenotary/cas/pkg/bom/artifact"
 	"github.com/codenotary/cas/pkg/bom/docker"
+	"github.com/codenotary/cas/pkg/bom/golang"
 )
 
 const (
@@ -25,18 +26,39 @@ const (
 	transientDep   = "Transient"
 )
 
-func Output(a artifact.Artifact) error {
-	filename := viper.GetString("bom-spdx")
+// Output writes BOM to the files in all requested formats. Hash is the notarized hash of the asset itself,
+// empty if unknown
+func Output(a artifact.Artifact, hash string) error {
+	var overrides []CPEOverride
+	filename := viper.GetString("bom-cpe-overrides")
 	if filename != "" {
-		err := OutputSpdxText(a, filename)
+		var err error
+		overrides, err = LoadCPEOverrides(filename)
+		if err != nil {
+			return err
+		}
+	}
+	GenerateCPEs(a, overrides)
+
+	filename = viper.GetString("bom-spdx")
+	if filename != "" {
+		err := OutputSpdxText(a, hash, filename)
 		if err != nil {
 			return fmt.Errorf("cannot output SPDX: %w", err)
 		}
 	}
 
+	filename = viper.GetString("bom-spdx-json")
+	if filename != "" {
+		err := OutputSpdxJSON(a, hash, filename)
+		if err != nil {
+			return fmt.Errorf("cannot output SPDX JSON: %w", err)
+		}
+	}
+
 	filename = viper.GetString("bom-cdx-json")
 	if filename != "" {
-		err := OutputCycloneDX(a, filename, cyclonedx.BOMFileFormatJSON)
+		err := OutputCycloneDX(a, hash, filename, cyclonedx.BOMFileFormatJSON)
 		if err != nil {
 			return fmt.Errorf("cannot output  CycloneDX JSON: %w", err)
 		}
@@ -44,7 +66,7 @@ func Output(a artifact.Artifact) error {
 
 	filename = viper.GetString("bom-cdx-xml")
 	if filename != "" {
-		err := OutputCycloneDX(a, filename, cyclonedx.BOMFileFormatXML)
+		err := OutputCycloneDX(a, hash, filename, cyclonedx.BOMFileFormatXML)
 		if err != nil {
 			return fmt.Errorf("cannot output  CycloneDX XML: %w", err)
 		}
@@ -63,6 +85,10 @@ func DepLinkType(a artifact.Artifact, d artifact.Dependency) string {
 		return staticLinkage
 	}
 
+	if golang.IsExecutable(a) && d.Kind == golang.AssetType {
+		return staticLinkage
+	}
+
 	return dynamicLinkage
 }
 
@@ -72,3 +98,36 @@ func DepType(d artifact.Dependency) string {
 	}
 	return transientDep
 }
+
+type depKey struct {
+	kind    string
+	name    string
+	version string
+}
+
+// depIndex maps dependencies to their positions in dependency slice, used for resolving relations
+// between dependencies
+type depIndex map[depKey]int
+
+func newDepIndex(deps []artifact.Dependency) depIndex {
+	index := make(depIndex, len(deps))
+	for i, d := range deps {
+		k := depKey{d.Kind, d.Name, d.Version}
+		if _, ok := index[k]; !ok {
+			index[k] = i
+		}
+	}
+	return index
+}
+
+// required returns positions of dependencies, required by the dependency. Dependencies that aren't in the
+// index are skipped
+func (index depIndex) required(d artifact.Dependency) []int {
+	res := make([]int, 0, len(d.Requires))
+	for _, ref := range d.Requires {
+		if i, ok := index[depKey{d.Kind, ref.Name, ref.Version}]; ok {
+			res = append(res, i)
+		}
+	}
+	return res
+}
@@ -0,0 +1,249 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package bom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/santhosh-tekuri/jsonschema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/docker"
+	"github.com/codenotary/cas/pkg/bom/javascript"
+)
+
+type testArtifact struct {
+	artifact.GenericArtifact
+	kind string
+}
+
+func (a testArtifact) Path() string {
+	return "/src/app"
+}
+
+func (a testArtifact) Type() string {
+	return a.kind
+}
+
+func (a testArtifact) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	return a.Deps, nil
+}
+
+func newTestArtifact(kind string) testArtifact {
+	return testArtifact{kind: kind, GenericArtifact: artifact.GenericArtifact{Deps: []artifact.Dependency{
+		{Name: "app-lib", Version: "1.0", Kind: kind, Type: artifact.DepDirect, Requires: []artifact.DepRef{
+			{Name: "util", Version: "2.0"},
+			{Name: "missing", Version: "1.0"},
+		}},
+		{Name: "util", Version: "2.0", Kind: kind, Type: artifact.DepTransient, Hash: "1234",
+			HashType: artifact.HashSHA1, Supplier: "Util Team <util@example.com>", License: "MIT"},
+	}}}
+}
+
+func TestCycloneDXDependencies(t *testing.T) {
+	bom := convertToCyclone(newTestArtifact("test"), "abcd")
+	assert.Equal(t, "app", bom.Metadata.Component.BOMRef)
+	assert.Equal(t, &[]cdx.Hash{{Algorithm: cdx.HashAlgoSHA256, Value: "abcd"}}, bom.Metadata.Component.Hashes)
+	assert.Equal(t, &[]cdx.Dependency{
+		{Ref: "app", Dependencies: &[]cdx.Dependency{{Ref: "app-1"}}},
+		{Ref: "app-1", Dependencies: &[]cdx.Dependency{{Ref: "app-2"}}},
+		{Ref: "app-2"},
+	}, bom.Dependencies)
+
+	bom = convertToCyclone(newTestArtifact("test"), "")
+	assert.Nil(t, bom.Metadata.Component.Hashes)
+}
+
+func TestSpdxRelationships(t *testing.T) {
+	a := newTestArtifact("test")
+	assert.Equal(t, [][3]string{
+		{"SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-Asset"},
+		{"SPDXRef-Asset", "DEPENDS_ON", "SPDXRef-Package-1"},
+		{"SPDXRef-Package-1", "DEPENDS_ON", "SPDXRef-Package-2"},
+	}, spdxRelationships(a, a.Deps))
+
+	// image contains all packages
+	a = newTestArtifact(docker.DPKG)
+	assert.Equal(t, [][3]string{
+		{"SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-Asset"},
+		{"SPDXRef-Asset", "CONTAINS", "SPDXRef-Package-1"},
+		{"SPDXRef-Asset", "CONTAINS", "SPDXRef-Package-2"},
+		{"SPDXRef-Package-1", "DEPENDS_ON", "SPDXRef-Package-2"},
+	}, spdxRelationships(a, a.Deps))
+}
+
+func TestSpdxJSON(t *testing.T) {
+	doc, err := convertToSpdx(newTestArtifact(javascript.AssetType), "abcd")
+	assert.NoError(t, err)
+	assert.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+	assert.Equal(t, "app", doc.Name)
+	assert.Equal(t, []string{"Tool: Codenotary cas"}, doc.CreationInfo.Creators)
+	assert.Equal(t, []spdxPackage{{
+		SPDXID:           "SPDXRef-Asset",
+		Name:             "app",
+		DownloadLocation: "NOASSERTION",
+		Checksums:        []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: "abcd"}},
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  "NOASSERTION",
+		CopyrightText:    "NOASSERTION",
+	}, {
+		SPDXID:           "SPDXRef-Package-1",
+		Name:             "app-lib",
+		VersionInfo:      "1.0",
+		Supplier:         "NOASSERTION",
+		DownloadLocation: "NOASSERTION",
+		ExternalRefs: []spdxExternalRef{{
+			ReferenceCategory: "PACKAGE-MANAGER",
+			ReferenceType:     "purl",
+			ReferenceLocator:  "pkg:npm/app-lib@1.0",
+		}},
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  "NOASSERTION",
+		CopyrightText:    "NOASSERTION",
+		Comment:          "Dynamic, Direct",
+	}, {
+		SPDXID:           "SPDXRef-Package-2",
+		Name:             "util",
+		VersionInfo:      "2.0",
+		Supplier:         "Organization: Util Team (util@example.com)",
+		DownloadLocation: "NOASSERTION",
+		Checksums:        []spdxChecksum{{Algorithm: "SHA1", ChecksumValue: "1234"}},
+		ExternalRefs: []spdxExternalRef{{
+			ReferenceCategory: "PACKAGE-MANAGER",
+			ReferenceType:     "purl",
+			ReferenceLocator:  "pkg:npm/util@2.0",
+		}},
+		LicenseConcluded: "MIT",
+		LicenseDeclared:  "MIT",
+		CopyrightText:    "NOASSERTION",
+		Comment:          "Dynamic, Transient",
+	}}, doc.Packages)
+	assert.Equal(t, []spdxRelationship{
+		{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-Asset"},
+		{SPDXElementID: "SPDXRef-Asset", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: "SPDXRef-Package-1"},
+		{SPDXElementID: "SPDXRef-Package-1", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: "SPDXRef-Package-2"},
+	}, doc.Relationships)
+}
+
+func TestSpdxJSONSchema(t *testing.T) {
+	schema, err := jsonschema.Compile(filepath.Join("testdata", "spdx-schema.json"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	a := newTestArtifact(javascript.AssetType)
+	a.Deps[0].CPEs = []string{"cpe:2.3:a:app-lib:app-lib:1.0:*:*:*:*:*:*:*", "cpe:2.3:a:app-lib_project:app-lib:1.0:*:*:*:*:*:*:*"}
+	a.Deps[0].License = "LicenseRef-Custom"
+	a.Deps[0].LicenseDeclared = "Custom license"
+	filename := filepath.Join(t.TempDir(), "bom.spdx.json")
+	assert.NoError(t, OutputSpdxJSON(a, "abcd", filename))
+
+	f, err := os.Open(filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+	assert.NoError(t, schema.Validate(f))
+}
+
+func TestSpdxExtractedLicenses(t *testing.T) {
+	a := newTestArtifact(docker.DPKG)
+	a.Deps[0].License = "LicenseRef-public-domain AND MIT"
+	a.Deps[0].LicenseDeclared = "public-domain; Expat"
+	a.Deps[1].License = "LicenseRef-public-domain OR LicenseRef-Other"
+	doc, err := convertToSpdx(a, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Declared license: public-domain; Expat", doc.Packages[1].LicenseComments)
+	assert.Equal(t, "LicenseRef-public-domain AND MIT", doc.Packages[1].LicenseDeclared)
+	assert.Equal(t, []spdxExtractedLicense{
+		{LicenseID: "LicenseRef-public-domain", ExtractedText: "public-domain; Expat", Name: "public-domain"},
+		{LicenseID: "LicenseRef-Other", ExtractedText: "LicenseRef-public-domain OR LicenseRef-Other", Name: "Other"},
+	}, doc.HasExtractedLicensingInfos)
+}
+
+func TestCycloneDXVulnerabilities(t *testing.T) {
+	a := newTestArtifact("test")
+	vuln := artifact.Vulnerability{
+		ID:       "GHSA-1",
+		Aliases:  []string{"CVE-2023-1", "OSV-2023-1"},
+		Summary:  "Remote code execution",
+		Severity: artifact.SeverityCritical,
+		Score:    9.8,
+		Vector:   "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+		Fixed:    "2.1",
+	}
+	a.Deps[0].Vulnerabilities = []artifact.Vulnerability{{ID: "GHSA-2"}}
+	a.Deps[1].Vulnerabilities = []artifact.Vulnerability{vuln, {ID: "GHSA-2"}}
+
+	bom := convertToCyclone(a, "")
+	score := 9.8
+	assert.Equal(t, &[]cdx.Vulnerability{{
+		ID:      "GHSA-2",
+		Source:  &cdx.Source{Name: "OSV", URL: "https://osv.dev/vulnerability/GHSA-2"},
+		Ratings: &[]cdx.VulnerabilityRating{{Severity: cdx.SeverityUnknown}},
+		Affects: &[]cdx.Affects{
+			{Ref: "app-1", Range: &[]cdx.AffectedVersions{{Version: "1.0", Status: cdx.VulnerabilityStatusAffected}}},
+			{Ref: "app-2", Range: &[]cdx.AffectedVersions{{Version: "2.0", Status: cdx.VulnerabilityStatusAffected}}},
+		},
+	}, {
+		ID:     "GHSA-1",
+		Source: &cdx.Source{Name: "OSV", URL: "https://osv.dev/vulnerability/GHSA-1"},
+		References: &[]cdx.VulnerabilityReference{
+			{ID: "CVE-2023-1", Source: &cdx.Source{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/CVE-2023-1"}},
+			{ID: "OSV-2023-1", Source: &cdx.Source{Name: "OSV", URL: "https://osv.dev/vulnerability/OSV-2023-1"}},
+		},
+		Ratings: &[]cdx.VulnerabilityRating{{
+			Score:    &score,
+			Severity: cdx.SeverityCritical,
+			Method:   cdx.ScoringMethodCVSSv31,
+			Vector:   "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+		}},
+		Description: "Remote code execution",
+		Affects: &[]cdx.Affects{{Ref: "app-2", Range: &[]cdx.AffectedVersions{
+			{Version: "2.0", Status: cdx.VulnerabilityStatusAffected},
+			{Version: "2.1", Status: cdx.VulnerabilityStatusNotAffected},
+		}}},
+	}}, bom.Vulnerabilities)
+
+	assert.Nil(t, convertToCyclone(newTestArtifact("test"), "").Vulnerabilities)
+}
+
+func TestPurl(t *testing.T) {
+	a := newTestArtifact(docker.DPKG)
+	for expected, d := range map[string]artifact.Dependency{
+		"pkg:deb/debian/libssl3@3.0.11-1~deb12u2?arch=amd64&distro=debian-12&upstream=openssl": {
+			Name: "libssl3", Version: "3.0.11-1~deb12u2", Kind: docker.DPKG, Properties: map[string]string{
+				docker.PropArch: "amd64", docker.PropDistro: "debian-12", docker.PropSource: "openssl",
+			},
+		},
+		"pkg:apk/alpine/musl@1.2.4-r2?arch=x86_64&distro=alpine-3.18.4": {
+			Name: "musl", Version: "1.2.4-r2", Kind: docker.APK, Properties: map[string]string{
+				docker.PropArch: "x86_64", docker.PropDistro: "alpine-3.18.4",
+			},
+		},
+		"pkg:rpm/redhat/bash@5.1.8-6.el9?distro=rhel-9.2": {
+			Name: "bash", Version: "5.1.8-6.el9", Kind: docker.RPM, Properties: map[string]string{
+				docker.PropDistro: "rhel-9.2",
+			},
+		},
+		"pkg:rpm/opensuse/bash@5.2.15-1.1?distro=opensuse-tumbleweed-20231010": {
+			Name: "bash", Version: "5.2.15-1.1", Kind: docker.RPM, Properties: map[string]string{
+				docker.PropDistro: "opensuse-tumbleweed-20231010",
+			},
+		},
+		"pkg:deb/tzdata@2024a-0":       {Name: "tzdata", Version: "2024a-0", Kind: docker.DPKG},
+		"pkg:npm/%40types/node@20.1.0": {Name: "@types/node", Version: "20.1.0", Kind: javascript.AssetType},
+	} {
+		assert.Equal(t, expected, Purl(a, d))
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package php
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+const installedJSON = "vendor/composer/installed.json"
+
+// common application locations in PHP images, and global Composer home
+var appDirGlobs = []string{"/var/www/html", "/var/www", "/var/www/*", "/app", "/srv/*", "/opt/*",
+	"/root/.composer", "/root/.config/composer"}
+
+// InstalledPackages finds Composer packages, installed in common application locations, accessible with executor
+func InstalledPackages(e executor.Executor, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	globs := make([]string, len(appDirGlobs))
+	for i, dir := range appDirGlobs {
+		globs[i] = dir + "/" + installedJSON
+	}
+	files, err := executor.Glob(e, globs)
+	if err != nil {
+		// no shell - try locations without wildcards
+		files = files[:0]
+		for _, glob := range globs {
+			if !strings.Contains(glob, "*") {
+				files = append(files, glob)
+			}
+		}
+	}
+
+	var res []artifact.Dependency
+	for _, file := range files {
+		buf, err := e.ReadFile(file)
+		if err != nil {
+			continue // no such file
+		}
+		packages, err := parseInstalled(buf)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %w", file, err)
+		}
+		res = append(res, dependencies(file, packages, nil, output)...)
+	}
+	return res, nil
+}
+
+// parseInstalled parses vendor/composer/installed.json - a package list (Composer 1), or an object with
+// package list and development package names (Composer 2). Development packages are excluded
+func parseInstalled(buf []byte) ([]composerPackage, error) {
+	var installed struct {
+		Packages        []composerPackage `json:"packages"`
+		DevPackageNames []string          `json:"dev-package-names"`
+	}
+	if err := json.Unmarshal(buf, &installed); err != nil {
+		if err := json.Unmarshal(buf, &installed.Packages); err != nil {
+			return nil, err
+		}
+	}
+
+	dev := make(map[string]bool, len(installed.DevPackageNames))
+	for _, name := range installed.DevPackageNames {
+		dev[strings.ToLower(name)] = true
+	}
+	res := installed.Packages[:0]
+	for _, p := range installed.Packages {
+		if !dev[strings.ToLower(p.Name)] {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,213 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package php
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+const AssetType = "phpcom"
+
+const (
+	composerLock = "composer.lock"
+	composerJSON = "composer.json"
+)
+
+var sha1Pattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// phpArtifact implements Artifact interface for Composer projects
+type phpArtifact struct {
+	artifact.GenericArtifact
+	path     string
+	lockFile string
+}
+
+// composerPackage is a package entry in composer.lock and vendor/composer/installed.json
+type composerPackage struct {
+	Name    string            `json:"name"`
+	Version string            `json:"version"`
+	Source  composerSource    `json:"source"`
+	Dist    composerSource    `json:"dist"`
+	Require map[string]string `json:"require"`
+	License []string          `json:"license"`
+}
+
+type composerSource struct {
+	Type      string `json:"type"`
+	Reference string `json:"reference"`
+	Shasum    string `json:"shasum"`
+}
+
+// New returns new Artifact object, or nil if filename doesn't refer to composer.lock or directory containing it
+func New(filename string) artifact.Artifact {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil
+	}
+
+	if fi.IsDir() {
+		lockFile := filepath.Join(filename, composerLock)
+		if _, err := os.Stat(lockFile); err == nil {
+			return &phpArtifact{path: filename, lockFile: lockFile}
+		}
+		return nil
+	}
+
+	if filepath.Base(filename) == composerLock {
+		return &phpArtifact{path: filename, lockFile: filename}
+	}
+	return nil
+}
+
+func (a phpArtifact) Type() string {
+	return AssetType
+}
+
+func (a phpArtifact) Path() string {
+	return a.path
+}
+
+func (a *phpArtifact) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	if a.Deps != nil {
+		return a.Deps, nil
+	}
+
+	buf, err := ioutil.ReadFile(a.lockFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", a.lockFile, err)
+	}
+	var lock struct {
+		Packages []composerPackage `json:"packages"`
+	}
+	if err := json.Unmarshal(buf, &lock); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", a.lockFile, err)
+	}
+
+	direct, err := projectRequirements(filepath.Join(filepath.Dir(a.lockFile), composerJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	deps := dependencies(a.lockFile, lock.Packages, direct, output)
+	a.Deps = deps
+	return deps, nil
+}
+
+// projectRequirements returns names of the packages, required by composer.json, or nil if the file
+// doesn't exist. Development requirements are ignored
+func projectRequirements(filename string) ([]string, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+	var project struct {
+		Require map[string]string `json:"require"`
+	}
+	if err := json.Unmarshal(buf, &project); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+	res := make([]string, 0, len(project.Require))
+	for name := range project.Require {
+		res = append(res, strings.ToLower(name))
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// dependencies builds dependency graph from package list. If project requirements are unknown, packages not
+// required by other packages are considered direct. Requirements of PHP platform and extensions are ignored
+func dependencies(root string, packages []composerPackage, direct []string, output artifact.OutputOptions) []artifact.Dependency {
+	g := depgraph.NewGraph(root, "")
+	byName := make(map[string]*composerPackage, len(packages))
+	required := make(map[string]bool, len(packages))
+	missing := 0
+	for i := range packages {
+		p := &packages[i]
+		hash := packageHash(p)
+		if hash == "" {
+			missing++
+			continue
+		}
+		byName[strings.ToLower(p.Name)] = p
+		for name := range p.Require {
+			required[strings.ToLower(name)] = true
+		}
+		g.NewNode(p.Name, p.Version, &artifact.Dependency{
+			Name:     p.Name,
+			Version:  p.Version,
+			Hash:     hash,
+			HashType: artifact.HashSHA1,
+			Kind:     AssetType,
+			License:  License(p.License),
+		})
+	}
+	if missing > 0 {
+		artifact.Warnf(output, "%d packages have neither checksum nor commit reference and were skipped\n", missing)
+	}
+
+	for _, p := range byName {
+		parent := g.Node(p.Name, p.Version)
+		for name := range p.Require {
+			if child, ok := byName[strings.ToLower(name)]; ok {
+				g.AddChild(parent, child.Name, child.Version, nil)
+			}
+		}
+	}
+	if direct == nil {
+		for name := range byName {
+			if !required[name] {
+				direct = append(direct, name)
+			}
+		}
+	}
+	for _, name := range direct {
+		if p, ok := byName[name]; ok {
+			g.AddChild(g.Root, p.Name, p.Version, nil)
+		}
+	}
+
+	return g.FlatDeps()
+}
+
+// packageHash returns SHA-1 of the distribution archive, if known, or SHA-1 commit reference, otherwise
+func packageHash(p *composerPackage) string {
+	if p.Dist.Shasum != "" {
+		return strings.ToLower(p.Dist.Shasum)
+	}
+	for _, ref := range []string{p.Dist.Reference, p.Source.Reference} {
+		if sha1Pattern.MatchString(ref) {
+			return ref
+		}
+	}
+	return ""
+}
+
+// License converts Composer license list into SPDX expression - multiple licenses are alternatives
+func License(licenses []string) string {
+	switch len(licenses) {
+	case 0:
+		return ""
+	case 1:
+		return licenses[0]
+	}
+	return "(" + strings.Join(licenses, " OR ") + ")"
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package php
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+const testComposerLock = `{
+    "content-hash": "e3b0c44298fc1c149afbf4c8996fb924",
+    "packages": [
+        {
+            "name": "monolog/monolog",
+            "version": "2.3.5",
+            "source": {"type": "git", "url": "https://github.com/Seldaek/monolog.git", "reference": "fd4380d6fc37626e2f799f29d91195040137eba9"},
+            "dist": {"type": "zip", "url": "https://api.github.com/repos/Seldaek/monolog/zipball/fd4380d6fc37626e2f799f29d91195040137eba9", "reference": "fd4380d6fc37626e2f799f29d91195040137eba9", "shasum": ""},
+            "require": {"php": ">=7.2", "psr/log": "^1.0.1 || ^2.0 || ^3.0"},
+            "license": ["MIT"]
+        },
+        {
+            "name": "psr/log",
+            "version": "1.1.4",
+            "dist": {"type": "zip", "url": "https://example.com/psr-log.zip", "shasum": "D49695B909C3B7628B6289DB5479A1C204601F11"},
+            "license": ["MIT", "Apache-2.0"]
+        },
+        {
+            "name": "local/package",
+            "version": "dev-main",
+            "dist": {"type": "path", "url": "../package"}
+        }
+    ],
+    "packages-dev": [
+        {
+            "name": "phpunit/phpunit",
+            "version": "9.5.10",
+            "dist": {"type": "zip", "reference": "c814a05837f2edb0d1471d6e3f4ab3501ca3899a", "shasum": ""}
+        }
+    ]
+}`
+
+func TestComposerLock(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, composerLock), []byte(testComposerLock), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, composerJSON),
+		[]byte(`{"require": {"php": "^8.0", "Monolog/Monolog": "^2.3"}, "require-dev": {"phpunit/phpunit": "^9.5"}}`), 0644))
+
+	a := New(dir)
+	assert.NotNil(t, a)
+	assert.Equal(t, AssetType, a.Type())
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+
+	expected := map[string]artifact.Dependency{
+		"monolog/monolog": {Version: "2.3.5", Hash: "fd4380d6fc37626e2f799f29d91195040137eba9", License: "MIT",
+			Type: artifact.DepDirect},
+		"psr/log": {Version: "1.1.4", Hash: "d49695b909c3b7628b6289db5479a1c204601f11", License: "(MIT OR Apache-2.0)",
+			Type: artifact.DepTransient},
+	}
+	assert.Len(t, deps, len(expected))
+	for _, d := range deps {
+		e, ok := expected[d.Name]
+		if !assert.True(t, ok, d.Name) {
+			continue
+		}
+		assert.Equal(t, e.Version, d.Version)
+		assert.Equal(t, e.Hash, d.Hash)
+		assert.Equal(t, artifact.HashSHA1, d.HashType)
+		assert.Equal(t, e.License, d.License)
+		assert.Equal(t, e.Type, d.Type, d.Name)
+	}
+}
+
+func TestParseInstalled(t *testing.T) {
+	composer2 := `{"packages": [{"name": "psr/log", "version": "1.1.4"}, {"name": "phpunit/phpunit", "version": "9.5.10"}],
+		"dev": true, "dev-package-names": ["phpunit/phpunit"]}`
+	packages, err := parseInstalled([]byte(composer2))
+	assert.NoError(t, err)
+	assert.Len(t, packages, 1)
+	assert.Equal(t, "psr/log", packages[0].Name)
+
+	composer1 := `[{"name": "psr/log", "version": "1.1.4"}]`
+	packages, err = parseInstalled([]byte(composer1))
+	assert.NoError(t, err)
+	assert.Len(t, packages, 1)
+
+	_, err = parseInstalled([]byte(`"string"`))
+	assert.Error(t, err)
+}
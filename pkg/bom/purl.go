@@ -9,18 +9,81 @@
 package bom
 
 import (
+	"regexp"
+	"sort"
+	"strings"
+
 	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/conda"
 	"github.com/codenotary/cas/pkg/bom/docker"
+	"github.com/codenotary/cas/pkg/bom/dotnet"
+	"github.com/codenotary/cas/pkg/bom/golang"
+	"github.com/codenotary/cas/pkg/bom/java"
+	"github.com/codenotary/cas/pkg/bom/javascript"
+	"github.com/codenotary/cas/pkg/bom/osv"
+	"github.com/codenotary/cas/pkg/bom/php"
+	"github.com/codenotary/cas/pkg/bom/python"
+	"github.com/codenotary/cas/pkg/bom/ruby"
+	"github.com/codenotary/cas/pkg/bom/rust"
 	purl "github.com/package-url/packageurl-go"
 )
 
+// purl types, missing in packageurl-go
+const (
+	typeApk   = "apk"
+	typeCargo = "cargo"
+	typeConda = "conda"
+	typeDeb   = "deb" // packageurl-go uses incorrect 'debian' type
+)
+
 var typeMap = map[string]string{
-	docker.DPKG:  purl.TypeDebian,
-	docker.RPM:   purl.TypeRPM,
-	docker.Image: purl.TypeDocker,
+	docker.DPKG:          typeDeb,
+	docker.APK:           typeApk,
+	docker.RPM:           purl.TypeRPM,
+	docker.Image:         purl.TypeDocker,
+	golang.AssetType:     purl.TypeGolang,
+	python.AssetType:     purl.TypePyPi,
+	javascript.AssetType: purl.TypeNPM,
+	java.AssetType:       purl.TypeMaven,
+	dotnet.AssetType:     purl.TypeNuget,
+	rust.AssetType:       typeCargo,
+	ruby.AssetType:       purl.TypeGem,
+	php.AssetType:        purl.TypeComposer,
+	conda.AssetType:      typeConda,
+}
+
+// distroNamespaces map os-release IDs to purl namespaces, where they differ
+var distroNamespaces = map[string]string{
+	"rhel": "redhat",
+	"sles": "suse",
+	"sled": "suse",
+	"amzn": "amazon",
+	"ol":   "oracle",
 }
 
+// qualifierProperties map qualifiers of OS package URL to dependency properties
+var qualifierProperties = map[string]string{
+	"arch":     docker.PropArch,
+	"distro":   docker.PropDistro,
+	"upstream": docker.PropSource,
+}
+
+// trailing VERSION_ID of 'distro' qualifier
+var distroVersion = regexp.MustCompile(`-\d[^-]*$`)
+
+// Purl returns package URL of the dependency, see https://github.com/package-url/purl-spec. OS packages have
+// the distribution as namespace, and architecture, distribution release and source package as qualifiers
 func Purl(a artifact.Artifact, d artifact.Dependency) string {
+	return purlOf(a, d).ToString()
+}
+
+// CompareVersions compares versions of dependencies of given kind by the rules of their ecosystem, returning
+// -1, 0 or 1. Versions of unknown kinds are compared as semantic versions
+func CompareVersions(kind, a, b string) int {
+	return osv.CompareVersions(typeMap[kind], a, b)
+}
+
+func purlOf(a artifact.Artifact, d artifact.Dependency) *purl.PackageURL {
 	assetType := d.Kind
 	if assetType == "" {
 		assetType = a.Type()
@@ -29,5 +92,45 @@ func Purl(a artifact.Artifact, d artifact.Dependency) string {
 	if !ok {
 		assetType = purl.TypeGeneric
 	}
-	return purl.NewPackageURL(assetType, "", d.Name, d.Version, nil, "").ToString()
+	namespace, name := "", d.Name
+	var qualifiers purl.Qualifiers
+	switch assetType {
+	case typeDeb, typeApk, purl.TypeRPM:
+		namespace = distroNamespace(d.Properties[docker.PropDistro])
+		for key, prop := range qualifierProperties {
+			if value := d.Properties[prop]; value != "" {
+				qualifiers = append(qualifiers, purl.Qualifier{Key: key, Value: value})
+			}
+		}
+		sort.Slice(qualifiers, func(i, j int) bool { return qualifiers[i].Key < qualifiers[j].Key })
+	case purl.TypeGolang:
+		// module path is split into namespace and name at the last slash
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			namespace, name = name[:i], name[i+1:]
+		}
+	case purl.TypePyPi:
+		name = python.NormalizeName(name)
+	case purl.TypeNPM:
+		namespace, name = javascript.SplitName(name)
+	case purl.TypeMaven:
+		namespace, name = java.SplitName(name)
+	case purl.TypeComposer:
+		// package name is '<vendor>/<project>'
+		if i := strings.Index(name, "/"); i >= 0 {
+			namespace, name = name[:i], name[i+1:]
+		}
+	}
+	return purl.NewPackageURL(assetType, namespace, name, d.Version, qualifiers, "")
+}
+
+// distroNamespace returns purl namespace of OS packages from the 'distro' qualifier, like 'debian-12'
+func distroNamespace(distro string) string {
+	id := distroVersion.ReplaceAllString(distro, "")
+	if strings.HasPrefix(id, "opensuse") {
+		return "opensuse"
+	}
+	if namespace, ok := distroNamespaces[id]; ok {
+		return namespace
+	}
+	return id
 }
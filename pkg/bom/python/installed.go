@@ -0,0 +1,317 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package python
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+const (
+	distInfoSuffix = ".dist-info"
+	metadataFile   = "METADATA"
+	recordFile     = "RECORD"
+)
+
+// site-packages locations, used if image has no shell to expand the globs
+var (
+	sitePrefixes   = []string{"/usr/lib", "/usr/local/lib", "/opt/venv/lib", "/venv/lib", "/opt/conda/lib"}
+	pythonVersions = []string{"python3", "python2.7", "python3.6", "python3.7", "python3.8", "python3.9",
+		"python3.10", "python3.11", "python3.12", "python3.13"}
+	siteDirs = []string{"site-packages", "dist-packages"}
+)
+
+// installedArtifact implements Artifact interface for Python environment (virtualenv or site-packages directory)
+type installedArtifact struct {
+	artifact.GenericArtifact
+	path string
+	dirs []string // site-packages directories
+}
+
+// distInfo holds content of the installed distribution metadata files
+type distInfo struct {
+	metadata []byte
+	record   []byte
+}
+
+// newInstalled returns new Artifact object, or nil if directory is neither virtualenv nor site-packages
+func newInstalled(dir string) artifact.Artifact {
+	if hasDistInfo(dir) {
+		return &installedArtifact{path: dir, dirs: []string{dir}}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "pyvenv.cfg")); err != nil {
+		return nil
+	}
+	dirs, _ := filepath.Glob(filepath.Join(dir, "lib", "python*", "site-packages"))
+	if win := filepath.Join(dir, "Lib", "site-packages"); hasDistInfo(win) {
+		dirs = append(dirs, win)
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+	return &installedArtifact{path: dir, dirs: dirs}
+}
+
+func hasDistInfo(dir string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*"+distInfoSuffix))
+	return len(matches) > 0
+}
+
+func (a installedArtifact) Type() string {
+	return AssetType
+}
+
+func (a installedArtifact) Path() string {
+	return a.path
+}
+
+func (a *installedArtifact) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	if a.Deps != nil {
+		return a.Deps, nil
+	}
+
+	dists := make(map[string]*distInfo)
+	for _, dir := range a.dirs {
+		matches, err := filepath.Glob(filepath.Join(dir, "*"+distInfoSuffix))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			d := &distInfo{}
+			d.metadata, err = ioutil.ReadFile(filepath.Join(m, metadataFile))
+			if err != nil {
+				continue // incomplete installation
+			}
+			d.record, err = ioutil.ReadFile(filepath.Join(m, recordFile))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			dists[m] = d
+		}
+	}
+
+	deps := distDependencies(dists, output)
+	a.Deps = deps
+	return deps, nil
+}
+
+// InstalledPackages finds Python distributions, installed in the system site-packages and common virtualenv
+// locations, accessible with executor
+func InstalledPackages(e executor.Executor, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	dists := make(map[string]*distInfo)
+	for _, dir := range sitePackagesDirs(e) {
+		if err := readDistInfos(e, dir, dists); err != nil {
+			return nil, err
+		}
+	}
+
+	return distDependencies(dists, output), nil
+}
+
+// sitePackagesDirs returns candidate site-packages directories. Globs are expanded by the shell, if available
+func sitePackagesDirs(e executor.Executor) []string {
+	globs := make([]string, 0, len(sitePrefixes)*len(siteDirs))
+	for _, prefix := range sitePrefixes {
+		for _, dir := range siteDirs {
+			globs = append(globs, prefix+"/python*/"+dir)
+		}
+	}
+
+	if dirs, err := executor.Glob(e, globs); err == nil {
+		return dirs
+	}
+
+	res := make([]string, 0, len(sitePrefixes)*len(pythonVersions)*len(siteDirs))
+	for _, prefix := range sitePrefixes {
+		for _, version := range pythonVersions {
+			for _, dir := range siteDirs {
+				res = append(res, path.Join(prefix, version, dir))
+			}
+		}
+	}
+	return res
+}
+
+// readDistInfos reads metadata of all distributions in site-packages directory. Missing directory isn't an error
+func readDistInfos(e executor.Executor, dir string, dists map[string]*distInfo) error {
+	reader, err := e.ReadDir(dir)
+	if err != nil {
+		return nil // no such directory
+	}
+	defer reader.Close()
+
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading directory %s: %w", dir, err)
+		}
+		// file name has a form of '<site-packages>/<dist>-<version>.dist-info/<file>'
+		base := path.Base(hdr.Name)
+		distDir := path.Dir(hdr.Name)
+		if !strings.HasSuffix(distDir, distInfoSuffix) || path.Dir(distDir) != path.Base(dir) {
+			continue
+		}
+		if base != metadataFile && base != recordFile {
+			continue
+		}
+		buf, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("error reading file %s: %w", hdr.Name, err)
+		}
+		key := path.Join(path.Dir(dir), distDir)
+		d, ok := dists[key]
+		if !ok {
+			d = &distInfo{}
+			dists[key] = d
+		}
+		if base == metadataFile {
+			d.metadata = buf
+		} else {
+			d.record = buf
+		}
+	}
+
+	return nil
+}
+
+// distDependencies converts distribution metadata into dependencies, ordered by distribution location.
+// Distributions with malformed metadata are skipped
+func distDependencies(dists map[string]*distInfo, output artifact.OutputOptions) []artifact.Dependency {
+	keys := make([]string, 0, len(dists))
+	for k := range dists {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	res := make([]artifact.Dependency, 0, len(dists))
+	for _, k := range keys {
+		d := dists[k]
+		if d.metadata == nil {
+			continue // incomplete installation
+		}
+		dep, err := parseDistInfo(d)
+		if err != nil {
+			artifact.Warnf(output, "Cannot process %s, skipping: %v\n", k, err)
+			continue
+		}
+		if dep.Hash == "" {
+			continue // installed without RECORD, e.g. by OS package manager
+		}
+		res = append(res, *dep)
+	}
+
+	return res
+}
+
+func parseDistInfo(d *distInfo) (*artifact.Dependency, error) {
+	// METADATA has a form of email message headers, see https://packaging.python.org/en/latest/specifications/core-metadata/
+	tp := textproto.NewReader(bufio.NewReader(io.MultiReader(bytes.NewReader(d.metadata), strings.NewReader("\r\n\r\n"))))
+	hdr, err := tp.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("malformed METADATA: %w", err)
+	}
+
+	dep := &artifact.Dependency{
+		Name:    hdr.Get("Name"),
+		Version: hdr.Get("Version"),
+		License: distLicense(hdr),
+		Kind:    AssetType,
+		Type:    artifact.DepDirect,
+	}
+	if dep.Name == "" {
+		return nil, errors.New("METADATA has no package name")
+	}
+
+	if d.record != nil {
+		dep.Hash, err = combineRecordHashes(d.record)
+		if err != nil {
+			return nil, err
+		}
+		dep.HashType = artifact.HashSHA256
+	}
+
+	return dep, nil
+}
+
+// distLicense returns SPDX license expression, if present, otherwise license text or license classifier
+func distLicense(hdr textproto.MIMEHeader) string {
+	if l := hdr.Get("License-Expression"); l != "" {
+		return l
+	}
+	if l := strings.TrimSpace(hdr.Get("License")); l != "" && l != "UNKNOWN" && !strings.Contains(l, "\n") {
+		return l
+	}
+	for _, c := range hdr.Values("Classifier") {
+		if strings.HasPrefix(c, "License ::") {
+			fields := strings.Split(c, "::")
+			return strings.TrimSpace(fields[len(fields)-1])
+		}
+	}
+	return ""
+}
+
+// combineRecordHashes combines SHA-256 hashes of all installed files, listed in RECORD. The file is a CSV with
+// '<path>,sha256=<urlsafe-base64-nopad hash>,<size>' lines, see https://peps.python.org/pep-0376/#record
+func combineRecordHashes(record []byte) (string, error) {
+	r := csv.NewReader(bytes.NewReader(record))
+	r.FieldsPerRecord = -1
+
+	var hash []byte
+	for {
+		fields, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("malformed RECORD: %w", err)
+		}
+		if len(fields) < 2 || !strings.HasPrefix(fields[1], "sha256=") {
+			continue // files without hash, like RECORD itself or compiled bytecode
+		}
+		comp, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(fields[1], "sha256="))
+		if err != nil || len(comp) != 32 {
+			return "", fmt.Errorf("malformed hash for %s in RECORD", fields[0])
+		}
+		if hash == nil {
+			hash = comp
+			continue
+		}
+		// XOR hash
+		for i := range hash {
+			hash[i] ^= comp[i]
+		}
+	}
+
+	if hash == nil {
+		return "", nil
+	}
+	return hex.EncodeToString(hash), nil
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package python
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+const (
+	testMetadata = `Metadata-Version: 2.1
+Name: requests
+Version: 2.28.1
+License: Apache 2.0
+Classifier: License :: OSI Approved :: Apache Software License
+
+Long description
+`
+	testRecord = `requests/__init__.py,sha256=wrong!,4963
+requests/api.py,sha256=cg9VjC4J-kEQYDRl1vKkfv3ySwwyyW2J3cF1i8bY9Ac,6377
+requests/models.py,sha256=D2lrxsgsgSS-m5cfo4NjWbY5yQPMzzY7q10P2CXGAlg,35213
+requests-2.28.1.dist-info/RECORD,,
+requests/__pycache__/api.cpython-310.pyc,,
+`
+)
+
+// dirExecutor serves single directory from memory, it doesn't support command execution
+type dirExecutor struct {
+	dir   string
+	files map[string]string
+}
+
+func (e dirExecutor) Exec(cmd []string) ([]byte, []byte, int, error) {
+	return nil, nil, 0, errors.New("not supported")
+}
+
+func (e dirExecutor) ReadFile(name string) ([]byte, error) {
+	return nil, os.ErrNotExist
+}
+
+func (e dirExecutor) ReadDir(dir string) (io.ReadCloser, error) {
+	if dir != e.dir {
+		return nil, os.ErrNotExist
+	}
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for name, content := range e.files {
+		tw.WriteHeader(&tar.Header{Name: path.Join(path.Base(dir), name), Size: int64(len(content)), Mode: 0644})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	return ioutil.NopCloser(buf), nil
+}
+
+func (e dirExecutor) Close() error {
+	return nil
+}
+
+func TestInstalledPackages(t *testing.T) {
+	e := dirExecutor{
+		dir: "/usr/local/lib/python3.10/site-packages",
+		files: map[string]string{
+			"requests-2.28.1.dist-info/METADATA": testMetadata,
+			"requests-2.28.1.dist-info/RECORD": `requests/api.py,sha256=cg9VjC4J-kEQYDRl1vKkfv3ySwwyyW2J3cF1i8bY9Ac,6377
+requests/models.py,sha256=D2lrxsgsgSS-m5cfo4NjWbY5yQPMzzY7q10P2CXGAlg,35213
+requests-2.28.1.dist-info/RECORD,,
+`,
+			"requests/api.py":                   "",
+			"broken-1.0.dist-info/RECORD":       "",
+			"nested/pkg-1.0.dist-info/METADATA": testMetadata,
+			"malformed-1.0.dist-info/METADATA":  testMetadata,
+			"malformed-1.0.dist-info/RECORD":    "malformed/__init__.py,sha256=not-a-hash,10\n",
+		},
+	}
+
+	deps, err := InstalledPackages(e, artifact.Silent)
+	assert.NoError(t, err)
+	assert.Equal(t, []artifact.Dependency{{
+		Name:     "requests",
+		Version:  "2.28.1",
+		Hash:     "7d663e4ae6257b65aefba37a7571c7274bcb820ffe065bb2769c7a53e31ef65f",
+		HashType: artifact.HashSHA256,
+		License:  "Apache 2.0",
+		Kind:     AssetType,
+		Type:     artifact.DepDirect,
+	}}, deps)
+
+	_, err = combineRecordHashes([]byte(testRecord))
+	assert.Error(t, err)
+}
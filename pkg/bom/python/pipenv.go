@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package python
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+type pipfileLockPackage struct {
+	Version string   `json:"version"`
+	Hashes  []string `json:"hashes"`
+}
+
+type pipfileLockContent struct {
+	Default map[string]pipfileLockPackage `json:"default"`
+}
+
+// pipenvDeps parses Pipfile.lock. Only default (non-development) packages are included. Pipfile.lock has
+// no dependency graph, so packages listed in Pipfile are considered direct dependencies, if Pipfile exists
+func pipenvDeps(filename string) ([]artifact.Dependency, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+	var lock pipfileLockContent
+	if err := json.Unmarshal(buf, &lock); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+
+	direct, err := pipfilePackages(filepath.Join(filepath.Dir(filename), "Pipfile"))
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]artifact.Dependency, 0, len(lock.Default))
+	for name, pkg := range lock.Default {
+		hash, hashType, err := combineHashes(pkg.Hashes)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", name, err)
+		}
+		if hash == "" {
+			continue // VCS or local path dependency
+		}
+		depType := artifact.DepDirect
+		if direct != nil {
+			if _, ok := direct[NormalizeName(name)]; !ok {
+				depType = artifact.DepTransient
+			}
+		}
+		res = append(res, artifact.Dependency{
+			Name:     name,
+			Version:  strings.TrimLeft(pkg.Version, "="),
+			Hash:     hash,
+			HashType: hashType,
+			Kind:     AssetType,
+			Type:     depType,
+		})
+	}
+
+	return res, nil
+}
+
+// pipfilePackages returns normalized names of the packages listed in Pipfile, or nil if there is no Pipfile
+func pipfilePackages(filename string) (map[string]struct{}, error) {
+	tree, err := toml.LoadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+
+	res := make(map[string]struct{})
+	packages, ok := tree.Get("packages").(*toml.Tree)
+	if !ok {
+		return res, nil
+	}
+	for _, name := range packages.Keys() {
+		res[NormalizeName(name)] = struct{}{}
+	}
+	return res, nil
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package python
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/pelletier/go-toml"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+// PEP 508 requirement name
+var pep508NamePattern = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+type poetryPackage struct {
+	name         string
+	version      string
+	category     string
+	dependencies []string
+	hashes       []string
+}
+
+// poetryDeps parses poetry.lock. Development dependencies are excluded, if lock file marks them.
+// Direct dependencies are taken from pyproject.toml
+func poetryDeps(filename string) ([]artifact.Dependency, error) {
+	tree, err := toml.LoadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+	packages := parsePoetryLock(tree)
+
+	direct, err := pyprojectPackages(filepath.Join(filepath.Dir(filename), "pyproject.toml"))
+	if err != nil {
+		return nil, err
+	}
+
+	g := depgraph.NewGraph(filename, "")
+	versions := make(map[string]string, len(packages))
+	for _, pkg := range packages {
+		if pkg.category == "dev" {
+			continue
+		}
+		hash, hashType, err := combineHashes(pkg.hashes)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", pkg.name, err)
+		}
+		if hash == "" {
+			continue // VCS or local path dependency
+		}
+
+		name := NormalizeName(pkg.name)
+		versions[name] = pkg.version
+		g.NewNode(name, pkg.version, &artifact.Dependency{
+			Name:     pkg.name,
+			Version:  pkg.version,
+			Hash:     hash,
+			HashType: hashType,
+			Kind:     AssetType,
+		})
+	}
+
+	for _, pkg := range packages {
+		name := NormalizeName(pkg.name)
+		if _, ok := versions[name]; !ok {
+			continue
+		}
+		parent := g.Node(name, pkg.version)
+		for _, child := range pkg.dependencies {
+			childName := NormalizeName(child)
+			if version, ok := versions[childName]; ok {
+				g.AddChild(parent, childName, version, nil)
+			}
+		}
+		if _, ok := direct[name]; ok || direct == nil {
+			g.AddChild(g.Root, name, pkg.version, nil)
+		}
+	}
+
+	return g.FlatDeps(), nil
+}
+
+// parsePoetryLock extracts package details from the lock file. Poetry before 1.2 keeps file hashes in
+// metadata section instead of package one
+func parsePoetryLock(tree *toml.Tree) []poetryPackage {
+	tables, _ := tree.Get("package").([]*toml.Tree)
+	metaFiles, _ := tree.GetPath([]string{"metadata", "files"}).(*toml.Tree)
+
+	res := make([]poetryPackage, 0, len(tables))
+	for _, t := range tables {
+		pkg := poetryPackage{}
+		pkg.name, _ = t.Get("name").(string)
+		pkg.version, _ = t.Get("version").(string)
+		pkg.category, _ = t.Get("category").(string)
+		if deps, ok := t.Get("dependencies").(*toml.Tree); ok {
+			pkg.dependencies = deps.Keys()
+		}
+
+		files, ok := t.Get("files").([]*toml.Tree)
+		if !ok && metaFiles != nil {
+			files, _ = metaFiles.Get(pkg.name).([]*toml.Tree)
+		}
+		for _, f := range files {
+			if hash, ok := f.Get("hash").(string); ok {
+				pkg.hashes = append(pkg.hashes, hash)
+			}
+		}
+		res = append(res, pkg)
+	}
+	return res
+}
+
+// pyprojectPackages returns normalized names of the packages required by pyproject.toml, both in Poetry and
+// PEP 621 formats, or nil if there is no pyproject.toml
+func pyprojectPackages(filename string) (map[string]struct{}, error) {
+	tree, err := toml.LoadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+
+	res := make(map[string]struct{})
+	if deps, ok := tree.GetPath([]string{"tool", "poetry", "dependencies"}).(*toml.Tree); ok {
+		for _, name := range deps.Keys() {
+			if name != "python" {
+				res[NormalizeName(name)] = struct{}{}
+			}
+		}
+	}
+	if deps, ok := tree.GetPath([]string{"project", "dependencies"}).([]interface{}); ok {
+		for _, d := range deps {
+			s, ok := d.(string)
+			if !ok {
+				continue
+			}
+			if match := pep508NamePattern.FindStringSubmatch(s); match != nil {
+				res[NormalizeName(match[1])] = struct{}{}
+			}
+		}
+	}
+	return res, nil
+}
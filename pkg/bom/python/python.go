@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package python
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+const AssetType = "pythoncom"
+
+const (
+	poetryLock   = "poetry.lock"
+	pipfileLock  = "Pipfile.lock"
+	requirements = "requirements.txt"
+)
+
+// lock files in order of preference, if directory contains several of them
+var lockFiles = []string{poetryLock, pipfileLock, requirements}
+
+var nameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// pythonArtifact implements Artifact interface for Python lock files
+type pythonArtifact struct {
+	artifact.GenericArtifact
+	path     string
+	lockFile string
+}
+
+// New returns new Artifact object, or nil if filename doesn't refer to Python lock file, directory
+// containing one, virtualenv or site-packages directory
+func New(filename string) artifact.Artifact {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil
+	}
+
+	if !fi.IsDir() {
+		for _, name := range lockFiles {
+			if filepath.Base(filename) == name {
+				return &pythonArtifact{path: filename, lockFile: filename}
+			}
+		}
+		return nil
+	}
+
+	for _, name := range lockFiles {
+		lockFile := filepath.Join(filename, name)
+		if _, err := os.Stat(lockFile); err == nil {
+			return &pythonArtifact{path: filename, lockFile: lockFile}
+		}
+	}
+
+	return newInstalled(filename)
+}
+
+func (a pythonArtifact) Type() string {
+	return AssetType
+}
+
+func (a pythonArtifact) Path() string {
+	return a.path
+}
+
+func (a *pythonArtifact) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	if a.Deps != nil {
+		return a.Deps, nil
+	}
+
+	var deps []artifact.Dependency
+	var err error
+	switch filepath.Base(a.lockFile) {
+	case poetryLock:
+		deps, err = poetryDeps(a.lockFile)
+	case pipfileLock:
+		deps, err = pipenvDeps(a.lockFile)
+	default:
+		deps, err = requirementsDeps(a.lockFile, output)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a.Deps = deps
+	return deps, nil
+}
+
+// NormalizeName returns normalized package name, as defined in PEP 503
+func NormalizeName(name string) string {
+	return nameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// combineHashes combines hashes of all distribution files of the package in the form of '<algorithm>:<hex hash>'.
+// Only hashes of the strongest algorithm are used. The result does not depend on hash order
+func combineHashes(hashes []string) (string, artifact.HashType, error) {
+	var hashType artifact.HashType
+	var values []string
+	for _, h := range hashes {
+		fields := strings.SplitN(h, ":", 2)
+		if len(fields) != 2 {
+			return "", artifact.HashInvalid, fmt.Errorf("malformed hash %s", h)
+		}
+		t := artifact.HashTypeByName(strings.ToUpper(fields[0]))
+		switch {
+		case t == artifact.HashInvalid:
+			continue
+		case t > hashType && t <= artifact.HashSHA512:
+			hashType = t
+			values = values[:0]
+			fallthrough
+		case t == hashType:
+			values = append(values, fields[1])
+		}
+	}
+
+	var hash []byte
+	for _, v := range values {
+		comp, err := hex.DecodeString(v)
+		if err != nil {
+			return "", artifact.HashInvalid, fmt.Errorf("malformed hash %s", v)
+		}
+		if hash == nil {
+			hash = comp
+			continue
+		}
+		if len(comp) != len(hash) {
+			return "", artifact.HashInvalid, fmt.Errorf("malformed hash %s", v)
+		}
+		// XOR hash
+		for i := range hash {
+			hash[i] ^= comp[i]
+		}
+	}
+
+	if hash == nil {
+		return "", artifact.HashInvalid, nil
+	}
+	return hex.EncodeToString(hash), hashType, nil
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package python
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+var (
+	// <name>[extras] ==<version> [; markers]
+	requirementPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*(?:===?\s*([^\s;]+))?`)
+	hashPattern        = regexp.MustCompile(`--hash[=\s]+(\S+)`)
+)
+
+type requirement struct {
+	name    string
+	version string
+	hashes  []string
+	via     []string // packages requiring this one, as annotated by pip-compile
+}
+
+// requirementsDeps parses pip requirements file. Only requirements pinned with hashes ('--hash=<alg>:<hash>')
+// can be processed. If file is produced by pip-compile, '# via' annotations are used to build dependency graph
+func requirementsDeps(filename string, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	reqs, err := parseRequirements(f)
+	if err != nil {
+		return nil, err
+	}
+
+	annotated := false
+	for _, r := range reqs {
+		if len(r.via) > 0 {
+			annotated = true
+			break
+		}
+	}
+
+	g := depgraph.NewGraph(filename, "")
+	nodes := make(map[string]*depgraph.GraphNode, len(reqs))
+	skipped := 0
+	for i := range reqs {
+		hash, hashType, err := combineHashes(reqs[i].hashes)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", reqs[i].name, err)
+		}
+		if hash == "" {
+			skipped++
+			continue
+		}
+		dep := &artifact.Dependency{
+			Name:     reqs[i].name,
+			Version:  reqs[i].version,
+			Hash:     hash,
+			HashType: hashType,
+			Kind:     AssetType,
+		}
+		nodes[NormalizeName(dep.Name)] = g.NewNode(NormalizeName(dep.Name), dep.Version, dep)
+	}
+	if skipped > 0 {
+		artifact.Warnf(output, "%d requirement(s) have no hashes and were skipped, use 'pip-compile --generate-hashes' to pin hashes\n", skipped)
+	}
+
+	for _, r := range reqs {
+		name := NormalizeName(r.name)
+		if _, ok := nodes[name]; !ok {
+			continue
+		}
+		// without annotations there is no way to tell direct requirements from transient ones
+		direct := !annotated || len(r.via) == 0
+		for _, parent := range r.via {
+			if strings.HasPrefix(parent, "-r ") || strings.HasPrefix(parent, "-c ") {
+				direct = true // required by the input file
+				continue
+			}
+			if p, ok := nodes[NormalizeName(parent)]; ok {
+				g.AddChild(p, name, r.version, nil)
+			}
+		}
+		if direct {
+			g.AddChild(g.Root, name, r.version, nil)
+		}
+	}
+
+	return g.FlatDeps(), nil
+}
+
+func parseRequirements(r io.Reader) ([]requirement, error) {
+	var res []requirement
+	var last *requirement
+	inVia := false
+
+	scanner := bufio.NewScanner(r)
+	line := ""
+	for scanner.Scan() {
+		text := scanner.Text()
+		if strings.HasSuffix(text, "\\") {
+			line += strings.TrimSuffix(text, "\\") + " "
+			continue
+		}
+		line += text
+		trimmed := strings.TrimSpace(line)
+		line = ""
+
+		if strings.HasPrefix(trimmed, "#") {
+			// pip-compile annotations: '# via <pkg>' or '# via' followed by '#   <pkg>' lines
+			comment := strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+			switch {
+			case last == nil:
+			case comment == "via":
+				inVia = true
+			case strings.HasPrefix(comment, "via "):
+				last.via = append(last.via, strings.TrimSpace(strings.TrimPrefix(comment, "via ")))
+				inVia = false
+			case inVia && comment != "":
+				last.via = append(last.via, comment)
+			default:
+				inVia = false
+			}
+			continue
+		}
+		inVia = false
+
+		if i := strings.Index(trimmed, " #"); i >= 0 {
+			trimmed = strings.TrimSpace(trimmed[:i])
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "-") {
+			continue // options, references to other files, editable installs
+		}
+
+		match := requirementPattern.FindStringSubmatch(trimmed)
+		if match == nil {
+			last = nil
+			continue // URLs and local paths are not supported
+		}
+		req := requirement{name: match[1], version: match[2]}
+		for _, h := range hashPattern.FindAllStringSubmatch(trimmed, -1) {
+			req.hashes = append(req.hashes, h[1])
+		}
+		res = append(res, req)
+		last = &res[len(res)-1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
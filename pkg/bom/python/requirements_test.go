@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+const testRequirements = `--index-url https://pypi.org/simple
+click==8.1.3 \
+    --hash=sha256:7682dc8afb30297001674575ea00d1814d808d6a36af415a82bd481d37ba7b8e
+    # via flask
+Flask[async]==2.1.2 ; python_version >= "3.7" \
+    --hash=sha256:315ded2ddf8a6281567edb27393010fe3406188bafbfe65a3339d5787d89e477
+    # via -r requirements.in
+itsdangerous==2.1.2  # pinned
+    # via
+    #   flask
+    #   other-pkg
+-e ./local
+`
+
+func TestParseRequirements(t *testing.T) {
+	reqs, err := parseRequirements(strings.NewReader(testRequirements))
+	assert.NoError(t, err)
+	assert.Equal(t, []requirement{
+		{
+			name:    "click",
+			version: "8.1.3",
+			hashes:  []string{"sha256:7682dc8afb30297001674575ea00d1814d808d6a36af415a82bd481d37ba7b8e"},
+			via:     []string{"flask"},
+		},
+		{
+			name:    "Flask",
+			version: "2.1.2",
+			hashes:  []string{"sha256:315ded2ddf8a6281567edb27393010fe3406188bafbfe65a3339d5787d89e477"},
+			via:     []string{"-r requirements.in"},
+		},
+		{
+			name:    "itsdangerous",
+			version: "2.1.2",
+			via:     []string{"flask", "other-pkg"},
+		},
+	}, reqs)
+}
+
+func TestCombineHashes(t *testing.T) {
+	hash, hashType, err := combineHashes([]string{"sha256:0f0f", "sha1:ffff", "sha256:f0f1"})
+	assert.NoError(t, err)
+	assert.Equal(t, artifact.HashSHA256, hashType)
+	assert.Equal(t, "fffe", hash)
+
+	hash2, _, _ := combineHashes([]string{"sha256:f0f1", "sha256:0f0f"})
+	assert.Equal(t, hash, hash2)
+
+	_, _, err = combineHashes([]string{"sha256:0f0f", "sha256:0f"})
+	assert.Error(t, err)
+
+	assert.Equal(t, "zope-interface", NormalizeName("Zope_.Interface"))
+}
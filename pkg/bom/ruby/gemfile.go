@@ -0,0 +1,196 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package ruby
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+// Gemfile.lock sections
+const (
+	sectionGem          = "GEM"
+	sectionGit          = "GIT"
+	sectionPath         = "PATH"
+	sectionDependencies = "DEPENDENCIES"
+	sectionChecksums    = "CHECKSUMS"
+)
+
+// lockSpec is a gem, listed in 'specs' of GEM, GIT or PATH section
+type lockSpec struct {
+	name     string
+	version  string // version, including platform
+	source   string // section name
+	revision string // git commit for GIT section
+	deps     []string
+}
+
+type gemfileLockContent struct {
+	specs     []*lockSpec
+	direct    []string
+	checksums map[string]string // SHA-256 checksums, keyed by '<name> <version>'
+}
+
+// lockDeps parses Gemfile.lock. Gem hashes are taken from CHECKSUMS section (Bundler 2.5+), or calculated for
+// gem files in Bundler and RubyGems caches. Gems from git are identified by commit hash
+func lockDeps(filename string, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	lock, err := parseGemfileLock(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+
+	caches := gemCaches(filepath.Dir(filename))
+	g := depgraph.NewGraph(filename, "")
+	byName := make(map[string]*lockSpec, len(lock.specs))
+	missing := 0
+	for _, s := range lock.specs {
+		if _, ok := byName[s.name]; !ok {
+			byName[s.name] = s
+		}
+		if s.source == sectionPath {
+			continue // local gem
+		}
+		hash, hashType := lock.checksums[s.name+" "+s.version], artifact.HashSHA256
+		if hash == "" && s.source == sectionGit {
+			hash, hashType = s.revision, artifact.HashSHA1
+		}
+		if hash == "" {
+			hash = gemHash(caches, s.name+"-"+s.version+gemExt)
+		}
+		if hash == "" {
+			missing++
+			continue
+		}
+		version, _ := SplitVersion(s.version)
+		g.NewNode(s.name, s.version, &artifact.Dependency{
+			Name:     s.name,
+			Version:  version,
+			Hash:     hash,
+			HashType: hashType,
+			Kind:     AssetType,
+		})
+	}
+	if missing > 0 {
+		artifact.Warnf(output, "%d gems have no checksums and are missing in gem cache, skipped. Run 'bundle cache' to include them\n", missing)
+	}
+
+	for _, s := range lock.specs {
+		parent := g.Root
+		if s.source != sectionPath {
+			parent = g.Node(s.name, s.version)
+		}
+		for _, d := range s.deps {
+			if child, ok := byName[d]; ok && child.source != sectionPath {
+				g.AddChild(parent, child.name, child.version, nil)
+			}
+		}
+	}
+	for _, d := range lock.direct {
+		if s, ok := byName[d]; ok && s.source != sectionPath {
+			g.AddChild(g.Root, s.name, s.version, nil)
+		}
+	}
+
+	return g.FlatDeps(), nil
+}
+
+// parseGemfileLock parses Gemfile.lock content. Sections start at column 0, section attributes and
+// 'specs:' are indented by 2 spaces, specs by 4 spaces and spec dependencies by 6 spaces
+func parseGemfileLock(r io.Reader) (*gemfileLockContent, error) {
+	res := &gemfileLockContent{checksums: make(map[string]string)}
+	var section, revision string
+	var spec *lockSpec
+	var sectionSpecs []*lockSpec
+
+	// GIT section revision may follow the specs, so it is assigned at the end of the section
+	endSection := func() {
+		for _, s := range sectionSpecs {
+			s.revision = revision
+		}
+		sectionSpecs, revision, spec = nil, "", nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if line == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		text := strings.TrimSpace(line)
+
+		switch {
+		case indent == 0:
+			endSection()
+			section = text
+		case section == sectionDependencies && indent == 2:
+			// '<name>[!] [(<requirements>)]', '!' marks gems from GIT or PATH sources
+			res.direct = append(res.direct, strings.TrimSuffix(strings.Fields(text)[0], "!"))
+		case section == sectionChecksums && indent == 2:
+			// '<name> (<version>) sha256=<hex>'
+			name, version, rest := splitSpec(text)
+			for _, c := range strings.Fields(rest) {
+				if strings.HasPrefix(c, "sha256=") {
+					res.checksums[name+" "+version] = strings.TrimPrefix(c, "sha256=")
+				}
+			}
+		case section != sectionGem && section != sectionGit && section != sectionPath:
+			continue
+		case indent == 2 && strings.HasPrefix(text, "revision:"):
+			revision = strings.TrimSpace(strings.TrimPrefix(text, "revision:"))
+		case indent == 4:
+			name, version, _ := splitSpec(text)
+			if version == "" {
+				return nil, fmt.Errorf("malformed spec: %s", text)
+			}
+			spec = &lockSpec{name: name, version: version, source: section}
+			res.specs = append(res.specs, spec)
+			sectionSpecs = append(sectionSpecs, spec)
+		case indent == 6 && spec != nil:
+			name, _, _ := splitSpec(text)
+			spec.deps = append(spec.deps, name)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	endSection()
+
+	return res, nil
+}
+
+// splitSpec splits '<name> (<version or requirements>) <rest>' line
+func splitSpec(text string) (string, string, string) {
+	fields := strings.SplitN(text, " ", 2)
+	if len(fields) == 1 {
+		return fields[0], "", ""
+	}
+	rest := strings.TrimSpace(fields[1])
+	if !strings.HasPrefix(rest, "(") {
+		return fields[0], "", rest
+	}
+	end := strings.Index(rest, ")")
+	if end < 0 {
+		return fields[0], "", rest
+	}
+	return fields[0], rest[1:end], strings.TrimSpace(rest[end+1:])
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package ruby
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/executor"
+)
+
+// gem cache locations of official Ruby images, distro packages and Bundler deployments
+var (
+	gemCacheGlobs = []string{"/usr/local/bundle/cache", "/usr/local/bundle/ruby/*/cache",
+		"/usr/local/lib/ruby/gems/*/cache", "/var/lib/gems/*/cache", "/usr/lib/ruby/gems/*/cache",
+		"/usr/share/gems/cache"}
+	rubyVersions = []string{"2.5.0", "2.6.0", "2.7.0", "3.0.0", "3.1.0", "3.2.0", "3.3.0", "3.4.0"}
+)
+
+// InstalledGems finds gems, installed in the system and Bundler locations, accessible with executor. Gems are
+// identified by the gem files, kept by RubyGems in cache directory. Default gems, bundled with Ruby, have no
+// gem files and aren't reported
+func InstalledGems(e executor.Executor) ([]artifact.Dependency, error) {
+	var res []artifact.Dependency
+	seen := make(map[string]struct{})
+	for _, dir := range gemCacheDirs(e) {
+		deps, err := cachedGems(e, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range deps {
+			if _, ok := seen[d.Hash]; !ok {
+				seen[d.Hash] = struct{}{}
+				res = append(res, d)
+			}
+		}
+	}
+	return res, nil
+}
+
+// gemCacheDirs returns candidate gem cache directories. Globs are expanded by the shell, if available
+func gemCacheDirs(e executor.Executor) []string {
+	if dirs, err := executor.Glob(e, gemCacheGlobs); err == nil {
+		return dirs
+	}
+
+	res := make([]string, 0, len(gemCacheGlobs)*len(rubyVersions))
+	for _, glob := range gemCacheGlobs {
+		if !strings.Contains(glob, "*") {
+			res = append(res, glob)
+			continue
+		}
+		for _, version := range rubyVersions {
+			res = append(res, strings.Replace(glob, "*", version, 1))
+		}
+	}
+	return res
+}
+
+// cachedGems reads gem files in cache directory. Missing directory isn't an error
+func cachedGems(e executor.Executor, dir string) ([]artifact.Dependency, error) {
+	reader, err := e.ReadDir(dir)
+	if err != nil {
+		return nil, nil // no such directory
+	}
+	defer reader.Close()
+
+	var res []artifact.Dependency
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading directory %s: %w", dir, err)
+		}
+		// file name has a form of 'cache/<name>-<version>.gem'
+		if !hdr.FileInfo().Mode().IsRegular() || path.Dir(hdr.Name) != path.Base(dir) || path.Ext(hdr.Name) != gemExt {
+			continue
+		}
+		name, version, ok := ParseGemFile(path.Base(hdr.Name))
+		if !ok {
+			continue
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, tr); err != nil {
+			return nil, fmt.Errorf("error reading file %s: %w", hdr.Name, err)
+		}
+		version, _ = SplitVersion(version)
+		res = append(res, artifact.Dependency{
+			Name:     name,
+			Version:  version,
+			Hash:     hex.EncodeToString(h.Sum(nil)),
+			HashType: artifact.HashSHA256,
+			Kind:     AssetType,
+		})
+	}
+	return res, nil
+}
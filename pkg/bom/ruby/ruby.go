@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package ruby
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+const AssetType = "rubycom"
+
+const (
+	gemfileLock = "Gemfile.lock"
+	gemExt      = ".gem"
+)
+
+// rubyArtifact implements Artifact interface for Bundler projects
+type rubyArtifact struct {
+	artifact.GenericArtifact
+	path     string
+	lockFile string
+}
+
+// New returns new Artifact object, or nil if filename doesn't refer to Gemfile.lock or directory containing it
+func New(filename string) artifact.Artifact {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil
+	}
+
+	if fi.IsDir() {
+		lockFile := filepath.Join(filename, gemfileLock)
+		if _, err := os.Stat(lockFile); err == nil {
+			return &rubyArtifact{path: filename, lockFile: lockFile}
+		}
+		return nil
+	}
+
+	if filepath.Base(filename) == gemfileLock {
+		return &rubyArtifact{path: filename, lockFile: filename}
+	}
+	return nil
+}
+
+func (a rubyArtifact) Type() string {
+	return AssetType
+}
+
+func (a rubyArtifact) Path() string {
+	return a.path
+}
+
+func (a *rubyArtifact) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	if a.Deps != nil {
+		return a.Deps, nil
+	}
+
+	deps, err := lockDeps(a.lockFile, output)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Deps = deps
+	return deps, nil
+}
+
+// gemCaches returns directories, where Bundler and RubyGems keep downloaded gems
+func gemCaches(projectDir string) []string {
+	res := []string{filepath.Join(projectDir, "vendor", "cache")}
+	vendored, _ := filepath.Glob(filepath.Join(projectDir, "vendor", "bundle", "ruby", "*", "cache"))
+	res = append(res, vendored...)
+	for _, env := range []string{"GEM_HOME", "BUNDLE_PATH"} {
+		if dir := os.Getenv(env); dir != "" {
+			res = append(res, filepath.Join(dir, "cache"))
+		}
+	}
+	if home, err := homedir.Dir(); err == nil {
+		user, _ := filepath.Glob(filepath.Join(home, ".gem", "ruby", "*", "cache"))
+		res = append(res, user...)
+		local, _ := filepath.Glob(filepath.Join(home, ".local", "share", "gem", "ruby", "*", "cache"))
+		res = append(res, local...)
+	}
+	return res
+}
+
+// gemHash returns hex-encoded SHA-256 of the gem file, the same value as RubyGems checksum. Returns empty
+// string if gem file cannot be found
+func gemHash(dirs []string, gemFile string) string {
+	for _, dir := range dirs {
+		f, err := os.Open(filepath.Join(dir, gemFile))
+		if err != nil {
+			continue
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err == nil {
+			return hex.EncodeToString(h.Sum(nil))
+		}
+	}
+	return ""
+}
+
+// SplitVersion splits gem version into version and platform, platform is empty for pure Ruby gems
+func SplitVersion(version string) (string, string) {
+	// gem versions cannot contain '-', prerelease versions use '.'
+	if i := strings.Index(version, "-"); i >= 0 {
+		return version[:i], version[i+1:]
+	}
+	return version, ""
+}
+
+// ParseGemFile extracts gem name and version (including platform) from '<name>-<version>[-<platform>].gem'
+// file name
+func ParseGemFile(filename string) (string, string, bool) {
+	base := strings.TrimSuffix(filename, gemExt)
+	parts := strings.Split(base, "-")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" && parts[i][0] >= '0' && parts[i][0] <= '9' {
+			return strings.Join(parts[:i], "-"), strings.Join(parts[i:], "-"), true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package ruby
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+const testGemfileLock = `GIT
+  remote: https://github.com/example/widget.git
+  revision: 4c2b4d3b8f0e6a1f6e4c5d2a9b7e8f1a2b3c4d5e
+  specs:
+    widget (0.3.0)
+      rack (>= 2.0)
+
+PATH
+  remote: .
+  specs:
+    app (1.0.0)
+      nokogiri (~> 1.13)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    mini_portile2 (2.8.0)
+    nokogiri (1.13.1-x86_64-linux)
+      racc (~> 1.4)
+    racc (1.6.0)
+    rack (2.2.3)
+
+PLATFORMS
+  x86_64-linux
+
+DEPENDENCIES
+  app!
+  rack (~> 2.2)
+  widget!
+
+CHECKSUMS
+  nokogiri (1.13.1-x86_64-linux) sha256=5f5d8cdd1ce8bd71a0e4ba71a2b4c1a8f0ad1e49d4f2d14c71b3ae8d1b0bc0e4
+  racc (1.6.0) sha256=3d5e3b3a2d3c9b0c4f8a2c4e3f1d6a7b8c9d0e1f2a3b4c5d6e7f8091a2b3c4d5
+
+BUNDLED WITH
+   2.5.3
+`
+
+func TestGemfileLock(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GEM_HOME", filepath.Join(dir, "gems"))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, gemfileLock), []byte(testGemfileLock), 0644))
+	// gem without checksum is found in vendor/cache, mini_portile2 is missing
+	rack := []byte("rack gem")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "vendor", "cache"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "vendor", "cache", "rack-2.2.3.gem"), rack, 0644))
+	rackHash := sha256.Sum256(rack)
+
+	a := New(dir)
+	assert.NotNil(t, a)
+	assert.Equal(t, AssetType, a.Type())
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+
+	expected := map[string]artifact.Dependency{
+		"widget": {Version: "0.3.0", Hash: "4c2b4d3b8f0e6a1f6e4c5d2a9b7e8f1a2b3c4d5e", HashType: artifact.HashSHA1,
+			Type: artifact.DepDirect},
+		"nokogiri": {Version: "1.13.1", Hash: "5f5d8cdd1ce8bd71a0e4ba71a2b4c1a8f0ad1e49d4f2d14c71b3ae8d1b0bc0e4",
+			HashType: artifact.HashSHA256, Type: artifact.DepDirect},
+		"racc": {Version: "1.6.0", Hash: "3d5e3b3a2d3c9b0c4f8a2c4e3f1d6a7b8c9d0e1f2a3b4c5d6e7f8091a2b3c4d5",
+			HashType: artifact.HashSHA256, Type: artifact.DepTransient},
+		"rack": {Version: "2.2.3", Hash: hex.EncodeToString(rackHash[:]), HashType: artifact.HashSHA256,
+			Type: artifact.DepDirect},
+	}
+	assert.Len(t, deps, len(expected))
+	for _, d := range deps {
+		e, ok := expected[d.Name]
+		if !assert.True(t, ok, d.Name) {
+			continue
+		}
+		assert.Equal(t, e.Version, d.Version)
+		assert.Equal(t, e.Hash, d.Hash)
+		assert.Equal(t, e.HashType, d.HashType)
+		assert.Equal(t, e.Type, d.Type, d.Name)
+		assert.Equal(t, AssetType, d.Kind)
+	}
+}
+
+func TestParseGemFile(t *testing.T) {
+	for file, expected := range map[string][2]string{
+		"rake-13.0.6.gem":                      {"rake", "13.0.6"},
+		"net-http-0.2.0.gem":                   {"net-http", "0.2.0"},
+		"nokogiri-1.13.1-x86_64-linux.gem":     {"nokogiri", "1.13.1-x86_64-linux"},
+		"google-protobuf-3.19.2-x86-linux.gem": {"google-protobuf", "3.19.2-x86-linux"},
+	} {
+		name, version, ok := ParseGemFile(file)
+		assert.True(t, ok, file)
+		assert.Equal(t, expected[0], name)
+		assert.Equal(t, expected[1], version)
+	}
+	_, _, ok := ParseGemFile("noversion.gem")
+	assert.False(t, ok)
+}
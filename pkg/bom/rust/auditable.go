@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package rust
+
+import (
+	"bytes"
+	"compress/zlib"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+// section, where cargo-auditable stores zlib-compressed JSON with dependency tree,
+// see https://github.com/rust-secure-code/cargo-auditable
+const (
+	auditableSection      = ".dep-v0"
+	auditableMachOSection = "__dep_v0" // Mach-O section names are limited to 16 characters
+	// upper limit for decompressed data, protects from decompression bombs
+	maxAuditableSize = 8 << 20
+)
+
+// auditablePackage is an entry of cargo-auditable dependency list
+type auditablePackage struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Source       string `json:"source"` // crates.io, git, local, registry or other
+	Kind         string `json:"kind"`   // runtime (default) or build
+	Dependencies []int  `json:"dependencies"`
+	Root         bool   `json:"root"`
+}
+
+type auditableInfo struct {
+	Packages []auditablePackage `json:"packages"`
+}
+
+// auditableSectionData returns raw content of cargo-auditable section, or nil if executable has none
+func auditableSectionData(filename string) ([]byte, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if ef, err := elf.NewFile(f); err == nil {
+		if s := ef.Section(auditableSection); s != nil {
+			return s.Data()
+		}
+		return nil, nil
+	}
+	if pf, err := pe.NewFile(f); err == nil {
+		if s := pf.Section(auditableSection); s != nil {
+			return s.Data()
+		}
+		return nil, nil
+	}
+	if mf, err := macho.NewFile(f); err == nil {
+		if s := mf.Section(auditableMachOSection); s != nil {
+			return s.Data()
+		}
+		return nil, nil
+	}
+	return nil, nil
+}
+
+func hasAuditableData(filename string) bool {
+	data, err := auditableSectionData(filename)
+	return err == nil && len(data) > 0
+}
+
+// parseAuditable decompresses and decodes cargo-auditable section content
+func parseAuditable(data []byte) (*auditableInfo, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	buf, err := ioutil.ReadAll(io.LimitReader(zr, maxAuditableSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) > maxAuditableSize {
+		return nil, fmt.Errorf("dependency information exceeds %d bytes", maxAuditableSize)
+	}
+	var info auditableInfo
+	if err := json.Unmarshal(buf, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// auditableDeps extracts dependencies from executable, built with cargo-auditable. Embedded data has no
+// checksums, so crates are looked up in Cargo registry cache, and the ones missing there are skipped.
+// Build-time dependencies aren't included into executable and are ignored
+func auditableDeps(filename string, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	data, err := auditableSectionData(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", filename, err)
+	}
+	info, err := parseAuditable(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode dependency information in %s: %w", filename, err)
+	}
+
+	return info.dependencies(filename, output), nil
+}
+
+// dependencies builds dependency graph from cargo-auditable package list, where dependencies are
+// referenced by the index in the list
+func (info *auditableInfo) dependencies(root string, output artifact.OutputOptions) []artifact.Dependency {
+	g := depgraph.NewGraph(root, "")
+	included := make([]bool, len(info.Packages))
+	missing := 0
+	for i, p := range info.Packages {
+		if p.Root || p.Kind == "build" || p.Source == "local" {
+			continue
+		}
+		hash := crateHash(p.Name, p.Version)
+		if hash == "" {
+			missing++
+			continue
+		}
+		included[i] = true
+		g.NewNode(p.Name, p.Version, &artifact.Dependency{
+			Name:     p.Name,
+			Version:  p.Version,
+			Hash:     hash,
+			HashType: artifact.HashSHA256,
+			Kind:     AssetType,
+		})
+	}
+	if missing > 0 {
+		artifact.Warnf(output, "%d crates are missing in Cargo registry cache and were skipped\n", missing)
+	}
+
+	for i, p := range info.Packages {
+		parent := g.Root
+		if !p.Root && p.Source != "local" {
+			if !included[i] {
+				continue
+			}
+			parent = g.Node(p.Name, p.Version)
+		}
+		for _, idx := range p.Dependencies {
+			if idx < 0 || idx >= len(info.Packages) || !included[idx] {
+				continue
+			}
+			child := info.Packages[idx]
+			g.AddChild(parent, child.Name, child.Version, nil)
+		}
+	}
+
+	return g.FlatDeps()
+}
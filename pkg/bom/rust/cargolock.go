@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package rust
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/depgraph"
+)
+
+const (
+	gitSourcePrefix = "git+"
+	// Cargo.lock v1 keeps checksums in metadata section with 'checksum <name> <version> (<source>)' keys
+	metadataChecksumPrefix = "checksum "
+)
+
+// lockPackage is '[[package]]' entry of Cargo.lock
+type lockPackage struct {
+	name         string
+	version      string
+	source       string
+	checksum     string
+	dependencies []string // '<name>', '<name> <version>' or '<name> <version> (<source>)'
+}
+
+// lockDeps parses Cargo.lock. Packages without source are workspace members, and the packages they require
+// are direct dependencies. Packages from git are identified by commit hash
+func lockDeps(filename string, output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	tree, err := toml.LoadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", filename, err)
+	}
+	packages := parseCargoLock(tree)
+
+	g := depgraph.NewGraph(filename, "")
+	byName := make(map[string][]*lockPackage, len(packages))
+	for i := range packages {
+		p := &packages[i]
+		byName[p.name] = append(byName[p.name], p)
+		if p.source == "" {
+			continue // workspace member
+		}
+		hash, hashType := p.checksum, artifact.HashSHA256
+		if hash == "" && strings.HasPrefix(p.source, gitSourcePrefix) {
+			// 'git+<url>?<reference>#<commit hash>'
+			if i := strings.LastIndex(p.source, "#"); i >= 0 {
+				hash, hashType = p.source[i+1:], artifact.HashSHA1
+			}
+		}
+		if hash == "" {
+			continue // unsupported source
+		}
+		g.NewNode(p.name, p.version, &artifact.Dependency{
+			Name:     p.name,
+			Version:  p.version,
+			Hash:     hash,
+			HashType: hashType,
+			Kind:     AssetType,
+		})
+	}
+
+	for _, p := range packages {
+		parent := g.Root
+		if p.source != "" {
+			parent = g.Node(p.name, p.version)
+		}
+		for _, ref := range p.dependencies {
+			child := resolveRef(byName, ref)
+			if child == nil || child.source == "" {
+				continue
+			}
+			g.AddChild(parent, child.name, child.version, nil)
+		}
+	}
+
+	return g.FlatDeps(), nil
+}
+
+// parseCargoLock extracts packages from Cargo.lock of any version
+func parseCargoLock(tree *toml.Tree) []lockPackage {
+	trees, _ := tree.Get("package").([]*toml.Tree)
+	res := make([]lockPackage, 0, len(trees))
+	for _, t := range trees {
+		p := lockPackage{
+			name:     stringValue(t, "name"),
+			version:  stringValue(t, "version"),
+			source:   stringValue(t, "source"),
+			checksum: stringValue(t, "checksum"),
+		}
+		if deps, ok := t.Get("dependencies").([]interface{}); ok {
+			for _, d := range deps {
+				if s, ok := d.(string); ok {
+					p.dependencies = append(p.dependencies, s)
+				}
+			}
+		}
+		res = append(res, p)
+	}
+
+	if metadata, ok := tree.Get("metadata").(*toml.Tree); ok {
+		checksums := make(map[string]string)
+		for k, v := range metadata.ToMap() {
+			if s, ok := v.(string); ok && strings.HasPrefix(k, metadataChecksumPrefix) {
+				fields := strings.Fields(strings.TrimPrefix(k, metadataChecksumPrefix))
+				if len(fields) >= 2 {
+					checksums[fields[0]+" "+fields[1]] = s
+				}
+			}
+		}
+		for i := range res {
+			if res[i].checksum == "" {
+				res[i].checksum = checksums[res[i].name+" "+res[i].version]
+			}
+		}
+	}
+
+	return res
+}
+
+// resolveRef finds the package, referenced by dependency entry. Version is omitted from reference, if lock
+// file contains only one package with such name
+func resolveRef(byName map[string][]*lockPackage, ref string) *lockPackage {
+	fields := strings.Fields(ref)
+	if len(fields) == 0 {
+		return nil
+	}
+	candidates := byName[fields[0]]
+	if len(fields) == 1 {
+		if len(candidates) == 1 {
+			return candidates[0]
+		}
+		return nil
+	}
+	for _, p := range candidates {
+		if p.version == fields[1] {
+			return p
+		}
+	}
+	return nil
+}
+
+func stringValue(t *toml.Tree, key string) string {
+	s, _ := t.Get(key).(string)
+	return s
+}
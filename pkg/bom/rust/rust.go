@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package rust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+const AssetType = "rustcom"
+
+const (
+	cargoLock     = "Cargo.lock"
+	cargoManifest = "Cargo.toml"
+)
+
+// rustArtifact implements Artifact interface for Cargo projects and executables, built with cargo-auditable
+type rustArtifact struct {
+	artifact.GenericArtifact
+	path    string
+	resolve func(string, artifact.OutputOptions) ([]artifact.Dependency, error)
+	source  string
+}
+
+// New returns new Artifact object, or nil if filename doesn't refer to Cargo.lock, directory containing it,
+// or executable with dependency information embedded by cargo-auditable
+func New(filename string) artifact.Artifact {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil
+	}
+
+	if fi.IsDir() {
+		if lock := filepath.Join(filename, cargoLock); exists(lock) {
+			return &rustArtifact{path: filename, source: lock, resolve: lockDeps}
+		}
+		return nil
+	}
+
+	switch filepath.Base(filename) {
+	case cargoLock:
+		return &rustArtifact{path: filename, source: filename, resolve: lockDeps}
+	case cargoManifest:
+		if lock := filepath.Join(filepath.Dir(filename), cargoLock); exists(lock) {
+			return &rustArtifact{path: filename, source: lock, resolve: lockDeps}
+		}
+		return nil
+	}
+
+	if !hasAuditableData(filename) {
+		return nil
+	}
+	return &rustArtifact{path: filename, source: filename, resolve: auditableDeps}
+}
+
+func (a rustArtifact) Type() string {
+	return AssetType
+}
+
+func (a rustArtifact) Path() string {
+	return a.path
+}
+
+func (a *rustArtifact) ResolveDependencies(output artifact.OutputOptions) ([]artifact.Dependency, error) {
+	if a.Deps != nil {
+		return a.Deps, nil
+	}
+
+	deps, err := a.resolve(a.source, output)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Deps = deps
+	return deps, nil
+}
+
+// registryCache returns location of downloaded crates, '<CARGO_HOME>/registry/cache'
+func registryCache() string {
+	home := os.Getenv("CARGO_HOME")
+	if home == "" {
+		dir, err := homedir.Dir()
+		if err != nil {
+			return ""
+		}
+		home = filepath.Join(dir, ".cargo")
+	}
+	return filepath.Join(home, "registry", "cache")
+}
+
+// crateHash returns hex-encoded SHA-256 of the crate from Cargo registry cache, the same value as
+// Cargo.lock checksum. Returns empty string if crate isn't in the cache
+func crateHash(name, version string) string {
+	matches, _ := filepath.Glob(filepath.Join(registryCache(), "*", name+"-"+version+".crate"))
+	for _, m := range matches {
+		f, err := os.Open(m)
+		if err != nil {
+			continue
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err == nil {
+			return hex.EncodeToString(h.Sum(nil))
+		}
+	}
+	return ""
+}
+
+func exists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
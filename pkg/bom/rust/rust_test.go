@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package rust
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+const testCargoLock = `# This file is automatically @generated by Cargo.
+# It is not intended for manual editing.
+version = 3
+
+[[package]]
+name = "app"
+version = "0.1.0"
+dependencies = [
+ "log",
+ "rand 0.8.5",
+ "tool",
+]
+
+[[package]]
+name = "tool"
+version = "0.1.0"
+dependencies = [
+ "rand 0.7.3",
+]
+
+[[package]]
+name = "log"
+version = "0.4.14"
+source = "git+https://github.com/rust-lang/log?branch=master#0f0ad3fb1f5e2ad3f5a1d6b1a6e0ed9fbbd5e9c6"
+
+[[package]]
+name = "rand"
+version = "0.8.5"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "34af8d1a0e25924bc5b7c43c079c942339d8f0a8b57c39049bef581b46327404"
+dependencies = [
+ "libc",
+]
+
+[[package]]
+name = "rand"
+version = "0.7.3"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "6a6b1679d49b24bbfe0c803429aa1874472f50d9b363131f0e89fc356b544d03"
+
+[[package]]
+name = "libc"
+version = "0.2.112"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "1b03d17f364a3a042d5e5d46b053bbbf82c92c9430c592dd4c064dc6ee997125"
+`
+
+func checkDeps(t *testing.T, expected map[string]artifact.Dependency, deps []artifact.Dependency) {
+	assert.Len(t, deps, len(expected))
+	for _, d := range deps {
+		e, ok := expected[d.Name+" "+d.Version]
+		if !assert.True(t, ok, d.Name) {
+			continue
+		}
+		assert.Equal(t, e.Hash, d.Hash)
+		assert.Equal(t, e.HashType, d.HashType)
+		assert.Equal(t, e.Type, d.Type, d.Name)
+		assert.Equal(t, AssetType, d.Kind)
+	}
+}
+
+func TestCargoLock(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, cargoLock), []byte(testCargoLock), 0644))
+
+	a := New(dir)
+	assert.NotNil(t, a)
+	assert.Equal(t, AssetType, a.Type())
+	deps, err := a.ResolveDependencies(artifact.Silent)
+	assert.NoError(t, err)
+
+	checkDeps(t, map[string]artifact.Dependency{
+		"log 0.4.14": {Hash: "0f0ad3fb1f5e2ad3f5a1d6b1a6e0ed9fbbd5e9c6", HashType: artifact.HashSHA1, Type: artifact.DepDirect},
+		"rand 0.8.5": {Hash: "34af8d1a0e25924bc5b7c43c079c942339d8f0a8b57c39049bef581b46327404", HashType: artifact.HashSHA256, Type: artifact.DepDirect},
+		// required by workspace member
+		"rand 0.7.3":   {Hash: "6a6b1679d49b24bbfe0c803429aa1874472f50d9b363131f0e89fc356b544d03", HashType: artifact.HashSHA256, Type: artifact.DepDirect},
+		"libc 0.2.112": {Hash: "1b03d17f364a3a042d5e5d46b053bbbf82c92c9430c592dd4c064dc6ee997125", HashType: artifact.HashSHA256, Type: artifact.DepTransient},
+	}, deps)
+}
+
+func TestAuditable(t *testing.T) {
+	cargoHome := t.TempDir()
+	t.Setenv("CARGO_HOME", cargoHome)
+	cache := filepath.Join(cargoHome, "registry", "cache", "github.com-1ecc6299db9ec823")
+	assert.NoError(t, os.MkdirAll(cache, 0755))
+	crates := map[string][]byte{"rand-0.8.5.crate": []byte("rand"), "libc-0.2.112.crate": []byte("libc")}
+	for name, content := range crates {
+		assert.NoError(t, os.WriteFile(filepath.Join(cache, name), content, 0644))
+	}
+	sum := func(b []byte) string {
+		h := sha256.Sum256(b)
+		return hex.EncodeToString(h[:])
+	}
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	_, err := zw.Write([]byte(`{"packages":[
+		{"name":"app","version":"0.1.0","source":"local","dependencies":[1,3,4],"root":true},
+		{"name":"rand","version":"0.8.5","source":"crates.io","dependencies":[2]},
+		{"name":"libc","version":"0.2.112","source":"crates.io"},
+		{"name":"cc","version":"1.0.72","source":"crates.io","kind":"build"},
+		{"name":"missing","version":"1.0.0","source":"crates.io"}
+	]}`))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	info, err := parseAuditable(buf.Bytes())
+	assert.NoError(t, err)
+	checkDeps(t, map[string]artifact.Dependency{
+		"rand 0.8.5":   {Hash: sum(crates["rand-0.8.5.crate"]), HashType: artifact.HashSHA256, Type: artifact.DepDirect},
+		"libc 0.2.112": {Hash: sum(crates["libc-0.2.112.crate"]), HashType: artifact.HashSHA256, Type: artifact.DepTransient},
+	}, info.dependencies("app", artifact.Silent))
+
+	_, err = parseAuditable([]byte("not compressed"))
+	assert.Error(t, err)
+}
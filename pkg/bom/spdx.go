@@ -9,15 +9,19 @@
 package bom
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/license"
 )
 
 const (
@@ -25,106 +29,353 @@ const (
 	mandatory
 )
 
+const (
+	spdxVersion    = "SPDX-2.3"
+	spdxDocumentID = "SPDXRef-DOCUMENT"
+	spdxAssetID    = "SPDXRef-Asset"
+	noAssertionStr = "NOASSERTION"
+)
+
+// SPDX checksum algorithm names
+var spdxChecksumAlgorithms = map[artifact.HashType]string{
+	artifact.HashSHA1:   "SHA1",
+	artifact.HashSHA224: "SHA224",
+	artifact.HashSHA256: "SHA256",
+	artifact.HashSHA384: "SHA384",
+	artifact.HashSHA512: "SHA512",
+	artifact.HashMD2:    "MD2",
+	artifact.HashMD4:    "MD4",
+	artifact.HashMD5:    "MD5",
+	artifact.HashMD6:    "MD6",
+}
+
+// headerLine describes a single tag of SPDX document header. Value is rendered in tag-value format,
+// set function puts it into JSON document
 type headerLine struct {
 	tag      string
 	presense int
 	fn       func(artifact.Artifact) (string, error)
+	set      func(*spdxDocument, string)
 }
 
 var headerContent = []headerLine{
 	{"SPDXVersion", mandatory, func(artifact.Artifact) (string, error) {
-		return "SPDX-2.2", nil
-	}},
+		return spdxVersion, nil
+	}, func(doc *spdxDocument, v string) { doc.SPDXVersion = v }},
 	{"DataLicense", mandatory, func(artifact.Artifact) (string, error) {
 		return "CC0-1.0", nil
-	}},
+	}, func(doc *spdxDocument, v string) { doc.DataLicense = v }},
 	{"SPDXID", mandatory, func(artifact.Artifact) (string, error) {
-		return "SPDXRef-DOCUMENT", nil
-	}},
-	{"DocumentName", mandatory, func(p artifact.Artifact) (string, error) {
-		path, err := filepath.Abs(p.Path())
-		if err != nil {
-			return "", err
-		}
-		return filepath.Base(path), nil
-	}},
+		return spdxDocumentID, nil
+	}, func(doc *spdxDocument, v string) { doc.SPDXID = v }},
+	{"DocumentName", mandatory, documentName, func(doc *spdxDocument, v string) { doc.Name = v }},
 	{"DocumentNamespace", mandatory, func(p artifact.Artifact) (string, error) {
 		path, err := filepath.Abs(p.Path())
 		if err != nil {
 			return "", err
 		}
 		return "http://spdx.org/spdxdocs/" + filepath.Base(path) + "-" + uuid.NewString(), nil
-	}},
+	}, func(doc *spdxDocument, v string) { doc.DocumentNamespace = v }},
 	{"Creator", mandatory, func(artifact.Artifact) (string, error) {
 		return "Tool: Codenotary cas", nil
-	}},
+	}, func(doc *spdxDocument, v string) { doc.CreationInfo.Creators = append(doc.CreationInfo.Creators, v) }},
 	{"Created", mandatory, func(artifact.Artifact) (string, error) {
 		return time.Now().UTC().Format(time.RFC3339), nil
-	}},
+	}, func(doc *spdxDocument, v string) { doc.CreationInfo.Created = v }},
 }
 
+// documentName returns the name of the asset, which is used as the name of the document and of the root package
+func documentName(a artifact.Artifact) (string, error) {
+	path, err := filepath.Abs(a.Path())
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(path), nil
+}
+
+// componentLine describes a single tag of SPDX package. fn sets typed value of the tag in the package, which is
+// output to JSON document as is, text renders it in tag-value format - a line for each returned value, and parse
+// reads the value of tag-value line into the package
 type componentLine struct {
 	tag      string
 	presense int
-	fn       func(artifact.Artifact, artifact.Dependency, int) (string, error)
+	fn       func(*spdxPackage, artifact.Artifact, artifact.Dependency, int) error
+	text     func(*spdxPackage) []string
+	parse    func(*spdxPackage, string)
 }
 
 var componentContent = []componentLine{
-	{"PackageName", mandatory, func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
-		return d.Name, nil
-	}},
-	{"SPDXID", mandatory, func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
-		return "SPDXRef-Package-" + strconv.Itoa(seq), nil
-	}},
-	{"PackageVersion", optional, func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
-		return d.Version, nil
-	}},
-	{"PackageDownloadLocation", mandatory, noAssertion},
+	stringTag("PackageName", mandatory, packageNameField, packageName),
+	stringTag("SPDXID", mandatory, packageIDField, func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
+		return spdxPackageID(seq), nil
+	}),
+	stringTag("PackageVersion", optional, func(p *spdxPackage) *string { return &p.VersionInfo },
+		func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
+			return d.Version, nil
+		}),
+	stringTag("PackageSupplier", mandatory, func(p *spdxPackage) *string { return &p.Supplier },
+		func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
+			return spdxSupplier(d.Supplier), nil
+		}),
+	stringTag("PackageDownloadLocation", mandatory, downloadLocationField, noAssertion),
 	// FilesAnalysed is optional, but by default it is true, which requires presence of many other fields
-	{"FilesAnalyzed", mandatory, func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
-		return "false", nil
-	}},
-	{"PackageChecksum", mandatory, func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
-		return artifact.HashTypeName(d.HashType) + ": " + d.Hash, nil
-	}},
-	{"PackageSourceInfo", optional, func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
-		return "<text>" + Purl(a, d) + "</text>", nil
-	}},
-	{"PackageLicenseConcluded", mandatory, func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
-		if d.License != "" {
-			return d.License, nil
-		}
-		return "NOASSERTION", nil
-	}},
-	{"PackageLicenseDeclared", mandatory, noAssertion},
-	{"PackageCopyrightText", mandatory, noAssertion},
-	{"PackageComment", optional, func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
-		text := "<text>"
-		l := artifact.TrustLevelName(d.TrustLevel)
-		if l != "" {
-			text += l + ", "
-		}
-		text += DepLinkType(a, d) + ", " + DepType(d) + "</text>"
-		return text, nil
-	}},
+	filesAnalyzedTag,
+	checksumTag,
+	externalRefTag,
+	stringTag("PackageLicenseConcluded", mandatory, licenseConcludedField, packageLicense),
+	stringTag("PackageLicenseDeclared", mandatory, licenseDeclaredField, packageLicense),
+	freeTextTag("PackageLicenseComments", func(p *spdxPackage) *string { return &p.LicenseComments },
+		func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
+			if d.LicenseDeclared == "" {
+				return "", nil
+			}
+			return "Declared license: " + d.LicenseDeclared, nil
+		}),
+	stringTag("PackageCopyrightText", mandatory, copyrightTextField, noAssertion),
+	freeTextTag("PackageComment", func(p *spdxPackage) *string { return &p.Comment },
+		func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
+			text := ""
+			l := artifact.TrustLevelName(d.TrustLevel)
+			if l != "" {
+				text += l + ", "
+			}
+			return text + DepLinkType(a, d) + ", " + DepType(d), nil
+		}),
+}
+
+// rootContent describes the asset itself, its checksum is the notarized hash, if it is known
+var rootContent = []componentLine{
+	stringTag("PackageName", mandatory, packageNameField, packageName),
+	stringTag("SPDXID", mandatory, packageIDField, func(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
+		return spdxAssetID, nil
+	}),
+	stringTag("PackageDownloadLocation", mandatory, downloadLocationField, noAssertion),
+	filesAnalyzedTag,
+	checksumTag,
+	stringTag("PackageLicenseConcluded", mandatory, licenseConcludedField, noAssertion),
+	stringTag("PackageLicenseDeclared", mandatory, licenseDeclaredField, noAssertion),
+	stringTag("PackageCopyrightText", mandatory, copyrightTextField, noAssertion),
+}
+
+// stringTag describes tag with string value, stored in the field of the package
+func stringTag(tag string, presense int, field func(*spdxPackage) *string,
+	fn func(artifact.Artifact, artifact.Dependency, int) (string, error)) componentLine {
+	return componentLine{
+		tag:      tag,
+		presense: presense,
+		fn: func(p *spdxPackage, a artifact.Artifact, d artifact.Dependency, seq int) (err error) {
+			*field(p), err = fn(a, d, seq)
+			return err
+		},
+		text: func(p *spdxPackage) []string {
+			if *field(p) == "" {
+				return nil
+			}
+			return []string{*field(p)}
+		},
+		parse: func(p *spdxPackage, v string) { *field(p) = v },
+	}
+}
+
+// freeTextTag describes optional tag with free form text, which may span several lines, so it is wrapped into
+// <text></text> in tag-value format
+func freeTextTag(tag string, field func(*spdxPackage) *string,
+	fn func(artifact.Artifact, artifact.Dependency, int) (string, error)) componentLine {
+	line := stringTag(tag, optional, field, fn)
+	line.text = func(p *spdxPackage) []string {
+		if *field(p) == "" {
+			return nil
+		}
+		return []string{"<text>" + *field(p) + "</text>"}
+	}
+	line.parse = func(p *spdxPackage, v string) {
+		*field(p) = strings.TrimSuffix(strings.TrimPrefix(v, "<text>"), "</text>")
+	}
+	return line
+}
+
+var filesAnalyzedTag = componentLine{
+	tag:      "FilesAnalyzed",
+	presense: mandatory,
+	fn: func(p *spdxPackage, a artifact.Artifact, d artifact.Dependency, seq int) error {
+		p.FilesAnalyzed = false
+		return nil
+	},
+	text:  func(p *spdxPackage) []string { return []string{strconv.FormatBool(p.FilesAnalyzed)} },
+	parse: func(p *spdxPackage, v string) { p.FilesAnalyzed = v == "true" },
+}
+
+// checksumTag has checksum in '<algorithm>: <value>' form in tag-value format. Checksum is omitted, if algorithm
+// isn't supported by SPDX
+var checksumTag = componentLine{
+	tag:      "PackageChecksum",
+	presense: optional,
+	fn: func(p *spdxPackage, a artifact.Artifact, d artifact.Dependency, seq int) error {
+		algorithm, ok := spdxChecksumAlgorithms[d.HashType]
+		if ok && d.Hash != "" {
+			p.Checksums = append(p.Checksums, spdxChecksum{Algorithm: algorithm, ChecksumValue: d.Hash})
+		}
+		return nil
+	},
+	text: func(p *spdxPackage) []string {
+		res := make([]string, len(p.Checksums))
+		for i, c := range p.Checksums {
+			res[i] = c.Algorithm + ": " + c.ChecksumValue
+		}
+		return res
+	},
+	parse: func(p *spdxPackage, v string) {
+		if fields := strings.SplitN(v, ": ", 2); len(fields) == 2 {
+			p.Checksums = append(p.Checksums, spdxChecksum{Algorithm: fields[0], ChecksumValue: fields[1]})
+		}
+	},
+}
+
+// externalRefTag has package URL and all candidate CPE names of the package, in
+// '<category> <type> <locator>' form in tag-value format
+var externalRefTag = componentLine{
+	tag:      "ExternalRef",
+	presense: optional,
+	fn: func(p *spdxPackage, a artifact.Artifact, d artifact.Dependency, seq int) error {
+		p.ExternalRefs = append(p.ExternalRefs,
+			spdxExternalRef{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: Purl(a, d)})
+		for _, cpe := range d.CPEs {
+			p.ExternalRefs = append(p.ExternalRefs,
+				spdxExternalRef{ReferenceCategory: "SECURITY", ReferenceType: "cpe23Type", ReferenceLocator: cpe})
+		}
+		return nil
+	},
+	text: func(p *spdxPackage) []string {
+		res := make([]string, len(p.ExternalRefs))
+		for i, r := range p.ExternalRefs {
+			res[i] = r.ReferenceCategory + " " + r.ReferenceType + " " + r.ReferenceLocator
+		}
+		return res
+	},
+	parse: func(p *spdxPackage, v string) {
+		if fields := strings.SplitN(v, " ", 3); len(fields) == 3 {
+			p.ExternalRefs = append(p.ExternalRefs,
+				spdxExternalRef{ReferenceCategory: fields[0], ReferenceType: fields[1], ReferenceLocator: fields[2]})
+		}
+	},
 }
 
 func noAssertion(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
-	return "NOASSERTION", nil
+	return noAssertionStr, nil
 }
 
-// Output info about package and its components in SPDX text (tag:value) format, according to
-// SPDX spec 2.2: https://spdx.dev/wp-content/uploads/sites/41/2020/08/SPDX-specification-2-2.pdf
-func OutputSpdxText(a artifact.Artifact, filename string) error {
-	deps := a.Dependencies()
-	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
+// packageLicense returns SPDX license expression of the package, as normalized from the declared one
+func packageLicense(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
+	if d.License != "" {
+		return d.License, nil
 	}
+	return noAssertionStr, nil
+}
 
-	defer f.Close()
+func packageName(a artifact.Artifact, d artifact.Dependency, seq int) (string, error) {
+	return d.Name, nil
+}
 
-	// SPDX header
+// spdxSupplier formats supplier as SPDX organization, converting optional e-mail in angle brackets to the
+// contact in parentheses, f.e. 'Organization: Debian QA Group (packages@qa.debian.org)'
+func spdxSupplier(supplier string) string {
+	if supplier == "" {
+		return noAssertionStr
+	}
+	supplier = strings.NewReplacer("<", "(", ">", ")").Replace(supplier)
+	return "Organization: " + supplier
+}
+
+func spdxPackageID(seq int) string {
+	return "SPDXRef-Package-" + strconv.Itoa(seq)
+}
+
+func packageNameField(p *spdxPackage) *string {
+	return &p.Name
+}
+
+func packageIDField(p *spdxPackage) *string {
+	return &p.SPDXID
+}
+
+func downloadLocationField(p *spdxPackage) *string {
+	return &p.DownloadLocation
+}
+
+func licenseConcludedField(p *spdxPackage) *string {
+	return &p.LicenseConcluded
+}
+
+func licenseDeclaredField(p *spdxPackage) *string {
+	return &p.LicenseDeclared
+}
+
+func copyrightTextField(p *spdxPackage) *string {
+	return &p.CopyrightText
+}
+
+// spdxDocument and related types are SPDX 2.3 JSON document elements, see
+// https://github.com/spdx/spdx-spec/blob/development/v2.3/schemas/spdx-schema.json
+type spdxDocument struct {
+	SPDXID            string             `json:"SPDXID"`
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	DocumentDescribes []string           `json:"documentDescribes,omitempty"` // deprecated, used by SPDX 2.2
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+
+	HasExtractedLicensingInfos []spdxExtractedLicense `json:"hasExtractedLicensingInfos,omitempty"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	Supplier         string            `json:"supplier,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	LicenseComments  string            `json:"licenseComments,omitempty"`
+	CopyrightText    string            `json:"copyrightText"`
+	Comment          string            `json:"comment,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxExtractedLicense struct {
+	LicenseID     string `json:"licenseId"`
+	ExtractedText string `json:"extractedText"`
+	Name          string `json:"name"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// headerValues calls fn for every header tag with its value. Optional tags without value are skipped
+func headerValues(a artifact.Artifact, fn func(line headerLine, value string) error) error {
 	for _, line := range headerContent {
 		value, err := line.fn(a)
 		if err != nil {
@@ -139,38 +390,226 @@ func OutputSpdxText(a artifact.Artifact, filename string) error {
 			}
 			continue // optional
 		}
-		if _, err = fmt.Fprintf(f, "%s: %s\n", line.tag, value); err != nil {
+		if err = fn(line, value); err != nil {
 			return err
 		}
 	}
+	return nil
+}
+
+// newSpdxPackage describes the component as SPDX package. Optional tags, which cannot be evaluated, are skipped
+func newSpdxPackage(lines []componentLine, a artifact.Artifact, dep artifact.Dependency, seq int) (*spdxPackage, error) {
+	var p spdxPackage
+	for _, line := range lines {
+		err := line.fn(&p, a, dep, seq)
+		if err != nil {
+			if line.presense == mandatory {
+				return nil, fmt.Errorf("cannot get value for tag %s for component %s: %w", line.tag, dep.Name, err)
+			}
+			continue // optional tag - ignore error
+		}
+		if line.presense == mandatory && len(line.text(&p)) == 0 {
+			return nil, fmt.Errorf("no value found for mandatory component tag %s for component %s", line.tag, dep.Name)
+		}
+	}
+	return &p, nil
+}
+
+// rootDependency represents the asset itself as a dependency, with notarized hash, if it is known
+func rootDependency(a artifact.Artifact, hash string) (artifact.Dependency, error) {
+	name, err := documentName(a)
+	if err != nil {
+		return artifact.Dependency{}, fmt.Errorf("cannot get asset name: %w", err)
+	}
+	root := artifact.Dependency{Name: name, Hash: hash}
+	if hash != "" {
+		root.HashType = artifact.HashSHA256
+	}
+	return root, nil
+}
+
+// Output info about package and its components in SPDX text (tag:value) format, according to
+// SPDX spec 2.3: https://spdx.github.io/spdx-spec/v2.3/
+// The asset itself is described by the root package, which has the notarized hash, if it is known
+func OutputSpdxText(a artifact.Artifact, hash, filename string) error {
+	deps := a.Dependencies()
+	root, err := rootDependency(a, hash)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	writeTag := func(tag, value string) error {
+		_, err := fmt.Fprintf(f, "%s: %s\n", tag, value)
+		return err
+	}
+
+	// SPDX header
+	err = headerValues(a, func(line headerLine, value string) error {
+		return writeTag(line.tag, value)
+	})
+	if err != nil {
+		return err
+	}
 
 	if _, err = fmt.Fprintf(f, "\n##### Software components\n\n"); err != nil {
 		return err
 	}
 
-	for i, dep := range deps {
-		for _, line := range componentContent {
-			value, err := line.fn(a, dep, i+1)
-			if err != nil {
-				if line.presense == mandatory {
-					return fmt.Errorf("cannot get value for tag %s for component %s: %w", line.tag, dep.Name, err)
-				}
-				continue // optional tag - ignore error
-			}
-			if value == "" {
-				if line.presense == mandatory {
-					return fmt.Errorf("no value found for mandatory component tag %s for component %s", line.tag, dep.Name)
+	writeComponent := func(lines []componentLine, dep artifact.Dependency, seq int) error {
+		p, err := newSpdxPackage(lines, a, dep, seq)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			for _, value := range line.text(p) {
+				if err = writeTag(line.tag, value); err != nil {
+					return err
 				}
-				continue // optional
 			}
-			if _, err = fmt.Fprintf(f, "%s: %s\n", line.tag, value); err != nil {
+		}
+		_, err = fmt.Fprintln(f)
+		return err
+	}
+	if err = writeComponent(rootContent, root, 0); err != nil {
+		return err
+	}
+	for i, dep := range deps {
+		if err = writeComponent(componentContent, dep, i+1); err != nil {
+			return err
+		}
+	}
+
+	if extracted := spdxExtractedLicenses(deps); len(extracted) > 0 {
+		if _, err = fmt.Fprintf(f, "##### Other licenses\n\n"); err != nil {
+			return err
+		}
+		for _, l := range extracted {
+			_, err = fmt.Fprintf(f, "LicenseID: %s\nExtractedText: <text>%s</text>\nLicenseName: %s\n\n",
+				l.LicenseID, l.ExtractedText, l.Name)
+			if err != nil {
 				return err
 			}
 		}
-		if _, err = fmt.Fprintln(f); err != nil {
+	}
+
+	if _, err = fmt.Fprintf(f, "##### Relationships\n\n"); err != nil {
+		return err
+	}
+	for _, r := range spdxRelationships(a, deps) {
+		if err = writeTag("Relationship", r[0]+" "+r[1]+" "+r[2]); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// OutputSpdxJSON outputs the same information as OutputSpdxText in SPDX 2.3 JSON format
+func OutputSpdxJSON(a artifact.Artifact, hash, filename string) error {
+	doc, err := convertToSpdx(a, hash)
+	if err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, buf, 0644)
+}
+
+func convertToSpdx(a artifact.Artifact, hash string) (*spdxDocument, error) {
+	deps := a.Dependencies()
+	root, err := rootDependency(a, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &spdxDocument{Packages: make([]spdxPackage, 0, len(deps)+1)}
+	err = headerValues(a, func(line headerLine, value string) error {
+		line.set(doc, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	addPackage := func(lines []componentLine, dep artifact.Dependency, seq int) error {
+		p, err := newSpdxPackage(lines, a, dep, seq)
+		if err != nil {
+			return err
+		}
+		doc.Packages = append(doc.Packages, *p)
+		return nil
+	}
+	if err = addPackage(rootContent, root, 0); err != nil {
+		return nil, err
+	}
+	for i, dep := range deps {
+		if err = addPackage(componentContent, dep, i+1); err != nil {
+			return nil, err
+		}
+	}
+
+	doc.HasExtractedLicensingInfos = spdxExtractedLicenses(deps)
+	for _, r := range spdxRelationships(a, deps) {
+		doc.Relationships = append(doc.Relationships,
+			spdxRelationship{SPDXElementID: r[0], RelationshipType: r[1], RelatedSPDXElement: r[2]})
+	}
+	return doc, nil
+}
+
+// spdxExtractedLicenses returns licenses, which aren't in SPDX license list, but are referenced by packages.
+// Their text is unknown, so the license, declared by the first package, referencing it, is used instead
+func spdxExtractedLicenses(deps []artifact.Dependency) []spdxExtractedLicense {
+	var res []spdxExtractedLicense
+	seen := make(map[string]bool)
+	for _, dep := range deps {
+		e, err := license.Parse(dep.License)
+		if err != nil {
+			continue
+		}
+		for _, l := range e.Licenses() {
+			if !strings.HasPrefix(l.License, license.LicenseRefPrefix) || seen[l.License] {
+				continue
+			}
+			seen[l.License] = true
+			text := dep.LicenseDeclared
+			if text == "" {
+				text = dep.License
+			}
+			res = append(res, spdxExtractedLicense{
+				LicenseID:     l.License,
+				ExtractedText: text,
+				Name:          strings.TrimPrefix(l.License, license.LicenseRefPrefix),
+			})
+		}
+	}
+	return res
+}
+
+// spdxRelationships returns relationships between the document, the asset and its dependencies. Asset contains
+// statically linked dependencies and depends on other direct dependencies. Each relationship is a triplet of
+// element ID, relationship type and related element ID
+func spdxRelationships(a artifact.Artifact, deps []artifact.Dependency) [][3]string {
+	res := [][3]string{{spdxDocumentID, "DESCRIBES", spdxAssetID}}
+	for i, dep := range deps {
+		if DepLinkType(a, dep) == staticLinkage {
+			res = append(res, [3]string{spdxAssetID, "CONTAINS", spdxPackageID(i + 1)})
+		} else if dep.Type == artifact.DepDirect {
+			res = append(res, [3]string{spdxAssetID, "DEPENDS_ON", spdxPackageID(i + 1)})
+		}
+	}
+	index := newDepIndex(deps)
+	for i, dep := range deps {
+		for _, j := range index.required(dep) {
+			res = append(res, [3]string{spdxPackageID(i + 1), "DEPENDS_ON", spdxPackageID(j + 1)})
+		}
+	}
+	return res
+}
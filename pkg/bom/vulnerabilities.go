@@ -0,0 +1,29 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package bom
+
+import (
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/bom/docker"
+	"github.com/codenotary/cas/pkg/bom/osv"
+)
+
+// MatchVulnerabilities sets known vulnerabilities of artifact dependencies, matching them by purl. It returns
+// the highest severity of found vulnerabilities
+func MatchVulnerabilities(a artifact.Artifact, db *osv.Database) artifact.Severity {
+	res := artifact.SeverityUnknown
+	deps := a.Dependencies()
+	for i := range deps { // dependencies are updated in place, so use the index
+		deps[i].Vulnerabilities = db.Match(Purl(a, deps[i]), deps[i].Properties[docker.PropSource])
+		if severity := deps[i].MaxVulnerabilitySeverity(); severity > res {
+			res = severity
+		}
+	}
+	return res
+}
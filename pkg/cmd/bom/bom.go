@@ -9,17 +9,17 @@
 package bom
 
 import (
+	"errors"
 	"fmt"
-	"path/filepath"
-	"strings"
+	"strconv"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/codenotary/cas/pkg/bom"
 	"github.com/codenotary/cas/pkg/bom/artifact"
-	"github.com/codenotary/cas/pkg/bom/docker"
-	"github.com/codenotary/cas/pkg/uri"
+	"github.com/codenotary/cas/pkg/bom/osv"
+	"github.com/codenotary/cas/pkg/meta"
 )
 
 // NewCommand returns the cobra command for `cas info`
@@ -33,6 +33,10 @@ Collect BOM (Bill of Materials) information
 
 It identifies dependencies of build artifact and produces the BOM. Dependencies can be
 later authenticated by 'cas a --bom', and notarized together with artifact by 'cas n --bom'.
+
+Dependencies can be matched against known vulnerabilities from locally mirrored OSV database,
+specified by --vuln-db. The exit code will be 6, if some dependencies have vulnerabilities
+with the severity, specified by --fail-on-severity, or higher.
 `,
 		RunE: runBom,
 		PreRun: func(cmd *cobra.Command, args []string) {
@@ -46,38 +50,48 @@ later authenticated by 'cas a --bom', and notarized together with artifact by 'c
 	}
 
 	// BOM output options
-	cmd.Flags().String("bom-spdx", "", "name of the file to output BOM in SPDX format")
+	cmd.Flags().String("bom-spdx", "", "name of the file to output BOM in SPDX tag-value format")
+	cmd.Flags().String("bom-spdx-json", "", "name of the file to output BOM in SPDX JSON format")
 	cmd.Flags().String("bom-cdx-json", "", "name of the file to output BOM in CycloneDX JSON format")
 	cmd.Flags().String("bom-cdx-xml", "", "name of the file to output BOM in CycloneDX XML format")
+	cmd.Flags().String("bom-cpe-overrides", "", "name of the YAML file with CPE names of packages, overriding generated ones in BOM output")
+	// vulnerability matching options
+	cmd.Flags().String("vuln-db", "", "OSV database (directory, ZIP archive or JSON file) to match dependencies against known vulnerabilities")
+	cmd.Flags().String("fail-on-severity", "", "fail if dependencies have vulnerabilities with this or higher severity: low / medium / high / critical")
+
+	cmd.AddCommand(newDiffCommand())
 
 	return cmd
 }
 
+// ErrVulnerable is returned when dependencies have vulnerabilities with severity, specified by --fail-on-severity, or higher
+var ErrVulnerable = errors.New("some dependencies have vulnerabilities with severity at or above the threshold")
+
 func runBom(cmd *cobra.Command, args []string) error {
-	path := args[0]
-	u, err := uri.Parse(path)
-	if err != nil {
-		return err
-	}
-	if _, ok := bom.BomSchemes[u.Scheme]; !ok {
-		return fmt.Errorf("unsupported URI %s for --bom option", path)
-	}
-	if u.Scheme != "" {
-		path = strings.TrimPrefix(u.Opaque, "//")
+	failSeverity := artifact.SeverityUnknown
+	if text := viper.GetString("fail-on-severity"); text != "" {
+		var ok bool
+		failSeverity, ok = artifact.SeverityByName(text)
+		if !ok || failSeverity == artifact.SeverityUnknown {
+			return fmt.Errorf("invalid severity, supported values are low/medium/high/critical")
+		}
+		if viper.GetString("vuln-db") == "" {
+			return fmt.Errorf("--fail-on-severity requires --vuln-db")
+		}
 	}
 
-	var bomArtifact artifact.Artifact
-	if u.Scheme == "docker" {
-		bomArtifact, err = docker.New(path)
-		if err != nil {
-			return err
-		}
-	} else {
-		path, err = filepath.Abs(path)
+	var db *osv.Database
+	if path := viper.GetString("vuln-db"); path != "" {
+		var err error
+		db, err = osv.Load(path)
 		if err != nil {
-			return err
+			return fmt.Errorf("cannot load vulnerability database: %w", err)
 		}
-		bomArtifact = bom.New(path)
+	}
+
+	bomArtifact, err := bom.NewFromURI(args[0])
+	if err != nil {
+		return err
 	}
 	if bomArtifact == nil {
 		return fmt.Errorf("unsupported artifact format/language")
@@ -94,7 +108,25 @@ func runBom(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("cannot get dependencies: %w", err)
 	}
 
-	artifact.Display(bomArtifact, artifact.ColNameVersion)
+	columns := artifact.ColNameVersion
+	vulnerable := false
+	if db != nil {
+		columns |= artifact.ColVulnerabilities
+		severity := bom.MatchVulnerabilities(bomArtifact, db)
+		vulnerable = failSeverity != artifact.SeverityUnknown && severity >= failSeverity
+	}
+
+	artifact.Display(bomArtifact, columns)
 
-	return bom.Output(bomArtifact) // process all possible BOM output options
+	err = bom.Output(bomArtifact, "") // process all possible BOM output options
+	if err != nil {
+		return err
+	}
+
+	if vulnerable {
+		cmd.SilenceUsage = true
+		viper.Set("exit-code", strconv.Itoa(meta.CasBomVulnerableExitCode))
+		return ErrVulnerable
+	}
+	return nil
 }
@@ -0,0 +1,368 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package bom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	caserr "github.com/codenotary/cas/internal/errors"
+	"github.com/codenotary/cas/pkg/api"
+	"github.com/codenotary/cas/pkg/bom"
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/cmd/verify"
+	"github.com/codenotary/cas/pkg/meta"
+	"github.com/codenotary/cas/pkg/signature"
+)
+
+// possible actions for the package in BOM diff
+const (
+	actionAdded       = "added"
+	actionRemoved     = "removed"
+	actionChanged     = "changed"
+	actionHashChanged = "hash_changed"
+)
+
+// ErrPackagesChanged is returned when some packages were added to or removed from BOM
+var ErrPackagesChanged = errors.New("packages were added or removed")
+
+type diff struct {
+	Counters counters  `json:"counters"`
+	Pkg      []pkgDiff `json:"packages"`
+}
+
+type pkgDiff struct {
+	Name string      `json:"name"`
+	Kind string      `json:"kind,omitempty"`
+	Base *pkgDetails `json:"base,omitempty"`
+	Diff *pkgDetails `json:"diff,omitempty"`
+}
+
+type pkgDetails struct {
+	Action    string `json:"action,omitempty"` // only in `diff`, always empty in `base`
+	Version   string `json:"version"`
+	Hash      string `json:"hash,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Timestamp string `json:"when,omitempty"`
+}
+
+type counters struct {
+	Unchanged   int `json:"unchanged"`
+	Removed     int `json:"removed"`
+	Added       int `json:"added"`
+	VerChanged  int `json:"version_changed"`
+	HashChanged int `json:"hash_changed"`
+}
+
+// newDiffCommand returns the cobra command for `cas bom diff`
+func newDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "diff",
+		Example: "  cas bom diff docker://app:1.0 docker://app:1.1\n  cas bom diff --base-hash <hash> docker://app:1.1",
+		Short:   "Compare BOMs of two assets",
+		Long: `
+Compare BOMs (Bill of Materials) of two assets
+
+Each of the compared BOMs is either resolved from the local asset, loaded from existing
+SPDX/CycloneDX document, or loaded from CAS for the asset, notarized with BOM. Base BOM
+is specified by the first ARG or by --base-hash, new BOM by the next ARG or by --hash.
+
+Packages are matched by kind, name and version, so assets may contain several versions
+of the same package. Version of the package is considered changed, if the version is
+missing in one of BOMs, while another version of the same package is present there.
+
+The exit code will be 0 if no packages were added or removed. Otherwise, it is 5.
+`,
+		RunE: runDiff,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			// Bind to all flags to env vars (after flags were parsed),
+			// but only ones retrivied by using viper will be used.
+			viper.BindPFlags(cmd.Flags())
+		},
+		Args: func(cmd *cobra.Command, args []string) error {
+			sides := len(args)
+			for _, flag := range []string{"base-hash", "hash"} {
+				if hash, _ := cmd.Flags().GetString(flag); hash != "" {
+					sides++
+				}
+			}
+			if sides != 2 {
+				return fmt.Errorf("exactly two BOMs must be specified, use ARG(s), --base-hash or --hash")
+			}
+			return nil
+		},
+	}
+
+	cmd.SetUsageTemplate(
+		strings.Replace(cmd.UsageTemplate(), "{{.UseLine}}", "{{.UseLine}} ARG(s)", 1),
+	)
+
+	cmd.Flags().String("base-hash", "", "hash of the notarized asset to load base BOM from CAS")
+	cmd.Flags().String("hash", "", "hash of the notarized asset to load new BOM from CAS")
+	cmd.Flags().StringSliceP("signerID", "s", nil, "use BOM, notarized by the passed SignerID, when loading BOM from CAS")
+	cmd.Flags().String("host", "", meta.CasHostFlagDesc)
+	cmd.Flags().String("port", "", meta.CasPortFlagDesc)
+	cmd.Flags().String("cert", "", meta.CasCertPathDesc)
+	cmd.Flags().Bool("skip-tls-verify", false, meta.CasSkipTlsVerifyDesc)
+	cmd.Flags().Bool("no-tls", false, meta.CasNoTlsDesc)
+	cmd.Flags().String("api-key", "", meta.CasApiKeyDesc)
+	cmd.Flags().String("ledger", "", meta.CasLedgerDesc)
+	cmd.Flags().String("signing-pub-key-file", "", meta.CasSigningPubKeyFileNameDesc)
+	cmd.Flags().String("signing-pub-key", "", meta.CasSigningPubKeyDesc)
+
+	return cmd
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	outputOpts := artifact.Progress
+	if viper.GetBool("silent") || output != "" {
+		outputOpts = artifact.Silent
+	}
+
+	var lcUser *api.LcUser
+	load := func(hash string) (artifact.Artifact, error) {
+		if lcUser == nil {
+			lcUser, err = newLcUser()
+			if err != nil {
+				return nil, err
+			}
+		}
+		var signerID string
+		if signerIDs := verify.GetSignerIDs(); len(signerIDs) > 0 {
+			signerID = signerIDs[0]
+		}
+		return verify.LoadBomFromDb(strings.ToLower(hash), signerID, lcUser)
+	}
+
+	var sides []artifact.Artifact
+	for _, flag := range []string{"base-hash", "hash"} {
+		if hash := viper.GetString(flag); hash != "" {
+			if flag == "hash" && len(sides) == 0 {
+				// base BOM is specified by ARG
+				a, err := resolveBom(args[0], outputOpts)
+				if err != nil {
+					return err
+				}
+				sides = append(sides, a)
+				args = args[1:]
+			}
+			a, err := load(hash)
+			if err != nil {
+				return err
+			}
+			sides = append(sides, a)
+		}
+	}
+	for _, path := range args {
+		a, err := resolveBom(path, outputOpts)
+		if err != nil {
+			return err
+		}
+		sides = append(sides, a)
+	}
+
+	for _, a := range sides {
+		if len(a.Dependencies()) == 0 {
+			return fmt.Errorf("artifact %s has no dependencies - nothing to compare", a.Path())
+		}
+	}
+
+	res := diffBOMs(sides[0].Dependencies(), sides[1].Dependencies())
+	if output == "json" {
+		out, _ := json.MarshalIndent(res, "", "  ")
+		fmt.Printf("%s\n", out)
+	} else {
+		displayDiff(res)
+	}
+
+	if res.Counters.Added > 0 || res.Counters.Removed > 0 {
+		viper.Set("exit-code", strconv.Itoa(meta.CasBomDiffExitCode))
+		if output != "" {
+			cmd.SilenceErrors = true // error must not break structured output
+		}
+		return ErrPackagesChanged
+	}
+	return nil
+}
+
+func resolveBom(path string, outputOpts artifact.OutputOptions) (artifact.Artifact, error) {
+	a, err := bom.NewFromURI(path)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, fmt.Errorf("unsupported artifact format/language")
+	}
+	if outputOpts != artifact.Silent {
+		fmt.Printf("Resolving dependencies of %s...\n", path)
+	}
+	_, err = a.ResolveDependencies(outputOpts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get dependencies: %w", err)
+	}
+	return a, nil
+}
+
+func newLcUser() (*api.LcUser, error) {
+	lcApiKey := viper.GetString("api-key")
+	if lcApiKey == "" && len(verify.GetSignerIDs()) == 0 {
+		return nil, caserr.ErrPubAuthNoSignerID
+	}
+	signingPubKey, _, err := signature.PrepareSignatureParams(
+		viper.GetString("signing-pub-key"),
+		viper.GetString("signing-pub-key-file"))
+	if err != nil {
+		return nil, err
+	}
+	return api.GetOrCreateLcUser(lcApiKey, viper.GetString("ledger"), viper.GetString("host"), viper.GetString("port"),
+		viper.GetString("cert"), viper.IsSet("skip-tls-verify"), viper.GetBool("skip-tls-verify"),
+		viper.IsSet("no-tls"), viper.GetBool("no-tls"), signingPubKey, lcApiKey == "")
+}
+
+type depKey struct {
+	kind string
+	name string
+}
+
+// diffBOMs compares the dependencies of base and new BOMs. Packages are matched by kind and name. Versions,
+// present in both BOMs, are compared by hash. Remaining versions of the package are paired in version order
+// and reported as changed, the rest is reported as added or removed
+func diffBOMs(first, second []artifact.Dependency) diff {
+	group := func(deps []artifact.Dependency) map[depKey][]*artifact.Dependency {
+		res := make(map[depKey][]*artifact.Dependency, len(deps))
+		for i := range deps {
+			key := depKey{kind: deps[i].Kind, name: deps[i].Name}
+			res[key] = append(res[key], &deps[i])
+		}
+		return res
+	}
+	baseDeps := group(first)
+	newDeps := group(second)
+
+	keys := make([]depKey, 0, len(baseDeps)+len(newDeps))
+	for key := range baseDeps {
+		keys = append(keys, key)
+	}
+	for key := range newDeps {
+		if _, ok := baseDeps[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].kind < keys[j].kind
+	})
+
+	res := diff{Pkg: []pkgDiff{}}
+	for _, key := range keys {
+		var removed []*artifact.Dependency
+		added := make(map[string]*artifact.Dependency, len(newDeps[key]))
+		for _, dep := range newDeps[key] {
+			added[dep.Version] = dep
+		}
+		for _, b := range baseDeps[key] {
+			n, ok := added[b.Version]
+			if !ok {
+				removed = append(removed, b)
+				continue
+			}
+			delete(added, b.Version)
+			if b.Hash == n.Hash {
+				res.Counters.Unchanged++
+				continue
+			}
+			res.Counters.HashChanged++
+			res.Pkg = append(res.Pkg, pkgDiff{Name: key.name, Kind: key.kind,
+				Base: newPkgDetails(b, ""), Diff: newPkgDetails(n, actionHashChanged)})
+		}
+
+		var remaining []*artifact.Dependency
+		for _, dep := range newDeps[key] {
+			if _, ok := added[dep.Version]; ok {
+				remaining = append(remaining, dep)
+			}
+		}
+		sortVersions(removed, key.kind)
+		sortVersions(remaining, key.kind)
+		for len(removed) > 0 && len(remaining) > 0 {
+			res.Counters.VerChanged++
+			res.Pkg = append(res.Pkg, pkgDiff{Name: key.name, Kind: key.kind,
+				Base: newPkgDetails(removed[0], ""), Diff: newPkgDetails(remaining[0], actionChanged)})
+			removed, remaining = removed[1:], remaining[1:]
+		}
+		for _, dep := range removed {
+			res.Counters.Removed++
+			res.Pkg = append(res.Pkg, pkgDiff{Name: key.name, Kind: key.kind,
+				Base: newPkgDetails(dep, ""), Diff: &pkgDetails{Version: "none", Status: "none", Action: actionRemoved}})
+		}
+		for _, dep := range remaining {
+			res.Counters.Added++
+			res.Pkg = append(res.Pkg, pkgDiff{Name: key.name, Kind: key.kind,
+				Diff: newPkgDetails(dep, actionAdded)})
+		}
+	}
+
+	return res
+}
+
+func newPkgDetails(dep *artifact.Dependency, action string) *pkgDetails {
+	res := &pkgDetails{
+		Action:  action,
+		Version: dep.Version,
+		Hash:    dep.Hash,
+		Status:  artifact.TrustLevelName(dep.TrustLevel),
+	}
+	if !dep.Timestamp.IsZero() {
+		res.Timestamp = dep.Timestamp.Format(time.RFC3339)
+	}
+	return res
+}
+
+// sortVersions sorts dependencies of given kind by version, using the version ordering of their ecosystem
+func sortVersions(deps []*artifact.Dependency, kind string) {
+	sort.Slice(deps, func(i, j int) bool { return bom.CompareVersions(kind, deps[i].Version, deps[j].Version) < 0 })
+}
+
+func displayDiff(res diff) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, p := range res.Pkg {
+		switch p.Diff.Action {
+		case actionAdded:
+			fmt.Fprintf(w, "+\t%s\t%s\t\n", p.Name, p.Diff.Version)
+		case actionRemoved:
+			fmt.Fprintf(w, "-\t%s\t%s\t\n", p.Name, p.Base.Version)
+		case actionChanged:
+			fmt.Fprintf(w, "~\t%s\t%s -> %s\t\n", p.Name, p.Base.Version, p.Diff.Version)
+		case actionHashChanged:
+			fmt.Fprintf(w, "!\t%s\t%s\thash %s -> %s\n", p.Name, p.Diff.Version, p.Base.Hash, p.Diff.Hash)
+		}
+	}
+	w.Flush()
+	fmt.Printf("\n%d unchanged, %d added, %d removed, %d version changed, %d hash changed\n",
+		res.Counters.Unchanged, res.Counters.Added, res.Counters.Removed,
+		res.Counters.VerChanged, res.Counters.HashChanged)
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package bom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+)
+
+func TestDiffBOMs(t *testing.T) {
+	base := []artifact.Dependency{
+		{Name: "libssl", Version: "1.1", Hash: "a1", Kind: "dpkg"},
+		{Name: "libssl", Version: "3.0", Hash: "a3", Kind: "dpkg"},
+		{Name: "zlib", Version: "1.2", Hash: "z1", Kind: "dpkg"},
+		{Name: "curl", Version: "7.0", Hash: "c7", Kind: "dpkg"},
+		{Name: "lodash", Version: "4.17.20", Hash: "l0", Kind: "npm"},
+	}
+	second := []artifact.Dependency{
+		{Name: "libssl", Version: "3.0", Hash: "a3", Kind: "dpkg"},
+		{Name: "libssl", Version: "3.1", Hash: "a4", Kind: "dpkg"},
+		{Name: "zlib", Version: "1.2", Hash: "z2", Kind: "dpkg"},
+		{Name: "lodash", Version: "4.17.21", Hash: "l1", Kind: "npm"},
+		{Name: "lodash", Version: "4.17.20", Hash: "l0", Kind: "pypi"},
+	}
+
+	res := diffBOMs(base, second)
+	assert.Equal(t, counters{Unchanged: 1, Removed: 1, Added: 1, VerChanged: 2, HashChanged: 1}, res.Counters)
+	assert.Equal(t, []pkgDiff{{
+		Name: "curl",
+		Kind: "dpkg",
+		Base: &pkgDetails{Version: "7.0", Hash: "c7"},
+		Diff: &pkgDetails{Action: actionRemoved, Version: "none", Status: "none"},
+	}, {
+		Name: "libssl",
+		Kind: "dpkg",
+		Base: &pkgDetails{Version: "1.1", Hash: "a1"},
+		Diff: &pkgDetails{Action: actionChanged, Version: "3.1", Hash: "a4"},
+	}, {
+		Name: "lodash",
+		Kind: "npm",
+		Base: &pkgDetails{Version: "4.17.20", Hash: "l0"},
+		Diff: &pkgDetails{Action: actionChanged, Version: "4.17.21", Hash: "l1"},
+	}, {
+		Name: "lodash",
+		Kind: "pypi",
+		Diff: &pkgDetails{Action: actionAdded, Version: "4.17.20", Hash: "l0"},
+	}, {
+		Name: "zlib",
+		Kind: "dpkg",
+		Base: &pkgDetails{Version: "1.2", Hash: "z1"},
+		Diff: &pkgDetails{Action: actionHashChanged, Version: "1.2", Hash: "z2"},
+	}}, res.Pkg)
+
+	res = diffBOMs(base, base)
+	assert.Equal(t, counters{Unchanged: len(base)}, res.Counters)
+	assert.Empty(t, res.Pkg)
+}
+
+func TestDiffBOMsVersionOrder(t *testing.T) {
+	// versions are paired in ecosystem order, not in string order, where 1.10 precedes 1.9
+	base := []artifact.Dependency{
+		{Name: "libssl", Version: "1.10-1", Hash: "a10", Kind: "dpkg"},
+		{Name: "libssl", Version: "1.9-1", Hash: "a9", Kind: "dpkg"},
+	}
+	second := []artifact.Dependency{
+		{Name: "libssl", Version: "1.12-1", Hash: "a12", Kind: "dpkg"},
+		{Name: "libssl", Version: "1.11-1", Hash: "a11", Kind: "dpkg"},
+	}
+
+	res := diffBOMs(base, second)
+	assert.Equal(t, counters{VerChanged: 2}, res.Counters)
+	changes := make(map[string]string)
+	for _, p := range res.Pkg {
+		changes[p.Base.Version] = p.Diff.Version
+	}
+	assert.Equal(t, map[string]string{"1.9-1": "1.11-1", "1.10-1": "1.12-1"}, changes)
+}
@@ -12,10 +12,15 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+
+	"github.com/codenotary/cas/pkg/bom"
 )
 
 func noArgsWhenHashOrPipe(cmd *cobra.Command, args []string) error {
 	if hash, _ := cmd.Flags().GetString("hash"); hash != "" {
+		if len(args) == 1 && bom.IsBOMOnlyURI(args[0]) {
+			return nil // BOM of the asset is loaded from existing document, image or file system
+		}
 		if len(args) > 0 {
 			return fmt.Errorf("cannot use ARG(s) with --hash")
 		}
@@ -12,7 +12,6 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/codenotary/cas/pkg/signature"
@@ -20,12 +19,10 @@ import (
 	"github.com/codenotary/cas/pkg/api"
 	"github.com/codenotary/cas/pkg/bom"
 	"github.com/codenotary/cas/pkg/bom/artifact"
-	"github.com/codenotary/cas/pkg/bom/docker"
 	"github.com/codenotary/cas/pkg/cicontext"
 	"github.com/codenotary/cas/pkg/cmd/verify"
 	"github.com/codenotary/cas/pkg/extractor"
 	"github.com/codenotary/cas/pkg/meta"
-	"github.com/codenotary/cas/pkg/uri"
 	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -50,10 +47,13 @@ func NewCommand() *cobra.Command {
 	cmd.Flags().Bool("bom", false, "auto-notarize asset dependencies and link dependencies to the asset")
 	cmd.Flags().String("bom-signerID", "", "signerID to use for authenticating dependencies")
 	cmd.Flags().Uint("bom-batch-size", 10, "By default BOM dependencies are authenticated/notarized in batches of up to 10 dependencies each. Use this flag to set a different batch size. A value of 0 will disable batching (all dependencies will be authenticated/notarized at once).")
+	cmd.Flags().Uint("bom-concurrency", artifact.MaxGoroutines, "maximum number of BOM dependency batches, authenticated/notarized at the same time. A value of 0 or 1 will process batches one after another.")
 	// BOM output options
-	cmd.Flags().String("bom-spdx", "", "name of the file to output BOM in SPDX format")
+	cmd.Flags().String("bom-spdx", "", "name of the file to output BOM in SPDX tag-value format")
+	cmd.Flags().String("bom-spdx-json", "", "name of the file to output BOM in SPDX JSON format")
 	cmd.Flags().String("bom-cdx-json", "", "name of the file to output BOM in CycloneDX JSON format")
 	cmd.Flags().String("bom-cdx-xml", "", "name of the file to output BOM in CycloneDX XML format")
+	cmd.Flags().String("bom-cpe-overrides", "", "name of the YAML file with CPE names of packages, overriding generated ones in BOM output")
 	return cmd
 }
 
@@ -195,9 +195,13 @@ func runSignWithState(cmd *cobra.Command, args []string, state meta.Status) erro
 	bomFlag := viper.GetBool("bom") ||
 		viper.IsSet("bom-signerID") ||
 		viper.IsSet("bom-spdx") ||
+		viper.IsSet("bom-spdx-json") ||
 		viper.IsSet("bom-cdx-json") ||
 		viper.IsSet("bom-cdx-xml") ||
-		viper.IsSet("bom-batch-size")
+		viper.IsSet("bom-cpe-overrides") ||
+		viper.IsSet("bom-batch-size") ||
+		viper.IsSet("bom-concurrency") ||
+		(len(args) == 1 && bom.IsBOMOnlyURI(args[0]))
 
 	artifacts := make([]*api.Artifact, 0, 1)
 
@@ -237,32 +241,21 @@ func runSignWithState(cmd *cobra.Command, args []string, state meta.Status) erro
 		if len(args) != 1 {
 			return fmt.Errorf("--bom option can be used only with single asset")
 		}
-		path := args[0]
-		u, err := uri.Parse(path)
+		var err error
+		bomArtifact, err = bom.NewFromURI(args[0])
 		if err != nil {
 			return err
 		}
-		if _, ok := bom.BomSchemes[u.Scheme]; !ok {
-			return fmt.Errorf("unsupported URI %s for --bom option", path)
-		}
-		if u.Scheme != "" {
-			path = strings.TrimPrefix(u.Opaque, "//")
-		}
-		if u.Scheme == "docker" {
-			bomArtifact, err = docker.New(path)
-			if err != nil {
-				return err
-			}
-		} else {
-			path, err = filepath.Abs(path)
-			if err != nil {
-				return err
-			}
-			bomArtifact = bom.New(path)
-		}
 		if bomArtifact == nil {
 			return fmt.Errorf("unsupported asset format/language")
 		}
+		if bom.IsBOMOnlyURI(args[0]) && hash == "" {
+			// image ID is known for images, read without Docker daemon
+			hash = artifact.AssetHash(bomArtifact)
+			if hash == "" {
+				return fmt.Errorf("please specify the hash of the asset, described by BOM source %s, by using --hash", args[0])
+			}
+		}
 
 		if outputOpts != artifact.Silent {
 			fmt.Printf("Resolving dependencies...\n")
@@ -273,17 +266,13 @@ func runSignWithState(cmd *cobra.Command, args []string, state meta.Status) erro
 		}
 
 		bomBatchSize := int(viper.GetUint("bom-batch-size"))
+		bomConcurrency := int(viper.GetUint("bom-concurrency"))
 
-		bomLinks, err = notarizeDeps(lcUser, deps, outputOpts, bomArtifact.Type(), bomBatchSize)
+		bomLinks, err = notarizeDeps(lcUser, deps, outputOpts, bomArtifact.Type(), bomBatchSize, bomConcurrency)
 		if err != nil {
 			return err
 		}
 
-		err = bom.Output(bomArtifact) // process all possible BOM output options
-		if err != nil {
-			// show warning, but not error, because authentication finished
-			fmt.Fprintln(os.Stderr, err)
-		}
 		if outputOpts != artifact.Silent {
 			artifact.Display(bomArtifact, artifact.ColNameVersion|artifact.ColHash|artifact.ColTrustLevel)
 		}
@@ -303,6 +292,9 @@ func runSignWithState(cmd *cobra.Command, args []string, state meta.Status) erro
 				Metadata:    ar.Metadata,
 			})
 		} else {
+			if name == "" && bomArtifact != nil && bom.IsBOMOnlyURI(args[0]) {
+				name = bomArtifact.Path() // the asset, described by the document, the image or the file system
+			}
 			if name == "" {
 				return fmt.Errorf("please set an asset name, by using --name")
 			}
@@ -316,6 +308,12 @@ func runSignWithState(cmd *cobra.Command, args []string, state meta.Status) erro
 	}
 	if bomArtifact != nil {
 		artifacts[0].Deps = verify.DepsToPackageDetails(bomArtifact.Dependencies())
+		// BOM output is produced once the asset hash is known
+		err = bom.Output(bomArtifact, artifacts[0].Hash) // process all possible BOM output options
+		if err != nil {
+			// show warning, but not error, because dependencies are already notarized
+			fmt.Fprintln(os.Stderr, err)
+		}
 	}
 	err = LcSign(lcUser, artifacts, state, output, name, metadata, lcVerbose, bomLinks)
 	if err != nil {
@@ -330,7 +328,7 @@ func pipeMode() bool {
 	return fileInfo.Mode()&os.ModeCharDevice == 0
 }
 
-func notarizeDeps(lcUser *api.LcUser, deps []artifact.Dependency, outputOpts artifact.OutputOptions, artType string, batchSize int) ([]*schema.VCNDependency, error) {
+func notarizeDeps(lcUser *api.LcUser, deps []artifact.Dependency, outputOpts artifact.OutputOptions, artType string, batchSize, concurrency int) ([]*schema.VCNDependency, error) {
 	if outputOpts != artifact.Silent {
 		fmt.Printf("Authenticating dependencies...\n")
 	}
@@ -351,7 +349,7 @@ func notarizeDeps(lcUser *api.LcUser, deps []artifact.Dependency, outputOpts art
 		}
 	}
 
-	errs, err := artifact.AuthenticateDependencies(lcUser, signerID, deps, batchSize, progressCallback)
+	errs, err := artifact.AuthenticateDependencies(lcUser, signerID, deps, batchSize, concurrency, progressCallback)
 	if err != nil {
 		return nil, fmt.Errorf("error authenticating dependencies: %w", err)
 	}
@@ -371,7 +369,12 @@ func notarizeDeps(lcUser *api.LcUser, deps []artifact.Dependency, outputOpts art
 		}
 		if deps[i].TrustLevel < artifact.Trusted {
 			depsToNotarize = append(depsToNotarize, &deps[i])
-			kinds = append(kinds, artType)
+			// artifacts like container images have dependencies of different kinds
+			kind := deps[i].Kind
+			if kind == "" {
+				kind = artType
+			}
+			kinds = append(kinds, kind)
 		}
 	}
 
@@ -403,7 +406,7 @@ func notarizeDeps(lcUser *api.LcUser, deps []artifact.Dependency, outputOpts art
 			}
 		}
 
-		err = artifact.NotarizeDependencies(lcUser, kinds, depsToNotarize, batchSize, progressCallbackN)
+		err = artifact.NotarizeDependencies(lcUser, kinds, depsToNotarize, batchSize, concurrency, progressCallbackN)
 		if err != nil {
 			return nil, fmt.Errorf("error notarizing dependencies: %w", err)
 		}
@@ -14,11 +14,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/viper"
 	"google.golang.org/grpc/metadata"
@@ -26,9 +25,8 @@ import (
 	"github.com/codenotary/cas/pkg/api"
 	"github.com/codenotary/cas/pkg/bom"
 	"github.com/codenotary/cas/pkg/bom/artifact"
-	"github.com/codenotary/cas/pkg/bom/docker"
+	"github.com/codenotary/cas/pkg/bom/license"
 	"github.com/codenotary/cas/pkg/meta"
-	"github.com/codenotary/cas/pkg/uri"
 	immuschema "github.com/codenotary/immudb/pkg/api/schema"
 )
 
@@ -54,13 +52,25 @@ var bomTrustLevelToMeta = map[artifact.TrustLevel]meta.Status{
 }
 
 var ErrInsufficientTrustLevel = errors.New("some dependencies have insufficient trust level")
+var ErrLicensePolicyViolation = errors.New("licenses of some dependencies violate license policy")
 
+// processBOM authenticates dependencies of the asset. If path is empty, BOM of the asset with the hash is loaded
+// from CAS, otherwise it is resolved from the path. Hash, if known, identifies the asset in BOM output
 func processBOM(lcUser *api.LcUser, signerID, output, hash, path string) (artifact.Artifact, error) {
 	trustLevel, ok := trustLevelMap[viper.GetString("bom-trust-level")]
 	if !ok {
 		return nil, fmt.Errorf("invalid BOM trust level, supported values are trusted/unknown/unsupported/untrusted")
 	}
 
+	var policy *license.Policy
+	if policyFile := viper.GetString("license-policy"); policyFile != "" {
+		var err error
+		policy, err = license.LoadPolicy(policyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	outputOpts := artifact.Progress
 	if viper.GetBool("silent") || output != "" {
 		outputOpts = artifact.Silent
@@ -69,40 +79,29 @@ func processBOM(lcUser *api.LcUser, signerID, output, hash, path string) (artifa
 	var bomArtifact artifact.Artifact
 	var deps []artifact.Dependency
 	var err error
-	if hash != "" {
-		// hash specified - resolve dependencies from DB
-		bomArtifact, err = loadBomFromDb(hash, signerID, lcUser)
+	if path == "" {
+		// no BOM source - resolve dependencies from DB
+		bomArtifact, err = LoadBomFromDb(hash, signerID, lcUser)
 		if err != nil {
 			return nil, err
 		}
 		deps = bomArtifact.Dependencies()
 	} else {
 		// resolve dependencies from the asset
-		u, err := uri.Parse(path)
+		bomArtifact, err = bom.NewFromURI(path)
 		if err != nil {
 			return nil, err
 		}
-		if _, ok := bom.BomSchemes[u.Scheme]; !ok {
-			return nil, fmt.Errorf("unsupported URI %s for --bom option", path)
-		}
-		if u.Scheme != "" {
-			path = strings.TrimPrefix(u.Opaque, "//")
-		}
-		if u.Scheme == "docker" {
-			bomArtifact, err = docker.New(path)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			path, err = filepath.Abs(path)
-			if err != nil {
-				return nil, err
-			}
-			bomArtifact = bom.New(path)
-		}
 		if bomArtifact == nil {
 			return nil, fmt.Errorf("unsupported artifact format/language")
 		}
+		if bom.IsBOMOnlyURI(path) && hash == "" {
+			// image ID is known for images, read without Docker daemon
+			hash = artifact.AssetHash(bomArtifact)
+			if hash == "" {
+				return nil, fmt.Errorf("please specify the hash of the asset, described by BOM source %s, by using --hash", path)
+			}
+		}
 		if signerID == "" {
 			signerID = api.GetSignerIDByApiKey(lcUser.Client.ApiKey)
 		}
@@ -135,8 +134,9 @@ func processBOM(lcUser *api.LcUser, signerID, output, hash, path string) (artifa
 	}
 
 	bomBatchSize := int(viper.GetUint("bom-batch-size"))
+	bomConcurrency := int(viper.GetUint("bom-concurrency"))
 
-	errs, err := artifact.AuthenticateDependencies(lcUser, signerID, deps, bomBatchSize, progressCallback)
+	errs, err := artifact.AuthenticateDependencies(lcUser, signerID, deps, bomBatchSize, bomConcurrency, progressCallback)
 	if err != nil {
 		return nil, fmt.Errorf("error authenticating dependencies: %w", err)
 	}
@@ -166,21 +166,61 @@ func processBOM(lcUser *api.LcUser, signerID, output, hash, path string) (artifa
 		failed = true // keep going - user still may need output files
 	}
 
-	err = bom.Output(bomArtifact)
+	violations := 0
+	if policy != nil {
+		for i := range deps {
+			deps[i].LicenseViolation = policy.Check(deps[i].Name, deps[i].License)
+			if deps[i].LicenseViolation != "" {
+				violations++
+			}
+		}
+		if violations > 0 && output == "" {
+			displayLicenseViolations(deps)
+		}
+	}
+
+	err = bom.Output(bomArtifact, hash)
 	if err != nil {
 		// show warning, but not error, because authentication finished
 		fmt.Fprintln(os.Stderr, err)
 	}
 
+	exitCode, err := bomResult(failed, lowestLevel, violations)
+	if err != nil {
+		viper.Set("exit-code", strconv.Itoa(exitCode))
+	}
+	return bomArtifact, err
+}
+
+// bomResult returns the exit code and the error of BOM authentication. Insufficient trust level takes precedence
+// over license policy violations, as untrusted dependencies must not be used regardless of their licenses, so
+// license violation exit code is returned only if trust levels of all dependencies are sufficient
+func bomResult(failed bool, lowestLevel artifact.TrustLevel, violations int) (int, error) {
 	if failed {
-		viper.Set("exit-code", strconv.Itoa(bomTrustLevelToMeta[lowestLevel].Int()))
-		return bomArtifact, ErrInsufficientTrustLevel
+		return bomTrustLevelToMeta[lowestLevel].Int(), ErrInsufficientTrustLevel
 	}
+	if violations > 0 {
+		return meta.CasLicenseViolationExitCode, ErrLicensePolicyViolation
+	}
+	return meta.CasDefaultExitCode, nil
+}
 
-	return bomArtifact, nil
+func displayLicenseViolations(deps []artifact.Dependency) {
+	fmt.Println("License policy violations:")
+	for _, dep := range deps {
+		if dep.LicenseViolation == "" {
+			continue
+		}
+		declared := dep.License
+		if declared == "" {
+			declared = "NOASSERTION"
+		}
+		fmt.Printf("  %s@%s (%s): %s\n", dep.Name, dep.Version, declared, color.RedString(dep.LicenseViolation))
+	}
 }
 
-func loadBomFromDb(hash string, signerID string, lcUser *api.LcUser) (artifact.Artifact, error) {
+// LoadBomFromDb loads the asset, specified by hash, together with its dependencies from CAS
+func LoadBomFromDb(hash string, signerID string, lcUser *api.LcUser) (artifact.Artifact, error) {
 	md := metadata.Pairs(meta.CasPluginTypeHeaderName, meta.CasPluginTypeHeaderValue)
 	ctx := metadata.NewOutgoingContext(context.Background(), md)
 	if signerID == "" {
@@ -269,108 +309,9 @@ func DepsToPackageDetails(deps []artifact.Dependency) []api.PackageDetails {
 			Hash:    deps.Hash,
 			Status:  bomTrustLevelToMeta[deps.TrustLevel],
 			License: deps.License,
-		})
-	}
-	return res
-}
-
-type diff struct {
-	Counters counters  `json:"counters"`
-	Pkg      []pkgDiff `json:"packages"`
-}
-
-type pkgDiff struct {
-	Name string      `json:"name"`
-	Base *pkgDetails `json:"base,omitempty"`
-	Diff *pkgDetails `json:"diff,omitempty"`
-}
-
-type pkgDetails struct {
-	Action    string `json:"action,omitempty"` // only in `diff`, always empty in `base`
-	Version   string `json:"version"`
-	Status    string `json:"status"`
-	Timestamp string `json:"when,omitempty"`
-}
-
-type counters struct {
-	Unchanged   int `json:"unchanged"`
-	Removed     int `json:"removed"`
-	Added       int `json:"added"`
-	VerChanged  int `json:"version_changed"`
-	HashChanged int `json:"hash_changed"`
-}
-
-// compare BOMs, output the difference
-// TODO currently this function assumes that artifact may have only one instance of the dependency,
-// multiple instances with different versions are not supported
-func diffBOMs(first, second artifact.Artifact) error {
-	firstDeps := first.Dependencies()
-	if len(firstDeps) == 0 {
-		return fmt.Errorf("artifact %s has no dependencies - nothing to compare", first.Path())
-	}
-	secondDeps := second.Dependencies()
-	if len(secondDeps) == 0 {
-		return fmt.Errorf("artifact %s has no dependencies - nothing to compare", second.Path())
-	}
-	depMap := make(map[string]*artifact.Dependency, len(firstDeps))
-	for i := range firstDeps {
-		depMap[firstDeps[i].Name] = &firstDeps[i]
-	}
 
-	var res diff
-	for _, newDep := range secondDeps {
-		pkg := pkgDiff{Name: newDep.Name}
-		pkg.Diff = &pkgDetails{Version: newDep.Version, Status: artifact.TrustLevelName(newDep.TrustLevel)}
-		if newDep.TrustLevel != artifact.Unknown {
-			pkg.Diff.Timestamp = newDep.Timestamp.Format(time.RFC3339)
-		}
-		base, ok := depMap[newDep.Name]
-		if !ok {
-			pkg.Diff.Action = "added"
-			res.Counters.Added++
-			res.Pkg = append(res.Pkg, pkg)
-		} else {
-			if base.Hash != newDep.Hash {
-				pkg.Base = &pkgDetails{
-					Version:   base.Version,
-					Status:    artifact.TrustLevelName(base.TrustLevel),
-					Timestamp: base.Timestamp.Format(time.RFC3339),
-				}
-				if base.Version != newDep.Version {
-					res.Counters.VerChanged++
-					pkg.Diff.Action = "changed"
-				} else {
-					res.Counters.HashChanged++
-					pkg.Diff.Action = "hash_changed"
-				}
-				res.Pkg = append(res.Pkg, pkg)
-			} else {
-				res.Counters.Unchanged++
-			}
-			delete(depMap, newDep.Name) // to make it has been processed
-		}
-	}
-
-	// anything left in the map was removed
-	for k, v := range depMap {
-		res.Pkg = append(res.Pkg, pkgDiff{
-			Name: k,
-			Base: &pkgDetails{
-				Version:   v.Version,
-				Status:    artifact.TrustLevelName(v.TrustLevel),
-				Timestamp: v.Timestamp.Format(time.RFC3339),
-			},
-			Diff: &pkgDetails{
-				Version: "none",
-				Status:  "none",
-				Action:  "removed",
-			},
+			LicenseViolation: deps.LicenseViolation,
 		})
-		res.Counters.Removed++
 	}
-
-	out, _ := json.MarshalIndent(res, "", "  ")
-	fmt.Printf("%s\n", out)
-
-	return nil
+	return res
 }
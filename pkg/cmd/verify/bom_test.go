@@ -0,0 +1,37 @@
+/*
+ * Copyright (c) 2018-2021 Codenotary, Inc. All Rights Reserved.
+ * This software is released under Apache License 2.0.
+ * The full license information can be found under:
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ */
+
+package verify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codenotary/cas/pkg/bom/artifact"
+	"github.com/codenotary/cas/pkg/meta"
+)
+
+func TestBomResult(t *testing.T) {
+	exitCode, err := bomResult(false, artifact.Trusted, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, meta.CasDefaultExitCode, exitCode)
+
+	exitCode, err = bomResult(false, artifact.Trusted, 2)
+	assert.Equal(t, ErrLicensePolicyViolation, err)
+	assert.Equal(t, meta.CasLicenseViolationExitCode, exitCode)
+
+	exitCode, err = bomResult(true, artifact.Unsupported, 0)
+	assert.Equal(t, ErrInsufficientTrustLevel, err)
+	assert.Equal(t, meta.StatusUnsupported.Int(), exitCode)
+
+	// insufficient trust level takes precedence over license violations
+	exitCode, err = bomResult(true, artifact.Untrusted, 2)
+	assert.Equal(t, ErrInsufficientTrustLevel, err)
+	assert.Equal(t, meta.StatusUntrusted.Int(), exitCode)
+}
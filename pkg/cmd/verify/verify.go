@@ -19,6 +19,7 @@ import (
 
 	caserr "github.com/codenotary/cas/internal/errors"
 	"github.com/codenotary/cas/pkg/api"
+	"github.com/codenotary/cas/pkg/bom"
 	"github.com/codenotary/cas/pkg/bom/artifact"
 	"github.com/codenotary/cas/pkg/extractor"
 	"github.com/codenotary/cas/pkg/meta"
@@ -43,7 +44,8 @@ var (
 	keyRegExp = regexp.MustCompile("0x[0-9a-z]{40}")
 )
 
-func getSignerIDs() []string {
+// GetSignerIDs returns SignerIDs passed by --signerID (or deprecated --key), e-mails are base64-encoded
+func GetSignerIDs() []string {
 	ids := viper.GetStringSlice("signerID")
 	if len(ids) > 0 {
 		for i := range ids {
@@ -81,6 +83,7 @@ Otherwise,
 	Status Unknown:       2
 	Status Unsupported:   3
 	Status ApikeyRevoked: 4
+	License violation:    7 (with --license-policy, if trust levels are sufficient)
 
 Assets are referenced by the passed ARG(s), with authentication accepting
 1 or more ARG(s) at a time. Multiple assets can be authenticated at the
@@ -111,12 +114,15 @@ CAS_ENFORCE_SIGNATURE_VERIFY=
 		},
 		Args: func(cmd *cobra.Command, args []string) error {
 			if org := viper.GetString("org"); org != "" {
-				if keys := getSignerIDs(); len(keys) > 0 {
+				if keys := GetSignerIDs(); len(keys) > 0 {
 					return fmt.Errorf("cannot use both --org and SignerID(s)")
 				}
 			}
 
 			if hash, _ := cmd.Flags().GetString("hash"); hash != "" {
+				if len(args) == 1 && bom.IsBOMOnlyURI(args[0]) {
+					return nil // BOM of the asset is loaded from existing document, image or file system
+				}
 				if len(args) > 0 {
 					return fmt.Errorf("cannot use ARG(s) with --hash")
 				}
@@ -148,15 +154,18 @@ CAS_ENFORCE_SIGNATURE_VERIFY=
 	cmd.Flags().String("bom-trust-level", "trusted", "min trust level: untrusted (unt) / unsupported (uns) / unknown (unk) / trusted (t)")
 	cmd.Flags().Float64("bom-max-unsupported", 0, "max number (in %) of unsupported dependencies")
 	cmd.Flags().Uint("bom-batch-size", 10, "By default BOM dependencies are authenticated/notarized in batches of up to 10 dependencies each. Use this flag to set a different batch size. A value of 0 will disable batching (all dependencies will be authenticated/notarized at once).")
+	cmd.Flags().Uint("bom-concurrency", artifact.MaxGoroutines, "maximum number of BOM dependency batches, authenticated/notarized at the same time. A value of 0 or 1 will process batches one after another.")
 	// BOM output options
-	cmd.Flags().String("bom-spdx", "", "name of the file to output BOM in SPDX format")
+	cmd.Flags().String("bom-spdx", "", "name of the file to output BOM in SPDX tag-value format")
+	cmd.Flags().String("bom-spdx-json", "", "name of the file to output BOM in SPDX JSON format")
 	cmd.Flags().String("bom-cdx-json", "", "name of the file to output BOM in CycloneDX JSON format")
 	cmd.Flags().String("bom-cdx-xml", "", "name of the file to output BOM in CycloneDX XML format")
+	cmd.Flags().String("bom-cpe-overrides", "", "name of the YAML file with CPE names of packages, overriding generated ones in BOM output")
+	cmd.Flags().String("license-policy", "", "name of the YAML file with license policy, dependencies must comply with")
 
 	cmd.Flags().String("signing-pub-key-file", "", meta.CasSigningPubKeyFileNameDesc)
 	cmd.Flags().String("signing-pub-key", "", meta.CasSigningPubKeyDesc)
 	cmd.Flags().Bool("enforce-signature-verify", false, meta.CasEnforceSignatureVerifyDesc)
-	cmd.Flags().MarkHidden("raw-diff")
 
 	return cmd
 }
@@ -200,7 +209,7 @@ func runVerify(cmd *cobra.Command, args []string) error {
 	}
 
 	var signerID string
-	signerIDs := getSignerIDs()
+	signerIDs := GetSignerIDs()
 	if len(signerIDs) > 0 {
 		signerID = signerIDs[0]
 	}
@@ -219,14 +228,22 @@ func runVerify(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// BOM of the asset can be loaded from existing document, image read without Docker daemon, or file
+	// system. Asset is specified by hash, or identified by image ID
+	bomSource := len(args) == 1 && bom.IsBOMOnlyURI(args[0])
 	// any set 'bom-xxx' option, except 'bom-what-includes', implies BOM
 	bomFlag := viper.GetBool("bom") ||
 		viper.IsSet("bom-trust-level") ||
 		viper.IsSet("bom-max-unsupported") ||
 		viper.IsSet("bom-spdx") ||
+		viper.IsSet("bom-spdx-json") ||
 		viper.IsSet("bom-cdx-json") ||
 		viper.IsSet("bom-cdx-xml") ||
-		viper.IsSet("bom-batch-size")
+		viper.IsSet("bom-cpe-overrides") ||
+		viper.IsSet("bom-batch-size") ||
+		viper.IsSet("bom-concurrency") ||
+		viper.IsSet("license-policy") ||
+		bomSource
 
 	if bomFlag {
 		err := lcUser.RequireFeatOrErr(schema.FeatBoM)
@@ -237,27 +254,40 @@ func runVerify(cmd *cobra.Command, args []string) error {
 
 	var bomArtifact artifact.Artifact
 	if bomFlag {
-		if len(hashes)+len(args) > 1 {
+		assets := len(hashes) + len(args)
+		if bomSource && len(hashes) > 0 {
+			assets = len(hashes)
+		}
+		if assets > 1 {
 			return fmt.Errorf("asset selection criteria match several assets - BOM can be processed only for single asset")
 		}
-		if len(hashes)+len(args) < 1 {
+		if assets < 1 {
 			return fmt.Errorf("asset selection criteria don't match any assets - BOM cannot be processed")
 		}
 
-		if len(hashes) > 0 {
+		if bomSource && len(hashes) > 0 {
+			bomArtifact, err = processBOM(lcUser, signerID, output, hashes[0], args[0])
+		} else if len(hashes) > 0 {
 			bomArtifact, err = processBOM(lcUser, signerID, output, hashes[0], "")
 		} else {
 			bomArtifact, err = processBOM(lcUser, signerID, output, "", args[0])
 		}
-		// in case of diff don't stop if some dependencies have insufficient trust level
-		if err != nil && err != ErrInsufficientTrustLevel {
+		// in case of diff don't stop if some dependencies have insufficient trust level or violate license policy
+		if err != nil && err != ErrInsufficientTrustLevel && err != ErrLicensePolicyViolation {
 			return err
 		}
+		if bomSource && len(hashes) == 0 {
+			hashes = []string{artifact.AssetHash(bomArtifact)}
+		}
 	}
 
 	if len(hashes) > 0 {
 		for _, hash := range hashes {
-			err = lcVerify(cmd, &api.Artifact{Hash: hash}, lcUser, signerID, lcUid, lcVerbose, output)
+			a := &api.Artifact{Hash: hash}
+			if bomArtifact != nil {
+				a.Deps = DepsToPackageDetails(bomArtifact.Dependencies())
+			}
+			err = lcVerify(cmd, a, lcUser, signerID, lcUid, lcVerbose, output)
 			if err != nil {
 				return err
 			}
@@ -55,6 +55,17 @@ const (
 
 const CasExitCode string = "override default exit codes in case of success"
 
+// CasBomDiffExitCode is the exit code of `cas bom diff`, when packages were added or removed
+const CasBomDiffExitCode = 5
+
+// CasBomVulnerableExitCode is the exit code of `cas bom`, when dependencies have vulnerabilities with severity,
+// specified by --fail-on-severity, or higher
+const CasBomVulnerableExitCode = 6
+
+// CasLicenseViolationExitCode is the exit code of `cas authenticate`, when licenses of dependencies violate
+// the policy, specified by --license-policy
+const CasLicenseViolationExitCode = 7
+
 const CasPrefix string = "cas"
 
 // Community Attestation Service